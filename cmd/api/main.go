@@ -3,7 +3,6 @@ package main
 import (
 	"context"
 	"fmt"
-	"log"
 	"net/http"
 	"os"
 	"os/signal"
@@ -11,7 +10,9 @@ import (
 	"time"
 
 	"fansly-api/internal/api"
+	"fansly-api/internal/config"
 	"fansly-api/internal/logger"
+	"fansly-api/internal/tracing"
 )
 
 func main() {
@@ -31,13 +32,51 @@ func main() {
 		os.Exit(1)
 	}
 
+	// Load server configuration
+	cfg, err := config.Load()
+	if err != nil {
+		log.Errorf("Error loading config: %v", err)
+		os.Exit(1)
+	}
+	if err := cfg.Validate(); err != nil {
+		log.Errorf("Invalid config: %v", err)
+		os.Exit(1)
+	}
+	log, err = logger.NewWithOptions(logger.Options{
+		Level:      cfg.LogLevel,
+		Format:     cfg.LogFormat,
+		File:       cfg.LogFile,
+		MaxSizeMB:  cfg.LogMaxSizeMB,
+		MaxBackups: cfg.LogMaxBackups,
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error creating logger: %v\n", err)
+		os.Exit(1)
+	}
+
+	// Set up tracing
+	shutdownTracing, err := tracing.Setup(context.Background(), tracing.Options{
+		Exporter:    cfg.TracingExporter,
+		Endpoint:    cfg.TracingEndpoint,
+		Insecure:    cfg.TracingInsecure,
+		SampleRatio: cfg.TracingSampleRatio,
+	})
+	if err != nil {
+		log.Errorf("Error setting up tracing: %v", err)
+		os.Exit(1)
+	}
+
 	// Create and start the server
-	server := api.NewServer(log)
-	log.Info("Starting server on :8080")
+	server, err := api.NewServer(cfg, log)
+	if err != nil {
+		log.Errorf("Error creating server: %v", err)
+		os.Exit(1)
+	}
+	log.Infof("Starting server on %s", cfg.ServerAddress)
 	
 	// Start the server in a goroutine
 	go func() {
-		if err := server.Start(":8080"); err != nil && err != http.ErrServerClosed {
+		if err := server.Start(cfg.ServerAddress); err != nil && err != http.ErrServerClosed {
 			log.Errorf("Error starting server: %v", err)
 		}
 	}()
@@ -55,6 +94,9 @@ func main() {
 	if err := server.Shutdown(ctx); err != nil {
 		log.Errorf("Server forced to shutdown: %v", err)
 	}
+	if err := shutdownTracing(ctx); err != nil {
+		log.Errorf("Error flushing traces: %v", err)
+	}
 
 	log.Info("Server exited properly")
 }
@@ -0,0 +1,254 @@
+package api
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"fansly-api/internal/storage"
+	"fansly-api/internal/validate"
+)
+
+// API key scopes
+const (
+	ScopeRead     = "read"
+	ScopeDownload = "download"
+	ScopeMessages = "messages"
+	ScopeAdmin    = "admin"
+)
+
+// apiKeyPrefix marks our keys so they are easy to recognise in logs and secret scanners
+const apiKeyPrefix = "fk"
+
+// bootstrapKeyFile is the data directory file the first admin key is written to
+const bootstrapKeyFile = "bootstrap_api_key"
+
+// lastUsedInterval limits how often last-used timestamps are written back to storage
+const lastUsedInterval = time.Minute
+
+// createAPIKeyRequest is the body of POST /api/v1/admin/api-keys
+type createAPIKeyRequest struct {
+	Name      string   `json:"name" validate:"required,max=100"`
+	Scopes    []string `json:"scopes" validate:"required,dive,oneof=read download messages admin"`
+	ExpiresIn int      `json:"expires_in,omitempty" validate:"min=0"` // seconds, 0 for no expiry
+}
+
+// apiKeyResponse is the public view of a stored API key
+type apiKeyResponse struct {
+	ID         string     `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Scopes     []string   `json:"scopes"`
+	CreatedAt  time.Time  `json:"created_at"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+}
+
+// createAPIKeyResponse includes the plaintext key, which is only shown once
+type createAPIKeyResponse struct {
+	Key    string         `json:"key"`
+	APIKey apiKeyResponse `json:"api_key"`
+}
+
+func newAPIKeyResponse(k *storage.APIKey) apiKeyResponse {
+	return apiKeyResponse{
+		ID:         k.ID,
+		Name:       k.Name,
+		Prefix:     k.Prefix,
+		Scopes:     k.Scopes,
+		CreatedAt:  k.CreatedAt,
+		ExpiresAt:  k.ExpiresAt,
+		LastUsedAt: k.LastUsedAt,
+		RevokedAt:  k.RevokedAt,
+	}
+}
+
+// handleCreateAPIKey handles POST /api/v1/admin/api-keys
+func (s *Server) handleCreateAPIKey(w http.ResponseWriter, r *http.Request) {
+	var req createAPIKeyRequest
+	if !bindJSON(w, r, &req) {
+		return
+	}
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		respondValidationError(w, r, validate.Errors{{Field: "name", Code: validate.CodeRequired, Message: "is required"}})
+		return
+	}
+
+	var expiresAt *time.Time
+	if req.ExpiresIn > 0 {
+		t := time.Now().Add(time.Duration(req.ExpiresIn) * time.Second)
+		expiresAt = &t
+	}
+
+	plaintext, key, err := s.createAPIKey(r.Context(), req.Name, req.Scopes, expiresAt)
+	if err != nil {
+		s.logFor(r.Context()).Errorf("Failed to create API key: %v", err)
+		respondWithError(w, http.StatusInternalServerError, "Failed to create API key")
+		return
+	}
+
+	s.logFor(r.Context()).Infof("Created API key %s (%s) with scopes %v", key.ID, key.Name, key.Scopes)
+	respondWithJSON(w, http.StatusCreated, createAPIKeyResponse{
+		Key:    plaintext,
+		APIKey: newAPIKeyResponse(key),
+	})
+}
+
+// handleListAPIKeys handles GET /api/v1/admin/api-keys
+func (s *Server) handleListAPIKeys(w http.ResponseWriter, r *http.Request) {
+	keys, err := s.apiKeys.List(r.Context())
+	if err != nil {
+		s.logFor(r.Context()).Errorf("Failed to list API keys: %v", err)
+		respondWithError(w, http.StatusInternalServerError, "Failed to list API keys")
+		return
+	}
+
+	data := make([]apiKeyResponse, 0, len(keys))
+	for _, key := range keys {
+		data = append(data, newAPIKeyResponse(key))
+	}
+	respondWithJSON(w, http.StatusOK, map[string]interface{}{"data": data})
+}
+
+// handleRevokeAPIKey handles DELETE /api/v1/admin/api-keys/{id}
+func (s *Server) handleRevokeAPIKey(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if err := s.apiKeys.Revoke(r.Context(), id, time.Now()); err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			respondWithError(w, http.StatusNotFound, "API key not found")
+			return
+		}
+		s.logFor(r.Context()).Errorf("Failed to revoke API key %s: %v", id, err)
+		respondWithError(w, http.StatusInternalServerError, "Failed to revoke API key")
+		return
+	}
+
+	s.logFor(r.Context()).Infof("Revoked API key %s", id)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// createAPIKeyAttempts bounds the retries when a generated ID or prefix is already taken
+const createAPIKeyAttempts = 3
+
+// createAPIKey generates and stores a new key, returning the plaintext once
+func (s *Server) createAPIKey(ctx context.Context, name string, scopes []string, expiresAt *time.Time) (string, *storage.APIKey, error) {
+	for attempt := 1; ; attempt++ {
+		plaintext, key, err := newAPIKey(name, scopes, expiresAt)
+		if err != nil {
+			return "", nil, err
+		}
+		err = s.apiKeys.Create(ctx, key)
+		if errors.Is(err, storage.ErrConflict) && attempt < createAPIKeyAttempts {
+			continue
+		} else if err != nil {
+			return "", nil, err
+		}
+		return plaintext, key, nil
+	}
+}
+
+// newAPIKey generates a key with a random ID, prefix and secret
+func newAPIKey(name string, scopes []string, expiresAt *time.Time) (string, *storage.APIKey, error) {
+	id, err := generateRandomString(16)
+	if err != nil {
+		return "", nil, err
+	}
+	prefix, err := generateRandomString(8)
+	if err != nil {
+		return "", nil, err
+	}
+	secret, err := generateRandomString(40)
+	if err != nil {
+		return "", nil, err
+	}
+
+	plaintext := fmt.Sprintf("%s_%s_%s", apiKeyPrefix, prefix, secret)
+	key := &storage.APIKey{
+		ID:        id,
+		Name:      name,
+		Prefix:    prefix,
+		Hash:      hashAPIKey(plaintext),
+		Scopes:    scopes,
+		CreatedAt: time.Now(),
+		ExpiresAt: expiresAt,
+	}
+	return plaintext, key, nil
+}
+
+// resolveAPIKey looks up an active key by its plaintext value
+func (s *Server) resolveAPIKey(ctx context.Context, plaintext string) (*storage.APIKey, error) {
+	parts := strings.Split(plaintext, "_")
+	if len(parts) != 3 || parts[0] != apiKeyPrefix {
+		return nil, errors.New("malformed API key")
+	}
+
+	key, err := s.apiKeys.GetByPrefix(ctx, parts[1])
+	if err != nil {
+		return nil, err
+	}
+	if subtle.ConstantTimeCompare([]byte(key.Hash), []byte(hashAPIKey(plaintext))) != 1 {
+		return nil, errors.New("API key mismatch")
+	}
+
+	now := time.Now()
+	if !key.Active(now) {
+		return nil, errors.New("API key expired or revoked")
+	}
+	if key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) > lastUsedInterval {
+		if err := s.apiKeys.TouchLastUsed(ctx, key.ID, now); err != nil {
+			s.logFor(ctx).Warnf("Failed to record API key usage for %s: %v", key.ID, err)
+		}
+	}
+	return key, nil
+}
+
+// bootstrapAPIKey creates an admin key when none exist so the admin endpoints are
+// reachable. The key is written to a file only the server's user can read rather
+// than logged, so it doesn't end up in log files or log shippers.
+func (s *Server) bootstrapAPIKey(ctx context.Context, dataDir string) error {
+	keys, err := s.apiKeys.List(ctx)
+	if err != nil {
+		return err
+	}
+	if len(keys) > 0 {
+		return nil
+	}
+
+	plaintext, key, err := s.createAPIKey(ctx, "bootstrap", []string{ScopeAdmin}, nil)
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(dataDir, bootstrapKeyFile)
+	if err := os.WriteFile(path, []byte(plaintext+"\n"), 0600); err != nil {
+		return fmt.Errorf("error writing %s: %w", path, err)
+	}
+	s.log.Warnf("No API keys found; created admin key %s and wrote it to %s (store it elsewhere and delete the file)", key.ID, path)
+	return nil
+}
+
+// hashAPIKey returns the stored hash of a plaintext API key. Keys are random,
+// so a fast hash is sufficient.
+func hashAPIKey(plaintext string) string {
+	sum := sha256.Sum256([]byte(plaintext))
+	return hex.EncodeToString(sum[:])
+}
+
+// apiKeyFromRequest extracts the API key from the header or query parameter
+func apiKeyFromRequest(r *http.Request) string {
+	if apiKey := r.Header.Get("X-API-Key"); apiKey != "" {
+		return apiKey
+	}
+	return r.URL.Query().Get("api_key")
+}
@@ -0,0 +1,134 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"fansly-api/internal/config"
+	"fansly-api/internal/storage"
+)
+
+// withAPIKey sends a request authenticated with an API key
+func withAPIKey(s *Server, method, path, key string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(method, path, nil)
+	r.Header.Set("X-API-Key", key)
+	return serve(s, r)
+}
+
+func TestAPIKeyAuth(t *testing.T) {
+	s := newTestServer(t, &config.Config{})
+	ctx := context.Background()
+	plaintext, key, err := s.createAPIKey(ctx, "test", []string{ScopeAdmin}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rec := withAPIKey(s, "GET", "/api/v1/admin/api-keys", plaintext); rec.Code != http.StatusOK {
+		t.Fatalf("valid key: status %d", rec.Code)
+	}
+
+	// A valid prefix with the wrong secret
+	wrongSecret := apiKeyPrefix + "_" + key.Prefix + "_" + strings.Repeat("x", 40)
+	if rec := withAPIKey(s, "GET", "/api/v1/admin/api-keys", wrongSecret); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("wrong secret: status %d, want 401", rec.Code)
+	}
+
+	past := time.Now().Add(-time.Minute)
+	expired, _, err := s.createAPIKey(ctx, "expired", []string{ScopeAdmin}, &past)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rec := withAPIKey(s, "GET", "/api/v1/admin/api-keys", expired); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("expired key: status %d, want 401", rec.Code)
+	}
+
+	if err := s.apiKeys.Revoke(ctx, key.ID, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	if rec := withAPIKey(s, "GET", "/api/v1/admin/api-keys", plaintext); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("revoked key: status %d, want 401", rec.Code)
+	}
+}
+
+func TestAPIKeyPlaintextNotStored(t *testing.T) {
+	s := newTestServer(t, &config.Config{})
+	plaintext, key, err := s.createAPIKey(context.Background(), "test", []string{ScopeRead}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if key.Hash != hashAPIKey(plaintext) {
+		t.Fatal("stored hash doesn't match the key")
+	}
+
+	dataDir, err := s.config.GetDataDir()
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(filepath.Join(dataDir, "api_keys.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	secret := plaintext[strings.LastIndex(plaintext, "_")+1:]
+	if strings.Contains(string(data), secret) {
+		t.Fatal("plaintext key secret written to the key store")
+	}
+	if !strings.Contains(string(data), key.Hash) {
+		t.Fatal("key hash missing from the key store")
+	}
+}
+
+func TestBootstrapAPIKeyFile(t *testing.T) {
+	s := newTestServer(t, &config.Config{})
+	dataDir, err := s.config.GetDataDir()
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dataDir, bootstrapKeyFile)
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Fatalf("bootstrap key file mode %v, want 0600", info.Mode().Perm())
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rec := withAPIKey(s, "GET", "/api/v1/admin/api-keys", strings.TrimSpace(string(data))); rec.Code != http.StatusOK {
+		t.Fatalf("bootstrap key: status %d", rec.Code)
+	}
+}
+
+// conflictingAPIKeyStore reports the first conflicts creates as taken
+type conflictingAPIKeyStore struct {
+	storage.APIKeyStore
+	conflicts int
+}
+
+func (s *conflictingAPIKeyStore) Create(ctx context.Context, key *storage.APIKey) error {
+	if s.conflicts > 0 {
+		s.conflicts--
+		return storage.ErrConflict
+	}
+	return s.APIKeyStore.Create(ctx, key)
+}
+
+func TestCreateAPIKeyRetriesConflicts(t *testing.T) {
+	s := newTestServer(t, &config.Config{})
+	store := &conflictingAPIKeyStore{APIKeyStore: storage.NewMemoryAPIKeyStore(), conflicts: createAPIKeyAttempts - 1}
+	s.apiKeys = store
+	if _, _, err := s.createAPIKey(context.Background(), "test", []string{ScopeRead}, nil); err != nil {
+		t.Fatalf("create after %d conflicts: %v", createAPIKeyAttempts-1, err)
+	}
+
+	store.conflicts = createAPIKeyAttempts
+	if _, _, err := s.createAPIKey(context.Background(), "test", []string{ScopeRead}, nil); err == nil {
+		t.Fatal("expected an error once every attempt conflicts")
+	}
+}
@@ -3,12 +3,14 @@ package api
 import (
 	"crypto/rand"
 	"encoding/hex"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
+
+	"fansly-api/internal/storage"
 )
 
 // authResponse represents the response for authentication endpoints
@@ -16,16 +18,22 @@ type authResponse struct {
 	URL       string `json:"url,omitempty"`
 	Token     string `json:"token,omitempty"`
 	ExpiresIn int    `json:"expires_in,omitempty"`
+	CSRFToken string `json:"csrf_token,omitempty"` // required in X-CSRF-Token for cookie-authenticated mutations
 }
 
 // authRequest represents the request for completing authentication
 type authRequest struct {
-	AuthToken string `json:"auth_token"`
+	AuthToken string `json:"auth_token" validate:"required"`
 	UserAgent string `json:"user_agent"`
 }
 
+// jwtIssuer is the iss claim of every token we issue
+const jwtIssuer = "fansly-api"
+
 type claims struct {
-	UserID string `json:"user_id"`
+	UserID      string   `json:"user_id"`
+	Roles       []string `json:"roles,omitempty"`
+	Permissions []string `json:"permissions,omitempty"` // granted in addition to the roles' permissions
 	jwt.RegisteredClaims
 }
 
@@ -34,69 +42,114 @@ func (s *Server) handleAuthInitiate(w http.ResponseWriter, r *http.Request) {
 	// Generate a random token for this authentication attempt
 	authToken, err := generateRandomString(32)
 	if err != nil {
-		s.log.Errorf("Failed to generate auth token: %v", err)
+		s.logFor(r.Context()).Errorf("Failed to generate auth token: %v", err)
 		respondWithError(w, http.StatusInternalServerError, "Failed to start authentication")
 		return
 	}
 
-	// Store the auth token in memory (in a real app, use a proper session store)
-	s.authTokens[authToken] = time.Now().Add(10 * time.Minute) // Token valid for 10 minutes
+	// Store the pending attempt, limiting how many each client can have outstanding
+	now := time.Now()
+	err = s.pendingAuth.Add(r.Context(), &storage.PendingAuth{
+		Token:     authToken,
+		IPAddress: clientIP(r),
+		CreatedAt: now,
+		ExpiresAt: now.Add(s.pendingAuthTTL()),
+	}, s.config.AuthPendingMaxPerIP)
+	if errors.Is(err, storage.ErrLimitExceeded) {
+		s.logFor(r.Context()).Warnf("Too many pending authentication attempts from %s", clientIP(r))
+		respondWithError(w, http.StatusTooManyRequests, "Too many pending authentication attempts")
+		return
+	}
+	if err != nil {
+		s.logFor(r.Context()).Errorf("Failed to store auth token: %v", err)
+		respondWithError(w, http.StatusInternalServerError, "Failed to start authentication")
+		return
+	}
 
 	// Return the authentication URL and token
 	respondWithJSON(w, http.StatusOK, authResponse{
-		URL:   "https://fansly.com/account/security", // URL where user can find their auth token
-		Token: authToken,
+		URL:       "https://fansly.com/account/security", // URL where user can find their auth token
+		Token:     authToken,
+		ExpiresIn: int(s.pendingAuthTTL().Seconds()),
 	})
 }
 
 // handleAuthComplete completes the authentication process
 func (s *Server) handleAuthComplete(w http.ResponseWriter, r *http.Request) {
 	var req authRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		s.log.Warnf("Invalid request: %v", err)
-		respondWithError(w, http.StatusBadRequest, "Invalid request")
+	if !bindJSON(w, r, &req) {
 		return
 	}
 
-	// Verify the auth token is valid and not expired
-	expiresAt, exists := s.authTokens[req.AuthToken]
-	if !exists {
-		s.log.Warnf("Invalid auth token: %s", req.AuthToken)
+	// Verify the auth token is valid and not expired. Taking it also removes it,
+	// so a token can only be used once.
+	_, err := s.pendingAuth.Take(r.Context(), req.AuthToken, time.Now())
+	switch {
+	case errors.Is(err, storage.ErrNotFound):
+		s.logFor(r.Context()).Warnf("Invalid auth token: %s", req.AuthToken)
 		respondWithError(w, http.StatusUnauthorized, "Invalid or expired authentication token")
 		return
-	}
-
-	if time.Now().After(expiresAt) {
-		delete(s.authTokens, req.AuthToken)
-		s.log.Warnf("Expired auth token: %s", req.AuthToken)
+	case errors.Is(err, storage.ErrExpired):
+		s.logFor(r.Context()).Warnf("Expired auth token: %s", req.AuthToken)
 		respondWithError(w, http.StatusUnauthorized, "Authentication token expired")
 		return
+	case err != nil:
+		s.logFor(r.Context()).Errorf("Failed to look up auth token: %v", err)
+		respondWithError(w, http.StatusInternalServerError, "Failed to complete authentication")
+		return
 	}
 
-	// Clean up the used token
-	delete(s.authTokens, req.AuthToken)
-
 	// In a real implementation, you would validate the auth token
 	// and exchange it for a JWT
 
 	// For now, just return a placeholder response
-	token, err := s.generateJWT("user123") // Replace with actual user ID
+	userID := "user123" // Replace with actual user ID
+	var roles []string
+	if s.config.DefaultRole != "" {
+		roles = []string{s.config.DefaultRole}
+	}
+
+	token, err := s.generateJWT(userID, roles)
 	if err != nil {
-		s.log.Errorf("Failed to generate JWT: %v", err)
+		s.logFor(r.Context()).Errorf("Failed to generate JWT: %v", err)
 		respondWithError(w, http.StatusInternalServerError, "Failed to generate token")
 		return
 	}
 
+	// Also start a cookie session for browser clients
+	session, err := s.createSession(w, r, userID, roles)
+	if err != nil {
+		s.logFor(r.Context()).Errorf("Failed to create session: %v", err)
+		respondWithError(w, http.StatusInternalServerError, "Failed to create session")
+		return
+	}
+
 	respondWithJSON(w, http.StatusOK, authResponse{
 		Token:     token,
-		ExpiresIn: 3600, // 1 hour
+		ExpiresIn: int(s.tokenExpiry().Seconds()),
+		CSRFToken: session.CSRFToken,
 	})
 }
 
 // requireAuth is a middleware that ensures the request is authenticated
 func (s *Server) requireAuth(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// API keys are accepted in place of a bearer token
+		if apiKeyFromRequest(r) != "" {
+			s.APIKeyAuth(next).ServeHTTP(w, r)
+			return
+		}
+
 		authHeader := r.Header.Get("Authorization")
+
+		// Browser clients authenticate with a session cookie instead
+		if authHeader == "" {
+			if _, err := r.Cookie(sessionCookieName); err == nil {
+				s.RequireAuth(next).ServeHTTP(w, r)
+				return
+			}
+		}
+
 		if authHeader == "" {
 			respondWithError(w, http.StatusUnauthorized, "Authorization header is required")
 			return
@@ -114,18 +167,26 @@ func (s *Server) requireAuth(next http.Handler) http.Handler {
 		}
 
 		// Parse and validate the token
-		token, err := jwt.ParseWithClaims(tokenString, &claims{}, func(token *jwt.Token) (interface{}, error) {
-			return []byte(s.config.JWTSecret), nil
-		})
+		// Only accept algorithms we have keys for, and resolve the key by kid
+		tokenClaims := &claims{}
+		token, err := jwt.ParseWithClaims(tokenString, tokenClaims, s.keys.Keyfunc,
+			jwt.WithValidMethods(s.keys.Methods()),
+			jwt.WithIssuer(jwtIssuer),
+		)
 
 		if err != nil || !token.Valid {
-			s.log.Warnf("Invalid token: %v", err)
+			s.logFor(r.Context()).Warnf("Invalid token: %v", err)
 			respondWithError(w, http.StatusUnauthorized, "Invalid or expired token")
 			return
 		}
 
 		// Token is valid, continue with the request
-		next.ServeHTTP(w, r)
+		next.ServeHTTP(w, withPrincipal(r, &Principal{
+			ID:          tokenClaims.UserID,
+			Type:        principalUser,
+			Roles:       tokenClaims.Roles,
+			Permissions: resolvePermissions(rolePermissions, tokenClaims.Roles, tokenClaims.Permissions),
+		}))
 	})
 }
 
@@ -139,27 +200,34 @@ func generateRandomString(length int) (string, error) {
 	return hex.EncodeToString(b)[:length], nil
 }
 
-// generateJWT creates a new JWT token for the given user ID
-func (s *Server) generateJWT(userID string) (string, error) {
+// generateJWT creates a new JWT token for the given user ID and roles
+func (s *Server) generateJWT(userID string, roles []string) (string, error) {
 	// Create the claims
+	now := time.Now()
 	claims := &claims{
 		UserID: userID,
+		Roles:  roles,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(24 * time.Hour)),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
-			NotBefore: jwt.NewNumericDate(time.Now()),
-			Issuer:    "fansly-api",
+			ExpiresAt: jwt.NewNumericDate(now.Add(s.tokenExpiry())),
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
+			Issuer:    jwtIssuer,
 		},
 	}
 
-	// Create the token
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-
-	// Sign the token with the secret key
-	tokenString, err := token.SignedString([]byte(s.config.JWTSecret))
+	// Sign the token with the active key
+	tokenString, err := s.keys.Sign(claims)
 	if err != nil {
 		return "", fmt.Errorf("failed to sign token: %w", err)
 	}
 
 	return tokenString, nil
 }
+
+// tokenExpiry returns the configured JWT lifetime
+func (s *Server) tokenExpiry() time.Duration {
+	if s.config.JWTExpiry > 0 {
+		return s.config.JWTExpiry
+	}
+	return 24 * time.Hour
+}
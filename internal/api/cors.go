@@ -0,0 +1,86 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/cors"
+
+	"fansly-api/internal/config"
+)
+
+// newCORSOptions builds the CORS policy from the configuration
+func newCORSOptions(cfg *config.Config) cors.Options {
+	origins := cfg.GetCORSAllowedOrigins()
+	allowAll := false
+	for _, origin := range origins {
+		if origin == "*" {
+			allowAll = true
+		}
+	}
+
+	return cors.Options{
+		AllowOriginFunc: func(r *http.Request, origin string) bool {
+			return allowAll || matchOrigin(origins, origin)
+		},
+		AllowedMethods: cfg.GetCORSAllowedMethods(),
+		AllowedHeaders: cfg.GetCORSAllowedHeaders(),
+		ExposedHeaders: cfg.GetCORSExposedHeaders(),
+		// Credentials with a wildcard origin would let any site make authenticated requests
+		AllowCredentials: cfg.CORSAllowCredentials && !allowAll,
+		MaxAge:           cfg.CORSMaxAge,
+	}
+}
+
+// matchOrigin reports whether origin matches one of the patterns. Patterns may use
+// "*" for a single host label or for the port, e.g. "https://*.example.com" or
+// "http://localhost:*"; it never spans a dot, so "https://*.example.com" doesn't
+// match "https://a.b.example.com".
+func matchOrigin(patterns []string, origin string) bool {
+	origin = strings.ToLower(origin)
+	for _, pattern := range patterns {
+		if matchOriginPattern(strings.ToLower(pattern), origin) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchOriginPattern compares the scheme, each host label and the port separately
+func matchOriginPattern(pattern, origin string) bool {
+	patternScheme, patternHost, ok := strings.Cut(pattern, "://")
+	if !ok {
+		return false
+	}
+	originScheme, originHost, ok := strings.Cut(origin, "://")
+	if !ok || patternScheme != originScheme {
+		return false
+	}
+
+	patternHost, patternPort := splitOriginPort(patternHost)
+	originHost, originPort := splitOriginPort(originHost)
+	if patternPort != originPort && (patternPort != "*" || originPort == "") {
+		return false
+	}
+
+	patternLabels := strings.Split(patternHost, ".")
+	originLabels := strings.Split(originHost, ".")
+	if len(patternLabels) != len(originLabels) {
+		return false
+	}
+	for i, label := range patternLabels {
+		if label != originLabels[i] && (label != "*" || originLabels[i] == "") {
+			return false
+		}
+	}
+	return true
+}
+
+// splitOriginPort splits "host:port" into host and port, leaving IPv6 literals such as "[::1]" intact
+func splitOriginPort(hostport string) (host, port string) {
+	i := strings.LastIndex(hostport, ":")
+	if i < 0 || i < strings.LastIndex(hostport, "]") {
+		return hostport, ""
+	}
+	return hostport[:i], hostport[i+1:]
+}
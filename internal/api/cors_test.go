@@ -0,0 +1,36 @@
+package api
+
+import "testing"
+
+func TestMatchOrigin(t *testing.T) {
+	tests := []struct {
+		pattern string
+		origin  string
+		want    bool
+	}{
+		{"https://app.example.com", "https://app.example.com", true},
+		{"https://app.example.com", "https://APP.example.com", true},
+		{"https://app.example.com", "http://app.example.com", false},
+		{"https://app.example.com", "https://app.example.com:8443", false},
+		{"https://*.example.com", "https://a.example.com", true},
+		{"https://*.example.com", "https://a.b.example.com", false},
+		{"https://*.example.com", "https://example.com", false},
+		{"https://*.example.com", "https://.example.com", false},
+		{"https://*.example.com", "https://a.example.com.evil.com", false},
+		{"https://*.example.com", "https://evilexample.com", false},
+		{"http://localhost:*", "http://localhost:3000", true},
+		{"http://localhost:*", "http://localhost", false},
+		{"http://localhost:*", "http://localhost.evil.com:3000", false},
+		{"http://localhost:3000", "http://localhost:3001", false},
+		{"http://[::1]:*", "http://[::1]:3000", true},
+		{"http://[::1]:*", "http://[::2]:3000", false},
+		{"https://*.example.com:*", "https://a.example.com:8443", true},
+		{"*", "https://example.com", false}, // handled by newCORSOptions as allow-all
+		{"https://example.com", "null", false},
+	}
+	for _, tt := range tests {
+		if got := matchOrigin([]string{tt.pattern}, tt.origin); got != tt.want {
+			t.Errorf("matchOrigin(%q, %q) = %v, want %v", tt.pattern, tt.origin, got, tt.want)
+		}
+	}
+}
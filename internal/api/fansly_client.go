@@ -4,10 +4,17 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
+	"net/url"
+	"strings"
 	"time"
 
 	"fansly-api/internal/logger"
+	"fansly-api/internal/metrics"
+	"fansly-api/internal/service"
+	"fansly-api/internal/storage"
+	"fansly-api/internal/tracing"
 )
 
 type FanslyClient struct {
@@ -21,7 +28,10 @@ func NewFanslyClient(authToken string, logger logger.Logger) *FanslyClient {
 	return &FanslyClient{
 		baseURL:    "https://apiv3.fansly.com",
 		authToken:  authToken,
-		httpClient: &http.Client{Timeout: 30 * time.Second},
+		httpClient: &http.Client{
+			Timeout:   30 * time.Second,
+			Transport: tracing.Transport(metrics.InstrumentTransport(nil, metrics.FanslyRequests, metrics.FanslyRequestDuration)),
+		},
 		logger:     logger,
 	}
 }
@@ -88,3 +98,510 @@ func (c *FanslyClient) GetFollowedUsers(ctx context.Context, limit, offset int)
 
 	return result.Response.Accounts, nil
 }
+
+// SearchAccounts searches Fansly accounts by username or display name. Accounts
+// without an ID or username are skipped.
+func (c *FanslyClient) SearchAccounts(ctx context.Context, query string, limit int) ([]service.Creator, error) {
+	var result struct {
+		Response struct {
+			Accounts []struct {
+				ID          string `json:"id"`
+				Username    string `json:"username"`
+				DisplayName string `json:"displayName"`
+				Following   bool   `json:"following"`
+			} `json:"accounts"`
+		} `json:"response"`
+	}
+	endpoint := fmt.Sprintf("%s/account/search?searchQuery=%s&limit=%d", c.baseURL, url.QueryEscape(query), limit)
+	if err := c.getJSON(ctx, endpoint, &result); err != nil {
+		return nil, err
+	}
+
+	creators := make([]service.Creator, 0, len(result.Response.Accounts))
+	for _, a := range result.Response.Accounts {
+		if a.ID == "" || a.Username == "" {
+			continue
+		}
+		name := a.DisplayName
+		if name == "" {
+			name = a.Username
+		}
+		creators = append(creators, service.Creator{ID: a.ID, Name: name, Username: a.Username, IsFollowing: a.Following})
+	}
+	return creators, nil
+}
+
+// FollowAccount follows the account with the given ID
+func (c *FanslyClient) FollowAccount(ctx context.Context, accountID string) error {
+	ctx = metrics.WithEndpoint(ctx, "/account/{id}/followers")
+	return c.post(ctx, fmt.Sprintf("%s/account/%s/followers", c.baseURL, url.PathEscape(accountID)))
+}
+
+// UnfollowAccount stops following the account with the given ID
+func (c *FanslyClient) UnfollowAccount(ctx context.Context, accountID string) error {
+	ctx = metrics.WithEndpoint(ctx, "/account/{id}/followers/remove")
+	return c.post(ctx, fmt.Sprintf("%s/account/%s/followers/remove", c.baseURL, url.PathEscape(accountID)))
+}
+
+// post sends a POST request without a body and checks for a successful response
+func (c *FanslyClient) post(ctx context.Context, endpoint string) error {
+	req, err := http.NewRequestWithContext(ctx, "POST", endpoint, nil)
+	if err != nil {
+		return fmt.Errorf("error creating request: %w", err)
+	}
+
+	req.Header.Set("Authorization", c.authToken)
+	req.Header.Set("User-Agent", "fansly-api/1.0")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("error making request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// messageGroupPageSize is the number of message groups requested at a time
+const messageGroupPageSize = 50
+
+// ListMessageGroups retrieves every direct message group of the authenticated user
+func (c *FanslyClient) ListMessageGroups(ctx context.Context) ([]storage.MessageGroup, error) {
+	var groups []storage.MessageGroup
+	for offset := 0; ; offset += messageGroupPageSize {
+		var result struct {
+			Response struct {
+				Data []struct {
+					GroupID          string `json:"groupId"`
+					PartnerAccountID string `json:"partnerAccountId"`
+					LastMessage      *struct {
+						CreatedAt int64 `json:"createdAt"`
+					} `json:"lastMessage"`
+				} `json:"data"`
+				AggregationData struct {
+					Accounts []struct {
+						ID       string `json:"id"`
+						Username string `json:"username"`
+					} `json:"accounts"`
+				} `json:"aggregationData"`
+			} `json:"response"`
+		}
+		endpoint := fmt.Sprintf("%s/messaging/groups?limit=%d&offset=%d", c.baseURL, messageGroupPageSize, offset)
+		if err := c.getJSON(ctx, endpoint, &result); err != nil {
+			return nil, err
+		}
+
+		usernames := make(map[string]string, len(result.Response.AggregationData.Accounts))
+		for _, account := range result.Response.AggregationData.Accounts {
+			usernames[account.ID] = account.Username
+		}
+		for _, g := range result.Response.Data {
+			group := storage.MessageGroup{
+				ID:              g.GroupID,
+				PartnerID:       g.PartnerAccountID,
+				PartnerUsername: usernames[g.PartnerAccountID],
+			}
+			if g.LastMessage != nil {
+				group.LastMessageAt = time.Unix(g.LastMessage.CreatedAt, 0).UTC()
+			}
+			groups = append(groups, group)
+		}
+
+		if len(result.Response.Data) < messageGroupPageSize {
+			return groups, nil
+		}
+	}
+}
+
+// ListMessages retrieves up to limit messages of a group, newest first. When
+// before is set, only messages older than the message with that ID are returned.
+func (c *FanslyClient) ListMessages(ctx context.Context, groupID, before string, limit int) ([]storage.Message, error) {
+	endpoint := fmt.Sprintf("%s/message?groupId=%s&limit=%d", c.baseURL, url.QueryEscape(groupID), limit)
+	if before != "" {
+		endpoint += "&before=" + url.QueryEscape(before)
+	}
+
+	var result struct {
+		Response struct {
+			Messages []struct {
+				ID          string `json:"id"`
+				GroupID     string `json:"groupId"`
+				SenderID    string `json:"senderId"`
+				Content     string `json:"content"`
+				CreatedAt   int64  `json:"createdAt"`
+				Attachments []struct {
+					ContentID   string `json:"contentId"`
+					ContentType int    `json:"contentType"`
+				} `json:"attachments"`
+			} `json:"messages"`
+		} `json:"response"`
+	}
+	if err := c.getJSON(ctx, endpoint, &result); err != nil {
+		return nil, err
+	}
+
+	messages := make([]storage.Message, 0, len(result.Response.Messages))
+	for _, m := range result.Response.Messages {
+		message := storage.Message{
+			ID:        m.ID,
+			GroupID:   m.GroupID,
+			SenderID:  m.SenderID,
+			Content:   m.Content,
+			CreatedAt: time.Unix(m.CreatedAt, 0).UTC(),
+		}
+		for _, a := range m.Attachments {
+			message.Attachments = append(message.Attachments, storage.MessageAttachment{
+				ID:   a.ContentID,
+				Type: attachmentType(a.ContentType),
+			})
+		}
+		messages = append(messages, message)
+	}
+	return messages, nil
+}
+
+// attachmentType maps a Fansly attachment content type to the type stored with a message
+func attachmentType(contentType int) string {
+	switch contentType {
+	case 1:
+		return "media"
+	case 2:
+		return "media_bundle"
+	case 7100:
+		return "tip"
+	default:
+		return "other"
+	}
+}
+
+// ListPosts retrieves up to limit posts of an account, newest first. When before
+// is set, only posts older than the post with that ID are returned.
+func (c *FanslyClient) ListPosts(ctx context.Context, accountID, before string, limit int) ([]storage.Post, error) {
+	endpoint := fmt.Sprintf("%s/timeline?accountId=%s&limit=%d", c.baseURL, url.QueryEscape(accountID), limit)
+	if before != "" {
+		endpoint += "&before=" + url.QueryEscape(before)
+	}
+
+	var result struct {
+		Response struct {
+			Posts []struct {
+				ID          string   `json:"id"`
+				AccountID   string   `json:"accountId"`
+				Content     string   `json:"content"`
+				CreatedAt   int64    `json:"createdAt"`
+				TierIDs     []string `json:"subscriptionTierIds"`
+				Attachments []struct {
+					ContentID   string `json:"contentId"`
+					ContentType int    `json:"contentType"`
+				} `json:"attachments"`
+			} `json:"posts"`
+			AccountMedia []struct {
+				ID       string `json:"id"`
+				Mimetype string `json:"mimetype"`
+				Caption  string `json:"caption"`
+			} `json:"accountMedia"`
+			AccountMediaBundles []struct {
+				ID              string   `json:"id"`
+				AccountMediaIDs []string `json:"accountMediaIds"`
+			} `json:"accountMediaBundles"`
+		} `json:"response"`
+	}
+	if err := c.getJSON(ctx, endpoint, &result); err != nil {
+		return nil, err
+	}
+
+	media := make(map[string]storage.PostMedia, len(result.Response.AccountMedia))
+	for _, m := range result.Response.AccountMedia {
+		mediaType := "image"
+		if strings.HasPrefix(m.Mimetype, "video/") {
+			mediaType = "video"
+		}
+		media[m.ID] = storage.PostMedia{ID: m.ID, Type: mediaType, Caption: m.Caption}
+	}
+	bundles := make(map[string][]string, len(result.Response.AccountMediaBundles))
+	for _, b := range result.Response.AccountMediaBundles {
+		bundles[b.ID] = b.AccountMediaIDs
+	}
+
+	posts := make([]storage.Post, 0, len(result.Response.Posts))
+	for _, p := range result.Response.Posts {
+		post := storage.Post{
+			ID:        p.ID,
+			CreatorID: p.AccountID,
+			Text:      p.Content,
+			TierIDs:   p.TierIDs,
+			CreatedAt: time.Unix(p.CreatedAt, 0).UTC(),
+		}
+		for _, a := range p.Attachments {
+			var mediaIDs []string
+			switch attachmentType(a.ContentType) {
+			case "media":
+				mediaIDs = []string{a.ContentID}
+			case "media_bundle":
+				mediaIDs = bundles[a.ContentID]
+			}
+			for _, id := range mediaIDs {
+				if m, ok := media[id]; ok {
+					post.Media = append(post.Media, m)
+				}
+			}
+		}
+		posts = append(posts, post)
+	}
+	return posts, nil
+}
+
+// ListStories retrieves the stories of an account that are currently visible
+func (c *FanslyClient) ListStories(ctx context.Context, accountID string) ([]storage.Story, error) {
+	var result struct {
+		Response struct {
+			MediaStories []struct {
+				ID        string `json:"id"`
+				AccountID string `json:"accountId"`
+				CreatedAt int64  `json:"createdAt"`
+				ExpiresAt int64  `json:"expiresAt"` // 0 when Fansly doesn't report it
+				Media     []struct {
+					ID       string `json:"id"`
+					Mimetype string `json:"mimetype"`
+					Location string `json:"location"`
+				} `json:"media"`
+			} `json:"mediaStories"`
+		} `json:"response"`
+	}
+	endpoint := fmt.Sprintf("%s/mediastories?accountId=%s", c.baseURL, url.QueryEscape(accountID))
+	if err := c.getJSON(ctx, endpoint, &result); err != nil {
+		return nil, err
+	}
+
+	stories := make([]storage.Story, 0, len(result.Response.MediaStories))
+	for _, st := range result.Response.MediaStories {
+		story := storage.Story{
+			ID:        st.ID,
+			CreatorID: st.AccountID,
+			CreatedAt: time.Unix(st.CreatedAt, 0).UTC(),
+		}
+		if st.ExpiresAt > 0 {
+			story.ExpiresAt = time.Unix(st.ExpiresAt, 0).UTC()
+		}
+		for _, m := range st.Media {
+			mediaType := "image"
+			if strings.HasPrefix(m.Mimetype, "video/") {
+				mediaType = "video"
+			}
+			story.Media = append(story.Media, storage.StoryMedia{ID: m.ID, Type: mediaType, Location: m.Location})
+		}
+		stories = append(stories, story)
+	}
+	return stories, nil
+}
+
+// ListSubscriptions retrieves the paid subscriptions of the authenticated user
+func (c *FanslyClient) ListSubscriptions(ctx context.Context) ([]storage.Subscription, error) {
+	var result struct {
+		Response struct {
+			Subscriptions []struct {
+				AccountID            string `json:"accountId"`
+				SubscriptionTierID   string `json:"subscriptionTierId"`
+				SubscriptionTierName string `json:"subscriptionTierName"`
+				Price                int    `json:"price"`
+				PlanDuration         int    `json:"planDuration"` // months
+				AutoRenew            bool   `json:"autoRenew"`
+				CreatedAt            int64  `json:"createdAt"`
+				RenewDate            int64  `json:"renewDate"`
+			} `json:"subscriptions"`
+		} `json:"response"`
+	}
+	if err := c.getJSON(ctx, fmt.Sprintf("%s/subscriptions", c.baseURL), &result); err != nil {
+		return nil, err
+	}
+
+	subs := make([]storage.Subscription, 0, len(result.Response.Subscriptions))
+	for _, sub := range result.Response.Subscriptions {
+		// A missing date is left zero rather than read as 1970
+		var subscribedAt, renewsAt time.Time
+		if sub.CreatedAt > 0 {
+			subscribedAt = time.Unix(sub.CreatedAt, 0).UTC()
+		}
+		if sub.RenewDate > 0 {
+			renewsAt = time.Unix(sub.RenewDate, 0).UTC()
+		}
+		subs = append(subs, storage.Subscription{
+			CreatorID:      sub.AccountID,
+			TierID:         sub.SubscriptionTierID,
+			TierName:       sub.SubscriptionTierName,
+			Price:          sub.Price,
+			Currency:       "USD", // Fansly prices are in US dollars
+			DurationMonths: sub.PlanDuration,
+			AutoRenew:      sub.AutoRenew,
+			SubscribedAt:   subscribedAt,
+			RenewsAt:       renewsAt,
+		})
+	}
+	return subs, nil
+}
+
+// GetSubscriptionTiers retrieves the subscription tiers offered by an account
+func (c *FanslyClient) GetSubscriptionTiers(ctx context.Context, accountID string) ([]service.SubscriptionTier, error) {
+	var result struct {
+		Response []struct {
+			ID    string `json:"id"`
+			Name  string `json:"name"`
+			Price int    `json:"price"` // per month
+		} `json:"response"`
+	}
+	endpoint := fmt.Sprintf("%s/account/%s/subscriptiontiers", c.baseURL, url.PathEscape(accountID))
+	ctx = metrics.WithEndpoint(ctx, "/account/{id}/subscriptiontiers")
+	if err := c.getJSON(ctx, endpoint, &result); err != nil {
+		return nil, err
+	}
+
+	tiers := make([]service.SubscriptionTier, 0, len(result.Response))
+	for _, t := range result.Response {
+		tiers = append(tiers, service.SubscriptionTier{ID: t.ID, Name: t.Name, Price: t.Price, Currency: "USD", DurationMonths: 1})
+	}
+	return tiers, nil
+}
+
+// purchasePageSize is the number of purchase orders requested at a time
+const purchasePageSize = 50
+
+// ListPurchases retrieves every media item and bundle the authenticated user has
+// bought, with bundles expanded into their media
+func (c *FanslyClient) ListPurchases(ctx context.Context) ([]service.VaultItem, error) {
+	var items []service.VaultItem
+	for offset := 0; ; offset += purchasePageSize {
+		var result struct {
+			Response struct {
+				Orders []struct {
+					AccountMediaID       string `json:"accountMediaId"`
+					AccountMediaBundleID string `json:"accountMediaBundleId"`
+					Price                int    `json:"price"`
+					CreatedAt            int64  `json:"createdAt"`
+					PostID               string `json:"postId"`
+					MessageID            string `json:"messageId"`
+				} `json:"orders"`
+				AggregationData struct {
+					AccountMedia []struct {
+						ID        string `json:"id"`
+						AccountID string `json:"accountId"`
+						Mimetype  string `json:"mimetype"`
+						Location  string `json:"location"`
+					} `json:"accountMedia"`
+					AccountMediaBundles []struct {
+						ID              string   `json:"id"`
+						AccountMediaIDs []string `json:"accountMediaIds"`
+					} `json:"accountMediaBundles"`
+				} `json:"aggregationData"`
+			} `json:"response"`
+		}
+		endpoint := fmt.Sprintf("%s/account/media/orders?limit=%d&offset=%d", c.baseURL, purchasePageSize, offset)
+		if err := c.getJSON(ctx, endpoint, &result); err != nil {
+			return nil, err
+		}
+
+		media := make(map[string]service.VaultItem, len(result.Response.AggregationData.AccountMedia))
+		for _, m := range result.Response.AggregationData.AccountMedia {
+			mediaType := "image"
+			if strings.HasPrefix(m.Mimetype, "video/") {
+				mediaType = "video"
+			}
+			media[m.ID] = service.VaultItem{MediaID: m.ID, CreatorID: m.AccountID, Type: mediaType, URL: m.Location}
+		}
+		bundles := make(map[string][]string, len(result.Response.AggregationData.AccountMediaBundles))
+		for _, b := range result.Response.AggregationData.AccountMediaBundles {
+			bundles[b.ID] = b.AccountMediaIDs
+		}
+
+		for _, order := range result.Response.Orders {
+			source, sourceID := service.VaultSourcePost, order.PostID
+			if order.MessageID != "" {
+				source, sourceID = service.VaultSourceMessage, order.MessageID
+			}
+			mediaIDs := []string{order.AccountMediaID}
+			if order.AccountMediaBundleID != "" {
+				mediaIDs = bundles[order.AccountMediaBundleID]
+			}
+			for _, id := range mediaIDs {
+				item, ok := media[id]
+				if !ok {
+					continue
+				}
+				item.BundleID = order.AccountMediaBundleID
+				item.Source, item.SourceID = source, sourceID
+				item.Price = order.Price
+				item.PurchasedAt = time.Unix(order.CreatedAt, 0).UTC()
+				items = append(items, item)
+			}
+		}
+
+		if len(result.Response.Orders) < purchasePageSize {
+			return items, nil
+		}
+	}
+}
+
+// Download streams the file at a media URL to w and returns the number of bytes written
+func (c *FanslyClient) Download(ctx context.Context, mediaURL string, w io.Writer) (int64, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", mediaURL, nil)
+	if err != nil {
+		return 0, fmt.Errorf("error creating request: %w", err)
+	}
+	req.Header.Set("User-Agent", "fansly-api/1.0")
+
+	// Media files can take longer than the API timeout; ctx bounds the download instead.
+	// Downloads are recorded by the caller rather than per URL, which would add a
+	// metric series per file.
+	client := &http.Client{Transport: tracing.Transport(downloadTransport)}
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("error making request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+
+	n, err := io.Copy(w, resp.Body)
+	if err != nil {
+		return n, fmt.Errorf("error reading response: %w", err)
+	}
+	return n, nil
+}
+
+// downloadTransport is shared by media downloads. Only the wait for the response
+// headers is limited here; callers bound the transfer of the body.
+var downloadTransport = func() http.RoundTripper {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	t.ResponseHeaderTimeout = 30 * time.Second
+	return t
+}()
+
+// getJSON sends a GET request and decodes the JSON response into v
+func (c *FanslyClient) getJSON(ctx context.Context, endpoint string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", endpoint, nil)
+	if err != nil {
+		return fmt.Errorf("error creating request: %w", err)
+	}
+
+	req.Header.Set("Authorization", c.authToken)
+	req.Header.Set("User-Agent", "fansly-api/1.0")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("error making request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("error decoding response: %w", err)
+	}
+	return nil
+}
@@ -1,101 +1,289 @@
 package api
 
 import (
+	"errors"
 	"math"
 	"net/http"
-	"sort"
-	"strconv"
-	"time"
+	"net/url"
 
-	"github.com/agnosto/fansly-scraper/internal/service"
+	"github.com/go-chi/chi/v5"
+
+	"fansly-api/internal/events"
+	"fansly-api/internal/service"
+	"fansly-api/internal/storage"
+	"fansly-api/internal/validate"
 )
 
 // Creator represents a content creator
 type Creator = service.Creator
 
+// listCreatorsParams are the query parameters of GET /api/v1/creators
+type listCreatorsParams struct {
+	Limit      int    `query:"limit" default:"20" validate:"min=1,max=100"`
+	Offset     int    `query:"offset" default:"0" validate:"min=0"`
+	Cursor     string `query:"cursor"`
+	Sort       string `query:"sort" default:"name" validate:"oneof=name last_updated"`
+	Order      string `query:"order" default:"asc" validate:"oneof=asc desc"`
+	Verified   string `query:"verified" validate:"oneof=true false"`
+	Following  string `query:"following" validate:"oneof=true false"`
+	Subscribed string `query:"subscribed" validate:"oneof=true false"`
+}
+
 // handleListCreators handles GET /api/v1/creators
 // Query parameters:
 //   - limit: number of creators to return (default: 20, max: 100)
 //   - offset: number of creators to skip (default: 0)
+//   - cursor: opaque cursor from a previous page's next/prev link, instead of offset
 //   - sort: field to sort by (name, last_updated, default: name)
 //   - order: sort order (asc, desc, default: asc)
+//   - verified, following, subscribed: filter by flag (true, false)
 func (s *Server) handleListCreators(w http.ResponseWriter, r *http.Request) {
-	// Parse and validate query parameters
-	limit, err := strconv.Atoi(r.URL.Query().Get("limit"))
-	if err != nil || limit <= 0 {
-		limit = 20 // Default limit
+	var params listCreatorsParams
+	if !bindQuery(w, r, &params) {
+		return
 	}
-	if limit > 100 {
-		limit = 100 // Max limit
+	if params.Cursor != "" && r.URL.Query().Has("offset") {
+		respondValidationError(w, r, validate.Errors{{
+			Field: "cursor", Code: validate.CodeInvalid, Message: "cannot be combined with offset",
+		}})
+		return
 	}
 
-	offset, err := strconv.Atoi(r.URL.Query().Get("offset"))
-	if err != nil || offset < 0 {
-		offset = 0
-	}
+	s.logFor(r.Context()).Infof("Listing creators with limit=%d, offset=%d, sort=%s, order=%s",
+		params.Limit, params.Offset, params.Sort, params.Order)
 
-	sortBy := r.URL.Query().Get("sort")
-	switch sortBy {
-	case "", "name", "last_updated":
-		// Valid sort fields
-	default:
-		respondWithError(w, http.StatusBadRequest, "Invalid sort field. Must be one of: name, last_updated")
+	page, err := s.scraperSvc.GetCreators(r.Context(), service.CreatorQuery{
+		Limit:      params.Limit,
+		Offset:     params.Offset,
+		Cursor:     params.Cursor,
+		Sort:       params.Sort,
+		Order:      params.Order,
+		Verified:   parseBoolFilter(params.Verified),
+		Following:  parseBoolFilter(params.Following),
+		Subscribed: parseBoolFilter(params.Subscribed),
+	})
+	switch {
+	case errors.Is(err, service.ErrInvalidCursor):
+		respondValidationError(w, r, validate.Errors{{
+			Field: "cursor", Code: validate.CodeInvalid, Message: "is not a valid cursor for this sort order",
+		}})
+		return
+	case errors.Is(err, service.ErrNotAuthenticated):
+		respondWithError(w, http.StatusServiceUnavailable, "Fansly account is not connected")
+		return
+	case err != nil:
+		s.logFor(r.Context()).Errorf("Failed to get creators: %v", err)
+		respondWithError(w, http.StatusInternalServerError, "Failed to fetch creators")
 		return
 	}
 
-	order := r.URL.Query().Get("order")
-	if order != "" && order != "asc" && order != "desc" {
-		respondWithError(w, http.StatusBadRequest, "Invalid order. Must be one of: asc, desc")
+	links := map[string]string{"self": r.URL.RequestURI()}
+	if page.NextCursor != "" {
+		links["next"] = cursorLink(r.URL, page.NextCursor)
+	}
+	if page.PrevCursor != "" {
+		links["prev"] = cursorLink(r.URL, page.PrevCursor)
 	}
 
-	s.log.Infof("Listing creators with limit=%d, offset=%d, sort=%s, order=%s", 
-		limit, offset, sortBy, order)
+	response := map[string]interface{}{
+		"data": page.Creators,
+		"meta": map[string]interface{}{
+			"total":        page.Total,
+			"count":        len(page.Creators),
+			"offset":       page.Offset,
+			"per_page":     params.Limit,
+			"current_page": page.Offset/params.Limit + 1,
+			"total_pages":  int(math.Ceil(float64(page.Total) / float64(params.Limit))),
+			"next_cursor":  page.NextCursor,
+			"prev_cursor":  page.PrevCursor,
+		},
+		"links": links,
+	}
+	respondWithJSON(w, http.StatusOK, response)
+}
 
-	// Get creators from the scraper service
-	creators, err := s.scraperSvc.GetCreators(r.Context(), limit, offset)
-	if err != nil {
-		s.log.Errorf("Failed to get creators: %v", err)
-		respondWithError(w, http.StatusInternalServerError, "Failed to fetch creators")
+// searchCreatorsParams are the query parameters of GET /api/v1/creators/search
+type searchCreatorsParams struct {
+	Query string `query:"q" validate:"required,min=2,max=100"`
+	Limit int    `query:"limit" default:"20" validate:"min=1,max=50"`
+}
+
+// handleSearchCreators handles GET /api/v1/creators/search
+// Query parameters:
+//   - q: username or display name to search for; tolerates typos
+//   - limit: number of results to return (default: 20, max: 50)
+//
+// Results from Fansly's account search are merged with locally known creators. When
+// either side fails, the other's results are still returned and meta.fansly_search
+// or meta.local_search reports the failure.
+func (s *Server) handleSearchCreators(w http.ResponseWriter, r *http.Request) {
+	var params searchCreatorsParams
+	if !bindQuery(w, r, &params) {
 		return
 	}
 
-	// Apply sorting
-	if sortBy == "last_updated" {
-		sort.Slice(creators, func(i, j int) bool {
-			if order == "desc" {
-				return creators[i].LastUpdated.After(creators[j].LastUpdated)
-			}
-			return creators[i].LastUpdated.Before(creators[j].LastUpdated)
-		})
-	} else { // sort by name (default)
-		sort.Slice(creators, func(i, j int) bool {
-			if order == "desc" {
-				return creators[i].Name > creators[j].Name
-			}
-			return creators[i].Name < creators[j].Name
-		})
+	fanslySearch := "unavailable"
+	var remote []Creator
+	if s.fansly != nil {
+		var err error
+		remote, err = s.fansly.SearchAccounts(r.Context(), params.Query, params.Limit)
+		if err != nil {
+			s.logFor(r.Context()).Warnf("Fansly account search failed: %v", err)
+			fanslySearch = "failed"
+		} else {
+			fanslySearch = "ok"
+		}
 	}
 
-	total := len(creators)
-	// Prepare response
-	response := map[string]interface{}{
-		"data": creators,
+	localSearch := "ok"
+	matches, err := s.scraperSvc.SearchCreators(r.Context(), params.Query, remote, params.Limit)
+	switch {
+	case errors.Is(err, service.ErrNotAuthenticated) && fanslySearch == "ok":
+		// Without local creators the Fansly results are still worth returning
+		localSearch = "unavailable"
+		matches = service.MatchCreators(params.Query, remote, nil, params.Limit)
+	case errors.Is(err, service.ErrNotAuthenticated):
+		respondWithError(w, http.StatusServiceUnavailable, "Fansly account is not connected")
+		return
+	case err != nil:
+		s.logFor(r.Context()).Errorf("Failed to search creators: %v", err)
+		respondWithError(w, http.StatusInternalServerError, "Failed to search creators")
+		return
+	}
+	if matches == nil {
+		matches = []service.CreatorMatch{}
+	}
+
+	respondWithJSON(w, http.StatusOK, map[string]interface{}{
+		"data": matches,
 		"meta": map[string]interface{}{
-			"total":       total,
-			"count":       len(creators),
-			"per_page":    limit,
-			"current_page": (offset / limit) + 1,
-			"total_pages": int(math.Ceil(float64(total) / float64(limit))),
+			"query":         params.Query,
+			"count":         len(matches),
+			"fansly_search": fanslySearch,
+			"local_search":  localSearch,
 		},
+	})
+}
+
+// creatorDetail is a creator's profile together with our local sync state
+type creatorDetail struct {
+	*service.CreatorProfile
+	Sync *storage.CreatorSync `json:"sync"`
+}
+
+// handleGetCreator handles GET /api/v1/creators/{id}. The response carries an ETag
+// and honours If-None-Match.
+func (s *Server) handleGetCreator(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	profile, err := s.scraperSvc.GetCreator(r.Context(), id)
+	switch {
+	case errors.Is(err, service.ErrCreatorNotFound):
+		respondWithError(w, http.StatusNotFound, "Creator not found")
+		return
+	case errors.Is(err, service.ErrNotAuthenticated):
+		respondWithError(w, http.StatusServiceUnavailable, "Fansly account is not connected")
+		return
+	case err != nil:
+		s.logFor(r.Context()).Errorf("Failed to get creator %s: %v", id, err)
+		respondWithError(w, http.StatusInternalServerError, "Failed to fetch creator")
+		return
 	}
-	respondWithJSON(w, http.StatusOK, response)
+
+	sync, err := s.creatorSync.Get(r.Context(), id)
+	if errors.Is(err, storage.ErrNotFound) {
+		sync = &storage.CreatorSync{CreatorID: id, Status: storage.SyncStatusNeverSynced}
+	} else if err != nil {
+		s.logFor(r.Context()).Errorf("Failed to get sync state of creator %s: %v", id, err)
+		respondWithError(w, http.StatusInternalServerError, "Failed to fetch creator")
+		return
+	}
+
+	respondWithETag(w, r, map[string]interface{}{
+		"data": creatorDetail{CreatorProfile: profile, Sync: sync},
+	})
+}
+
+// handleFollowCreator handles POST /api/v1/creators/{id}/follow
+func (s *Server) handleFollowCreator(w http.ResponseWriter, r *http.Request) {
+	s.setFollowing(w, r, true)
+}
+
+// handleUnfollowCreator handles DELETE /api/v1/creators/{id}/follow
+func (s *Server) handleUnfollowCreator(w http.ResponseWriter, r *http.Request) {
+	s.setFollowing(w, r, false)
+}
+
+// setFollowing follows or unfollows a creator on Fansly. Requests that wouldn't
+// change anything succeed without calling Fansly or emitting an event.
+func (s *Server) setFollowing(w http.ResponseWriter, r *http.Request, following bool) {
+	id := chi.URLParam(r, "id")
+
+	profile, err := s.scraperSvc.GetCreator(r.Context(), id)
+	switch {
+	case errors.Is(err, service.ErrCreatorNotFound):
+		respondWithError(w, http.StatusNotFound, "Creator not found")
+		return
+	case errors.Is(err, service.ErrNotAuthenticated) || (err == nil && s.fansly == nil):
+		respondWithError(w, http.StatusServiceUnavailable, "Fansly account is not connected")
+		return
+	case err != nil:
+		s.logFor(r.Context()).Errorf("Failed to get creator %s: %v", id, err)
+		respondWithError(w, http.StatusInternalServerError, "Failed to fetch creator")
+		return
+	}
+
+	if profile.IsFollowing != following {
+		call, eventType := s.fansly.FollowAccount, events.CreatorFollowed
+		if !following {
+			call, eventType = s.fansly.UnfollowAccount, events.CreatorUnfollowed
+		}
+		if err := call(r.Context(), id); err != nil {
+			s.logFor(r.Context()).Errorf("Failed to update follow of creator %s on Fansly: %v", id, err)
+			respondWithError(w, http.StatusBadGateway, "Fansly rejected the request")
+			return
+		}
+		if err := s.scraperSvc.SetFollowing(r.Context(), id, following); err != nil {
+			s.logFor(r.Context()).Errorf("Failed to record follow of creator %s: %v", id, err)
+			respondWithError(w, http.StatusInternalServerError, "Failed to update creator")
+			return
+		}
+		data := map[string]interface{}{"creator_id": id}
+		if p, ok := PrincipalFromContext(r.Context()); ok {
+			data["user_id"] = p.ID
+		}
+		s.events.Publish(eventType, data)
+	}
+
+	respondWithJSON(w, http.StatusOK, map[string]interface{}{
+		"data": map[string]interface{}{"creator_id": id, "following": following},
+	})
+}
+
+// parseBoolFilter converts an optional, already validated "true"/"false" parameter
+func parseBoolFilter(value string) *bool {
+	if value == "" {
+		return nil
+	}
+	b := value == "true"
+	return &b
+}
+
+// cursorLink returns the request URL with its offset replaced by cursor
+func cursorLink(u *url.URL, cursor string) string {
+	query := u.Query()
+	query.Del("offset")
+	query.Set("cursor", cursor)
+	link := *u
+	link.RawQuery = query.Encode()
+	return link.RequestURI()
 }
 
 // handleGetCreatorContent gets content from a specific creator
 func (s *Server) handleGetCreatorContent(w http.ResponseWriter, r *http.Request) {
 	// TODO: Implement getting creator content
 	creatorID := r.URL.Query().Get("creatorId")
-	s.log.Infof("Get creator content for: %s", creatorID)
+	s.logFor(r.Context()).Infof("Get creator content for: %s", creatorID)
 	respondWithJSON(w, http.StatusNotImplemented, map[string]string{
 		"message":  "Get creator content not yet implemented",
 		"creatorId": creatorID,
@@ -108,7 +296,7 @@ func (s *Server) handleGetMedia(w http.ResponseWriter, r *http.Request) {
 	creatorID := r.URL.Query().Get("creatorId")
 	mediaID := r.URL.Query().Get("mediaId")
 
-	s.log.Infof("Get media %s for creator %s", mediaID, creatorID)
+	s.logFor(r.Context()).Infof("Get media %s for creator %s", mediaID, creatorID)
 	respondWithJSON(w, http.StatusNotImplemented, map[string]string{
 		"message":  "Get media not yet implemented",
 		"creatorId": creatorID,
@@ -119,7 +307,7 @@ func (s *Server) handleGetMedia(w http.ResponseWriter, r *http.Request) {
 // handleStartMonitoring starts monitoring a creator for new content
 func (s *Server) handleStartMonitoring(w http.ResponseWriter, r *http.Request) {
 	// TODO: Implement start monitoring
-	s.log.Info("Start monitoring endpoint hit")
+	s.logFor(r.Context()).Info("Start monitoring endpoint hit")
 	respondWithJSON(w, http.StatusNotImplemented, map[string]string{
 		"message": "Start monitoring not yet implemented",
 	})
@@ -128,7 +316,7 @@ func (s *Server) handleStartMonitoring(w http.ResponseWriter, r *http.Request) {
 // handleStopMonitoring stops monitoring
 func (s *Server) handleStopMonitoring(w http.ResponseWriter, r *http.Request) {
 	// TODO: Implement stop monitoring
-	s.log.Info("Stop monitoring endpoint hit")
+	s.logFor(r.Context()).Info("Stop monitoring endpoint hit")
 	respondWithJSON(w, http.StatusNotImplemented, map[string]string{
 		"message": "Stop monitoring not yet implemented",
 	})
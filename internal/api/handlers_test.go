@@ -0,0 +1,66 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"fansly-api/internal/config"
+	"fansly-api/internal/logger"
+	"fansly-api/internal/service"
+)
+
+func TestSearchCreatorsWithoutLocalCreators(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/account/search" || r.URL.Query().Get("searchQuery") != "alice" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(`{"response":{"accounts":[
+			{"id":"1","username":"alice","displayName":"Alice","following":true},
+			{"id":"2","username":"alicia"},
+			{"id":"3","displayName":"No username"}
+		]}}`))
+	}))
+	t.Cleanup(upstream.Close)
+
+	// The scraper service isn't authenticated, so local creators are unavailable
+	s := newTestServer(t, &config.Config{})
+	s.fansly = NewFanslyClient("token", logger.New())
+	s.fansly.baseURL = upstream.URL
+	token, err := s.generateJWT("user", []string{RoleViewer})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := httptest.NewRequest("GET", "/api/v1/creators/search?q=alice", nil)
+	r.Header.Set("Authorization", "Bearer "+token)
+	rec := serve(s, r)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", rec.Code, rec.Body)
+	}
+	var resp struct {
+		Data []service.CreatorMatch `json:"data"`
+		Meta struct {
+			FanslySearch string `json:"fansly_search"`
+			LocalSearch  string `json:"local_search"`
+		} `json:"meta"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Meta.FanslySearch != "ok" || resp.Meta.LocalSearch != "unavailable" {
+		t.Fatalf("meta %+v", resp.Meta)
+	}
+	if len(resp.Data) != 2 || resp.Data[0].ID != "1" || resp.Data[0].Name != "Alice" || !resp.Data[0].IsFollowing ||
+		resp.Data[0].Source != service.SourceFansly || resp.Data[1].Name != "alicia" {
+		t.Fatalf("data %+v", resp.Data)
+	}
+
+	// Without either source the search fails
+	s.fansly.baseURL = upstream.URL + "/down"
+	if rec := serve(s, r); rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("both sources unavailable: status %d, want 503", rec.Code)
+	}
+}
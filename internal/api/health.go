@@ -0,0 +1,67 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"path/filepath"
+	"time"
+
+	"fansly-api/internal/health"
+)
+
+// fanslyCheckTTL is how long the result of the Fansly token check is reused
+const fanslyCheckTTL = 5 * time.Minute
+
+// storeFiles are the files in the data directory that stores always persist to
+var storeFiles = []string{
+	"api_keys.json", "users.json", "creator_sync.json", "follows.json", "subscriptions.json",
+	"posts.json", "messages.json", "stories.json", "download_jobs.json",
+}
+
+// registerHealthChecks adds the readiness checks for the server's dependencies.
+// Background workers register a health.Heartbeat check when they start.
+func (s *Server) registerHealthChecks(dataDir string) {
+	files := append([]string(nil), storeFiles...)
+	if s.config.SessionStore == "file" {
+		files = append(files, "sessions.json")
+	}
+	if s.config.AuthPendingStore == "file" {
+		files = append(files, "pending_auth.json", "oauth_states.json")
+	}
+	paths := make([]string, len(files))
+	for i, file := range files {
+		paths[i] = filepath.Join(dataDir, file)
+	}
+	s.health.Register("storage", health.FilesReadable(paths...))
+	s.health.Register("data_dir", health.DirWritable(dataDir))
+	if s.fansly != nil {
+		s.health.Register("fansly_auth", health.Cached(func(ctx context.Context) error {
+			_, err := s.fansly.GetAccountInfo(ctx)
+			return err
+		}, fanslyCheckTTL))
+	}
+	if s.downloader != nil {
+		s.health.Register("vault_downloads", s.downloader.Check)
+	}
+}
+
+// handleLiveness handles GET /health/live. It only reports that the process is
+// serving requests, so orchestrators don't restart it over a broken dependency.
+func (s *Server) handleLiveness(w http.ResponseWriter, r *http.Request) {
+	respondWithJSON(w, http.StatusOK, map[string]string{"status": health.StatusOK})
+}
+
+// handleReadiness handles GET /health/ready and returns 503 when any check fails
+func (s *Server) handleReadiness(w http.ResponseWriter, r *http.Request) {
+	report := s.health.Run(r.Context())
+	if !report.OK() {
+		for _, check := range report.Checks {
+			if check.Status != health.StatusOK {
+				s.logFor(r.Context()).Warnf("Readiness check %s failed: %s", check.Name, check.Error)
+			}
+		}
+		respondWithJSON(w, http.StatusServiceUnavailable, report)
+		return
+	}
+	respondWithJSON(w, http.StatusOK, report)
+}
@@ -0,0 +1,384 @@
+package api
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"fansly-api/internal/config"
+)
+
+// signingKey is a single key that can verify (and optionally sign) JWTs
+type signingKey struct {
+	kid    string
+	method jwt.SigningMethod
+	signer interface{}      // private key or HMAC secret, nil for verify-only keys
+	public crypto.PublicKey // nil for HMAC keys
+}
+
+// verifyKey returns the key material used to verify signatures
+func (k *signingKey) verifyKey() interface{} {
+	if k.public != nil {
+		return k.public
+	}
+	return k.signer
+}
+
+// keySet holds the active signing key and every key still accepted for verification
+type keySet struct {
+	mu     sync.RWMutex
+	active *signingKey
+	keys   map[string]*signingKey
+}
+
+// jwk is a single JSON Web Key as defined by RFC 7517
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+}
+
+// jwkSet is the document served from /.well-known/jwks.json
+type jwkSet struct {
+	Keys []jwk `json:"keys"`
+}
+
+// newKeySet builds the key set described by the configuration
+func newKeySet(cfg *config.Config) (*keySet, error) {
+	ks := &keySet{keys: make(map[string]*signingKey)}
+
+	var active *signingKey
+	var err error
+	switch strings.ToUpper(cfg.JWTAlgorithm) {
+	case "", "HS256":
+		secret := []byte(cfg.JWTSecret)
+		if len(secret) == 0 {
+			// Development only: config.Validate rejects an empty secret in production
+			random, err := generateRandomString(64)
+			if err != nil {
+				return nil, fmt.Errorf("failed to generate development secret: %w", err)
+			}
+			secret = []byte(random)
+		}
+		active = &signingKey{method: jwt.SigningMethodHS256, signer: secret}
+	case "RS256", "EDDSA":
+		active, err = loadPrivateKey(cfg.JWTPrivateKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		// The algorithm follows from the key type; refuse a key that doesn't match the configuration
+		if !strings.EqualFold(active.method.Alg(), cfg.JWTAlgorithm) {
+			return nil, fmt.Errorf("JWT_PRIVATE_KEY_FILE holds a %s key but JWT_ALGORITHM is %s", active.method.Alg(), cfg.JWTAlgorithm)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported JWT algorithm: %s", cfg.JWTAlgorithm)
+	}
+	ks.Rotate(active)
+
+	for _, path := range cfg.GetJWTVerifyKeyFiles() {
+		key, err := loadPublicKey(path)
+		if err != nil {
+			return nil, err
+		}
+		ks.AddVerificationKey(key)
+	}
+
+	return ks, nil
+}
+
+// Rotate makes key the active signing key. The previous key stays valid for verification.
+func (ks *keySet) Rotate(key *signingKey) {
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+	ks.active = key
+	ks.keys[key.kid] = key
+}
+
+// AddVerificationKey accepts tokens signed by key without using it for signing
+func (ks *keySet) AddVerificationKey(key *signingKey) {
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+	ks.keys[key.kid] = key
+}
+
+// RemoveKey stops accepting tokens signed by the key with the given ID
+func (ks *keySet) RemoveKey(kid string) error {
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+	if ks.active != nil && ks.active.kid == kid {
+		return errors.New("cannot remove the active signing key")
+	}
+	delete(ks.keys, kid)
+	return nil
+}
+
+// Sign signs the claims with the active key, setting the kid header for asymmetric keys
+func (ks *keySet) Sign(c jwt.Claims) (string, error) {
+	ks.mu.RLock()
+	active := ks.active
+	ks.mu.RUnlock()
+
+	token := jwt.NewWithClaims(active.method, c)
+	if active.kid != "" {
+		token.Header["kid"] = active.kid
+	}
+	return token.SignedString(active.signer)
+}
+
+// Methods returns the algorithms of every key accepted for verification
+func (ks *keySet) Methods() []string {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+	seen := make(map[string]bool)
+	var methods []string
+	for _, key := range ks.keys {
+		if alg := key.method.Alg(); !seen[alg] {
+			seen[alg] = true
+			methods = append(methods, alg)
+		}
+	}
+	sort.Strings(methods)
+	return methods
+}
+
+// Keyfunc resolves the verification key for a token from its kid and alg headers
+func (ks *keySet) Keyfunc(token *jwt.Token) (interface{}, error) {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+
+	kid, _ := token.Header["kid"].(string)
+	key, ok := ks.keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown signing key: %q", kid)
+	}
+	if token.Method.Alg() != key.method.Alg() {
+		return nil, fmt.Errorf("unexpected signing method: %s", token.Method.Alg())
+	}
+	return key.verifyKey(), nil
+}
+
+// JWKS returns the public keys in JWK Set format. HMAC secrets are never published.
+func (ks *keySet) JWKS() jwkSet {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+	set := jwkSet{Keys: []jwk{}}
+	for _, key := range ks.keys {
+		if key.public == nil {
+			continue
+		}
+		if k, err := publicJWK(key.public); err == nil {
+			k.Kid = key.kid
+			k.Use = "sig"
+			k.Alg = key.method.Alg()
+			set.Keys = append(set.Keys, k)
+		}
+	}
+	sort.Slice(set.Keys, func(i, j int) bool { return set.Keys[i].Kid < set.Keys[j].Kid })
+	return set
+}
+
+// handleJWKS handles GET /.well-known/jwks.json
+func (s *Server) handleJWKS(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	respondWithJSON(w, http.StatusOK, s.keys.JWKS())
+}
+
+// loadPrivateKey reads an RSA or Ed25519 private key from a PEM file
+func loadPrivateKey(path string) (*signingKey, error) {
+	block, err := readPEM(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var parsed interface{}
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	default:
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse private key %s: %w", path, err)
+	}
+
+	signer, ok := parsed.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("unsupported private key type in %s", path)
+	}
+	key, err := newAsymmetricKey(signer.Public())
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	key.signer = signer
+	return key, nil
+}
+
+// loadPublicKey reads an RSA or Ed25519 public key from a PEM file
+func loadPublicKey(path string) (*signingKey, error) {
+	block, err := readPEM(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var parsed interface{}
+	switch block.Type {
+	case "RSA PUBLIC KEY":
+		parsed, err = x509.ParsePKCS1PublicKey(block.Bytes)
+	default:
+		parsed, err = x509.ParsePKIXPublicKey(block.Bytes)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse public key %s: %w", path, err)
+	}
+
+	key, err := newAsymmetricKey(parsed)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return key, nil
+}
+
+// newAsymmetricKey creates a verify-only key identified by its RFC 7638 thumbprint
+func newAsymmetricKey(public crypto.PublicKey) (*signingKey, error) {
+	key := &signingKey{public: public}
+	switch public.(type) {
+	case *rsa.PublicKey:
+		key.method = jwt.SigningMethodRS256
+	case ed25519.PublicKey:
+		key.method = jwt.SigningMethodEdDSA
+	default:
+		return nil, fmt.Errorf("unsupported key type %T", public)
+	}
+
+	k, err := publicJWK(public)
+	if err != nil {
+		return nil, err
+	}
+	key.kid = k.thumbprint()
+	return key, nil
+}
+
+// publicJWK converts a public key to its JWK representation without kid/use/alg
+func publicJWK(public crypto.PublicKey) (jwk, error) {
+	switch pub := public.(type) {
+	case *rsa.PublicKey:
+		return jwk{
+			Kty: "RSA",
+			N:   base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+			E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+		}, nil
+	case ed25519.PublicKey:
+		return jwk{
+			Kty: "OKP",
+			Crv: "Ed25519",
+			X:   base64.RawURLEncoding.EncodeToString(pub),
+		}, nil
+	default:
+		return jwk{}, fmt.Errorf("unsupported key type %T", public)
+	}
+}
+
+// publicKey parses a JWK published by another issuer into a public key
+func (k jwk) publicKey() (crypto.PublicKey, error) {
+	decode := base64.RawURLEncoding.DecodeString
+	switch {
+	case k.Kty == "RSA":
+		n, err := decode(k.N)
+		if err != nil {
+			return nil, fmt.Errorf("invalid RSA modulus: %w", err)
+		}
+		e, err := decode(k.E)
+		if err != nil {
+			return nil, fmt.Errorf("invalid RSA exponent: %w", err)
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, nil
+	case k.Kty == "OKP" && k.Crv == "Ed25519":
+		x, err := decode(k.X)
+		if err != nil || len(x) != ed25519.PublicKeySize {
+			return nil, errors.New("invalid Ed25519 key")
+		}
+		return ed25519.PublicKey(x), nil
+	case k.Kty == "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %s", k.Crv)
+		}
+		x, err := decode(k.X)
+		if err != nil {
+			return nil, fmt.Errorf("invalid EC x coordinate: %w", err)
+		}
+		y, err := decode(k.Y)
+		if err != nil {
+			return nil, fmt.Errorf("invalid EC y coordinate: %w", err)
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %s", k.Kty)
+	}
+}
+
+// thumbprint computes the RFC 7638 JWK thumbprint used as the key ID
+func (k jwk) thumbprint() string {
+	// Required members only, in lexicographic order
+	var members interface{}
+	switch k.Kty {
+	case "RSA":
+		members = struct {
+			E   string `json:"e"`
+			Kty string `json:"kty"`
+			N   string `json:"n"`
+		}{k.E, k.Kty, k.N}
+	default:
+		members = struct {
+			Crv string `json:"crv"`
+			Kty string `json:"kty"`
+			X   string `json:"x"`
+		}{k.Crv, k.Kty, k.X}
+	}
+	data, _ := json.Marshal(members)
+	sum := sha256.Sum256(data)
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// readPEM reads the first PEM block from a file
+func readPEM(path string) (*pem.Block, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read key file: %w", err)
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("no PEM data found in %s", path)
+	}
+	return block, nil
+}
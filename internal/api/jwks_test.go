@@ -0,0 +1,210 @@
+package api
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"fansly-api/internal/config"
+)
+
+// writeRSAKey writes a new RSA private key and its public key as PEM files
+func writeRSAKey(t *testing.T) (privatePath, publicPath string) {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	public, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	privatePath, publicPath = filepath.Join(dir, "key.pem"), filepath.Join(dir, "key.pub")
+	writePEM(t, privatePath, "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(key))
+	writePEM(t, publicPath, "PUBLIC KEY", public)
+	return privatePath, publicPath
+}
+
+func writePEM(t *testing.T, path, blockType string, der []byte) {
+	t.Helper()
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// newRSAKeySet creates a key set signing with a new RSA key
+func newRSAKeySet(t *testing.T) *keySet {
+	t.Helper()
+	privatePath, _ := writeRSAKey(t)
+	ks, err := newKeySet(&config.Config{JWTAlgorithm: "RS256", JWTPrivateKeyFile: privatePath})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ks
+}
+
+// verify parses a token the way the auth middleware does
+func verify(ks *keySet, token string) error {
+	_, err := jwt.Parse(token, ks.Keyfunc, jwt.WithValidMethods(ks.Methods()))
+	return err
+}
+
+func testClaims() jwt.Claims {
+	return jwt.RegisteredClaims{Subject: "user", ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour))}
+}
+
+func TestKeySetSignAndVerify(t *testing.T) {
+	ks := newRSAKeySet(t)
+	token, err := ks.Sign(testClaims())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := verify(ks, token); err != nil {
+		t.Fatalf("token signed with the active key rejected: %v", err)
+	}
+
+	hmac, err := newKeySet(&config.Config{JWTSecret: "secret"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	token, err = hmac.Sign(testClaims())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := verify(hmac, token); err != nil {
+		t.Fatalf("HS256 token rejected: %v", err)
+	}
+}
+
+func TestKeySetRotation(t *testing.T) {
+	ks := newRSAKeySet(t)
+	oldToken, err := ks.Sign(testClaims())
+	if err != nil {
+		t.Fatal(err)
+	}
+	old := ks.active
+
+	privatePath, _ := writeRSAKey(t)
+	next, err := loadPrivateKey(privatePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ks.Rotate(next)
+	// The rotated-out key is kept for verification only
+	verifyOnly := *old
+	verifyOnly.signer = nil
+	ks.AddVerificationKey(&verifyOnly)
+
+	if err := verify(ks, oldToken); err != nil {
+		t.Fatalf("token of the rotated-out key rejected: %v", err)
+	}
+	newToken, err := ks.Sign(testClaims())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := verify(ks, newToken); err != nil {
+		t.Fatalf("token of the new key rejected: %v", err)
+	}
+
+	if err := ks.RemoveKey(next.kid); err == nil {
+		t.Fatal("removed the active signing key")
+	}
+	if err := ks.RemoveKey(old.kid); err != nil {
+		t.Fatal(err)
+	}
+	if err := verify(ks, oldToken); err == nil {
+		t.Fatal("token of a removed key accepted")
+	}
+}
+
+func TestKeySetRejectsUnknownKeys(t *testing.T) {
+	ks := newRSAKeySet(t)
+	other := newRSAKeySet(t)
+
+	token, err := other.Sign(testClaims())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := verify(ks, token); err == nil {
+		t.Fatal("token with an unknown kid accepted")
+	}
+
+	// Same key, but no kid header
+	noKid := jwt.NewWithClaims(jwt.SigningMethodRS256, testClaims())
+	signed, err := noKid.SignedString(ks.active.signer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := verify(ks, signed); err == nil {
+		t.Fatal("token without kid accepted")
+	}
+}
+
+func TestKeySetRejectsAlgorithmMismatch(t *testing.T) {
+	ks := newRSAKeySet(t)
+	// Also accept HS256 so the method filter alone can't reject the token
+	ks.AddVerificationKey(&signingKey{kid: "hmac", method: jwt.SigningMethodHS256, signer: []byte("secret")})
+
+	// HS256 keyed with the RSA public key, claiming the RSA key's kid
+	public, err := x509.MarshalPKIXPublicKey(ks.active.public)
+	if err != nil {
+		t.Fatal(err)
+	}
+	forged := jwt.NewWithClaims(jwt.SigningMethodHS256, testClaims())
+	forged.Header["kid"] = ks.active.kid
+	signed, err := forged.SignedString(public)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := verify(ks, signed); err == nil {
+		t.Fatal("HS256 token accepted for an RSA key")
+	}
+}
+
+func TestKeySetJWKS(t *testing.T) {
+	ks, err := newKeySet(&config.Config{JWTSecret: "secret"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if set := ks.JWKS(); len(set.Keys) != 0 {
+		t.Fatalf("HMAC secret published: %+v", set.Keys)
+	}
+
+	_, publicPath := writeRSAKey(t)
+	rsaKey, err := loadPublicKey(publicPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ks.AddVerificationKey(rsaKey)
+	edPublic, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	edKey, err := newAsymmetricKey(edPublic)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ks.AddVerificationKey(edKey)
+
+	set := ks.JWKS()
+	if len(set.Keys) != 2 {
+		t.Fatalf("%d keys published, want 2", len(set.Keys))
+	}
+	for _, k := range set.Keys {
+		if k.Kty == "oct" || k.Kid == "" || k.Use != "sig" {
+			t.Fatalf("unexpected key: %+v", k)
+		}
+		if want := map[string]string{rsaKey.kid: "RS256", edKey.kid: "EdDSA"}[k.Kid]; k.Alg != want {
+			t.Fatalf("key %s: alg %s, want %s", k.Kid, k.Alg, want)
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"go.opentelemetry.io/otel/trace"
+
+	"fansly-api/internal/logger"
+	"fansly-api/internal/metrics"
+)
+
+// requestInfo collects details about a request that are only known further down
+// the middleware chain, such as the authenticated principal
+type requestInfo struct {
+	principal *Principal
+}
+
+type requestInfoKey struct{}
+
+// accessLog is a middleware that gives each request a logger carrying its request ID,
+// writes one structured access log entry when the request completes and records
+// the request metrics
+func (s *Server) accessLog(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		reqID := middleware.GetReqID(r.Context())
+		if reqID != "" {
+			w.Header().Set(middleware.RequestIDHeader, reqID)
+		}
+
+		info := &requestInfo{}
+		reqLog := s.log.With("request_id", reqID)
+		if sc := trace.SpanContextFromContext(r.Context()); sc.IsValid() {
+			reqLog = reqLog.With("trace_id", sc.TraceID().String(), "span_id", sc.SpanID().String())
+		}
+		ctx := context.WithValue(r.Context(), requestInfoKey{}, info)
+		ctx = logger.WithContext(ctx, reqLog)
+
+		metrics.HTTPRequestsInFlight.Add(1)
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+		defer func() {
+			metrics.HTTPRequestsInFlight.Add(-1)
+			duration := time.Since(start)
+			status := ww.Status()
+			if status == 0 {
+				status = http.StatusOK
+			}
+			route := chi.RouteContext(ctx).RoutePattern()
+			if route == "" {
+				route = "unmatched"
+			}
+			metrics.HTTPRequests.Inc(r.Method, route, strconv.Itoa(status))
+			metrics.HTTPRequestDuration.Observe(duration.Seconds(), r.Method, route)
+
+			fields := []interface{}{
+				"method", r.Method,
+				"path", r.URL.Path,
+				"route", route,
+				"status", status,
+				"bytes", ww.BytesWritten(),
+				"duration_ms", float64(duration.Microseconds()) / 1000,
+				"remote_ip", clientIP(r),
+				"user_agent", r.UserAgent(),
+			}
+			if info.principal != nil {
+				fields = append(fields, "user_id", info.principal.ID, "principal_type", info.principal.Type)
+			}
+			reqLog.With(fields...).Infof("%s %s %d", r.Method, r.URL.Path, status)
+		}()
+
+		next.ServeHTTP(ww, r.WithContext(ctx))
+	})
+}
+
+// logFor returns the request's logger, falling back to the server logger
+func (s *Server) logFor(ctx context.Context) logger.Logger {
+	if l, ok := logger.FromContext(ctx); ok {
+		return l
+	}
+	return s.log
+}
+
+// logLevelRequest is the body of PUT /api/v1/admin/log-level
+type logLevelRequest struct {
+	Level string `json:"level" validate:"required,oneof=debug info warn warning error"`
+}
+
+// handleGetLogLevel handles GET /api/v1/admin/log-level
+func (s *Server) handleGetLogLevel(w http.ResponseWriter, r *http.Request) {
+	respondWithJSON(w, http.StatusOK, map[string]string{"level": s.log.Level()})
+}
+
+// handleSetLogLevel handles PUT /api/v1/admin/log-level. The change applies to
+// every logger derived from the server logger and lasts until restart.
+func (s *Server) handleSetLogLevel(w http.ResponseWriter, r *http.Request) {
+	var req logLevelRequest
+	if !bindJSON(w, r, &req) {
+		return
+	}
+	previous := s.log.Level()
+	if err := s.log.SetLevel(req.Level); err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	p, _ := PrincipalFromContext(r.Context())
+	s.logFor(r.Context()).Warnf("Log level changed from %s to %s by %s %s", previous, s.log.Level(), p.Type, p.ID)
+	respondWithJSON(w, http.StatusOK, map[string]string{"level": s.log.Level()})
+}
@@ -0,0 +1,123 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"fansly-api/internal/storage"
+	"fansly-api/internal/validate"
+)
+
+// listMessageGroupsParams are the query parameters of GET /api/v1/messages/groups
+type listMessageGroupsParams struct {
+	Limit  int `query:"limit" default:"20" validate:"min=1,max=100"`
+	Offset int `query:"offset" default:"0" validate:"min=0"`
+}
+
+// handleListMessageGroups handles GET /api/v1/messages/groups, listing mirrored
+// message groups, most recently active first
+func (s *Server) handleListMessageGroups(w http.ResponseWriter, r *http.Request) {
+	var params listMessageGroupsParams
+	if !bindQuery(w, r, &params) {
+		return
+	}
+
+	groups, err := s.messages.ListGroups(r.Context())
+	if err != nil {
+		s.logFor(r.Context()).Errorf("Failed to list message groups: %v", err)
+		respondWithError(w, http.StatusInternalServerError, "Failed to fetch message groups")
+		return
+	}
+
+	total := len(groups)
+	start := min(params.Offset, total)
+	end := min(start+params.Limit, total)
+	page := groups[start:end]
+
+	respondWithJSON(w, http.StatusOK, map[string]interface{}{
+		"data": page,
+		"meta": map[string]interface{}{
+			"total":    total,
+			"count":    len(page),
+			"offset":   params.Offset,
+			"per_page": params.Limit,
+		},
+	})
+}
+
+// getMessageGroupParams are the query parameters of GET /api/v1/messages/groups/{id}
+type getMessageGroupParams struct {
+	Limit  int    `query:"limit" default:"50" validate:"min=1,max=200"`
+	Before string `query:"before"`
+}
+
+// handleGetMessageGroup handles GET /api/v1/messages/groups/{id}, returning a group
+// and one page of its messages, newest first. The next page starts before the
+// oldest message returned, so messages mirrored in the meantime don't shift pages.
+func (s *Server) handleGetMessageGroup(w http.ResponseWriter, r *http.Request) {
+	var params getMessageGroupParams
+	if !bindQuery(w, r, &params) {
+		return
+	}
+
+	id := chi.URLParam(r, "id")
+	group, err := s.messages.GetGroup(r.Context(), id)
+	if errors.Is(err, storage.ErrNotFound) {
+		respondWithError(w, http.StatusNotFound, "Message group not found")
+		return
+	} else if err != nil {
+		s.logFor(r.Context()).Errorf("Failed to get message group %s: %v", id, err)
+		respondWithError(w, http.StatusInternalServerError, "Failed to fetch message group")
+		return
+	}
+
+	messages, err := s.messages.ListMessages(r.Context(), id)
+	if err != nil {
+		s.logFor(r.Context()).Errorf("Failed to list messages of group %s: %v", id, err)
+		respondWithError(w, http.StatusInternalServerError, "Failed to fetch messages")
+		return
+	}
+
+	start := 0
+	if params.Before != "" {
+		start = -1
+		for i, m := range messages {
+			if m.ID == params.Before {
+				start = i + 1
+				break
+			}
+		}
+		if start < 0 {
+			respondValidationError(w, r, validate.Errors{{
+				Field: "before", Code: validate.CodeInvalid, Message: "is not a message in this group",
+			}})
+			return
+		}
+	}
+	end := min(start+params.Limit, len(messages))
+	page := messages[start:end]
+
+	links := map[string]string{"self": r.URL.RequestURI()}
+	if end < len(messages) {
+		next := *r.URL
+		query := next.Query()
+		query.Set("before", page[len(page)-1].ID)
+		next.RawQuery = query.Encode()
+		links["next"] = next.RequestURI()
+	}
+
+	respondWithJSON(w, http.StatusOK, map[string]interface{}{
+		"data": map[string]interface{}{
+			"group":    group,
+			"messages": page,
+		},
+		"meta": map[string]interface{}{
+			"total":    len(messages),
+			"count":    len(page),
+			"per_page": params.Limit,
+		},
+		"links": links,
+	})
+}
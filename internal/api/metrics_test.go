@@ -0,0 +1,39 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"fansly-api/internal/config"
+)
+
+func TestMetricsToken(t *testing.T) {
+	s := newTestServer(t, &config.Config{MetricsToken: "scrape-token"})
+	scrape := func(authorization string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest("GET", "/metrics", nil)
+		if authorization != "" {
+			r.Header.Set("Authorization", authorization)
+		}
+		return serve(s, r)
+	}
+
+	for _, authorization := range []string{"", "Bearer wrong-token", "scrape-token"} {
+		rec := scrape(authorization)
+		if rec.Code != http.StatusUnauthorized || rec.Header().Get("WWW-Authenticate") == "" {
+			t.Errorf("Authorization %q: status %d, want 401 with a challenge", authorization, rec.Code)
+		}
+	}
+	rec := scrape("Bearer scrape-token")
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "# TYPE fansly_api_http_requests_total counter") {
+		t.Fatalf("scrape with token: status %d", rec.Code)
+	}
+}
+
+func TestMetricsWithoutToken(t *testing.T) {
+	s := newTestServer(t, &config.Config{})
+	if rec := serve(s, httptest.NewRequest("GET", "/metrics", nil)); rec.Code != http.StatusOK {
+		t.Fatalf("scrape without METRICS_TOKEN: status %d, want 200", rec.Code)
+	}
+}
@@ -2,57 +2,71 @@ package api
 
 import (
 	"net/http"
-	"strings"
 )
 
 // APIKeyAuth is a middleware that checks for a valid API key in the request
+// and stores the key's principal in the request context. It is only applied
+// through requireAuth, so public routes never reach it.
 func (s *Server) APIKeyAuth(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		// Skip auth for health check and auth endpoints
-		if r.URL.Path == "/" || 
-		   r.URL.Path == "/api/v1/health" ||
-		   strings.HasPrefix(r.URL.Path, "/api/v1/auth/") {
-			next.ServeHTTP(w, r)
-			return
-		}
-
 		// Get API key from header or query parameter
-		apiKey := r.Header.Get("X-API-Key")
+		apiKey := apiKeyFromRequest(r)
 		if apiKey == "" {
-			apiKey = r.URL.Query().Get("api_key")
+			s.logFor(r.Context()).Warnf("Missing API key from %s", r.RemoteAddr)
+			respondWithError(w, http.StatusUnauthorized, "Invalid or missing API key")
+			return
 		}
 
 		// Validate API key
-		if !s.config.IsValidAPIKey(apiKey) {
-			s.log.Warnf("Invalid or missing API key from %s", r.RemoteAddr)
+		key, err := s.resolveAPIKey(r.Context(), apiKey)
+		if err != nil {
+			s.logFor(r.Context()).Warnf("Invalid API key from %s: %v", r.RemoteAddr, err)
 			respondWithError(w, http.StatusUnauthorized, "Invalid or missing API key")
 			return
 		}
 
-		// Add user context if needed
-		ctx := r.Context()
-		// ctx = context.WithValue(ctx, "userID", userID) // You can add user context here
-		next.ServeHTTP(w, r.WithContext(ctx))
+		// Add the key's principal to the request context
+		next.ServeHTTP(w, withPrincipal(r, &Principal{
+			ID:          key.ID,
+			Type:        principalAPIKey,
+			Scopes:      key.Scopes,
+			Permissions: resolvePermissions(scopePermissions, key.Scopes, nil),
+		}))
 	})
 }
 
-// RequireAuth is a middleware that ensures the user is authenticated
+// RequireAuth is a middleware that ensures the user has a valid session cookie.
+// State-changing requests must also carry the session's CSRF token.
 func (s *Server) RequireAuth(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		// Check for session token
-		sessionID, err := r.Cookie("session_id")
+		sessionID, err := r.Cookie(sessionCookieName)
 		if err != nil || sessionID.Value == "" {
-			s.log.Warn("Unauthorized access attempt - no session")
+			s.logFor(r.Context()).Warnf("Unauthorized access attempt - no session")
 			respondWithError(w, http.StatusUnauthorized, "Authentication required")
 			return
 		}
 
-		// TODO: Validate session
-		// if !isValidSession(sessionID.Value) {
-		// 	respondWithError(w, http.StatusUnauthorized, "Invalid or expired session")
-		// 	return
-		// }
+		session, err := s.resolveSession(r.Context(), sessionID.Value)
+		if err != nil {
+			s.logFor(r.Context()).Warnf("Invalid session from %s: %v", r.RemoteAddr, err)
+			s.clearSessionCookie(w)
+			respondWithError(w, http.StatusUnauthorized, "Invalid or expired session")
+			return
+		}
+
+		if !validCSRFToken(r, session) {
+			s.logFor(r.Context()).Warnf("CSRF token mismatch for session of user %s", session.UserID)
+			respondWithError(w, http.StatusForbidden, "Invalid or missing CSRF token")
+			return
+		}
 
-		next.ServeHTTP(w, r)
+		next.ServeHTTP(w, withPrincipal(r, &Principal{
+			ID:          session.UserID,
+			Type:        principalUser,
+			Roles:       session.Roles,
+			Permissions: resolvePermissions(rolePermissions, session.Roles, nil),
+			SessionID:   session.ID,
+		}))
 	})
 }
@@ -0,0 +1,193 @@
+package api
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"fansly-api/internal/storage"
+)
+
+// oauthStateTTL is how long a user has to finish logging in at the identity provider
+const oauthStateTTL = 10 * time.Minute
+
+// oauthStateCookieName holds a hash of the login state, binding the callback to the
+// browser that started the login so a callback URL can't be replayed in another
+// browser to log it into someone else's account
+const oauthStateCookieName = "oauth_state"
+
+// handleOAuthLogin handles GET /api/v1/auth/login and redirects to the identity provider
+func (s *Server) handleOAuthLogin(w http.ResponseWriter, r *http.Request) {
+	state, err := generateRandomString(32)
+	if err != nil {
+		s.logFor(r.Context()).Errorf("Failed to generate OAuth state: %v", err)
+		respondWithError(w, http.StatusInternalServerError, "Failed to start login")
+		return
+	}
+	verifier, err := generateRandomString(64)
+	if err != nil {
+		s.logFor(r.Context()).Errorf("Failed to generate PKCE verifier: %v", err)
+		respondWithError(w, http.StatusInternalServerError, "Failed to start login")
+		return
+	}
+	nonce, err := generateRandomString(32)
+	if err != nil {
+		s.logFor(r.Context()).Errorf("Failed to generate OIDC nonce: %v", err)
+		respondWithError(w, http.StatusInternalServerError, "Failed to start login")
+		return
+	}
+
+	now := time.Now()
+	err = s.oauthStates.Add(r.Context(), &storage.PendingAuth{
+		Token:        state,
+		IPAddress:    clientIP(r),
+		CreatedAt:    now,
+		ExpiresAt:    now.Add(oauthStateTTL),
+		CodeVerifier: verifier,
+		Nonce:        nonce,
+	}, s.config.AuthPendingMaxPerIP)
+	if errors.Is(err, storage.ErrLimitExceeded) {
+		respondWithError(w, http.StatusTooManyRequests, "Too many pending logins")
+		return
+	}
+	if err != nil {
+		s.logFor(r.Context()).Errorf("Failed to store OAuth state: %v", err)
+		respondWithError(w, http.StatusInternalServerError, "Failed to start login")
+		return
+	}
+
+	s.setOAuthStateCookie(w, hashOAuthState(state), int(oauthStateTTL.Seconds()))
+	http.Redirect(w, r, s.oauth.AuthCodeURL(state, pkceChallenge(verifier), nonce), http.StatusFound)
+}
+
+// handleOAuthCallback handles GET /api/v1/auth/callback, the identity provider's redirect
+// back to us after login
+func (s *Server) handleOAuthCallback(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	if errCode := query.Get("error"); errCode != "" {
+		s.logFor(r.Context()).Warnf("OAuth login failed at provider: %s %s", errCode, query.Get("error_description"))
+		respondWithError(w, http.StatusUnauthorized, "Login was not completed")
+		return
+	}
+
+	code, state := query.Get("code"), query.Get("state")
+	if code == "" || state == "" {
+		respondWithError(w, http.StatusBadRequest, "Missing code or state")
+		return
+	}
+
+	// The state must belong to a login started by this browser
+	cookie, err := r.Cookie(oauthStateCookieName)
+	s.setOAuthStateCookie(w, "", -1)
+	if err != nil || subtle.ConstantTimeCompare([]byte(cookie.Value), []byte(hashOAuthState(state))) != 1 {
+		s.logFor(r.Context()).Warnf("OAuth state from %s doesn't match the login cookie", clientIP(r))
+		respondWithError(w, http.StatusBadRequest, "Invalid or expired login state")
+		return
+	}
+
+	// Each state can only be used once
+	pending, err := s.oauthStates.Take(r.Context(), state, time.Now())
+	if err != nil {
+		s.logFor(r.Context()).Warnf("Invalid OAuth state from %s: %v", clientIP(r), err)
+		respondWithError(w, http.StatusBadRequest, "Invalid or expired login state")
+		return
+	}
+
+	identity, err := s.oauth.Exchange(r.Context(), code, pending.CodeVerifier, pending.Nonce)
+	if err != nil {
+		s.logFor(r.Context()).Warnf("OAuth token exchange failed: %v", err)
+		respondWithError(w, http.StatusUnauthorized, "Login failed")
+		return
+	}
+
+	user, err := s.provisionUser(r.Context(), identity)
+	if err != nil {
+		s.logFor(r.Context()).Errorf("Failed to provision user: %v", err)
+		respondWithError(w, http.StatusInternalServerError, "Failed to complete login")
+		return
+	}
+
+	token, err := s.generateJWT(user.ID, user.Roles)
+	if err != nil {
+		s.logFor(r.Context()).Errorf("Failed to generate JWT: %v", err)
+		respondWithError(w, http.StatusInternalServerError, "Failed to generate token")
+		return
+	}
+	session, err := s.createSession(w, r, user.ID, user.Roles)
+	if err != nil {
+		s.logFor(r.Context()).Errorf("Failed to create session: %v", err)
+		respondWithError(w, http.StatusInternalServerError, "Failed to create session")
+		return
+	}
+
+	s.logFor(r.Context()).Infof("User %s logged in via %s", user.ID, s.oauth.Name())
+	respondWithJSON(w, http.StatusOK, authResponse{
+		Token:     token,
+		ExpiresIn: int(s.tokenExpiry().Seconds()),
+		CSRFToken: session.CSRFToken,
+	})
+}
+
+// setOAuthStateCookie sets the login state cookie, or clears it when maxAge is negative
+func (s *Server) setOAuthStateCookie(w http.ResponseWriter, value string, maxAge int) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     oauthStateCookieName,
+		Value:    value,
+		Path:     "/api/v1/auth/",
+		MaxAge:   maxAge,
+		HttpOnly: true,
+		Secure:   s.config.Environment == "production",
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+// hashOAuthState returns the cookie value for a login state
+func hashOAuthState(state string) string {
+	sum := sha256.Sum256([]byte(state))
+	return hex.EncodeToString(sum[:])
+}
+
+// provisionUser returns the user linked to the external identity, creating it on first login
+func (s *Server) provisionUser(ctx context.Context, identity *externalIdentity) (*storage.User, error) {
+	now := time.Now()
+	user, err := s.users.GetByIdentity(ctx, s.oauth.Name(), identity.Subject)
+	if err == nil {
+		user.Email = identity.Email
+		user.Name = identity.Name
+		user.LastLoginAt = now
+		if err := s.users.Update(ctx, user); err != nil {
+			return nil, fmt.Errorf("failed to update user: %w", err)
+		}
+		return user, nil
+	}
+	if !errors.Is(err, storage.ErrNotFound) {
+		return nil, err
+	}
+
+	id, err := generateRandomString(16)
+	if err != nil {
+		return nil, err
+	}
+	user = &storage.User{
+		ID:          id,
+		Provider:    s.oauth.Name(),
+		Subject:     identity.Subject,
+		Email:       identity.Email,
+		Name:        identity.Name,
+		CreatedAt:   now,
+		LastLoginAt: now,
+	}
+	if s.config.DefaultRole != "" {
+		user.Roles = []string{s.config.DefaultRole}
+	}
+	if err := s.users.Create(ctx, user); err != nil {
+		return nil, fmt.Errorf("failed to create user: %w", err)
+	}
+	s.logFor(ctx).Infof("Provisioned user %s for %s subject %s", user.ID, user.Provider, user.Subject)
+	return user, nil
+}
@@ -0,0 +1,216 @@
+package api
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"fansly-api/internal/config"
+)
+
+// fakeOIDCProvider is a local OpenID Connect provider serving discovery, JWKS and
+// the token endpoint. It issues an ID token for code "valid-code" when the PKCE
+// verifier matches the challenge of the last authorization request.
+type fakeOIDCProvider struct {
+	*httptest.Server
+	key       *rsa.PrivateKey
+	challenge string // code_challenge the token endpoint expects
+	nonce     string // nonce claim of issued ID tokens
+	audience  string // aud claim of issued ID tokens
+}
+
+func newFakeOIDCProvider(t *testing.T) *fakeOIDCProvider {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := &fakeOIDCProvider{key: key, audience: "client-id"}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 p.URL,
+			"authorization_endpoint": p.URL + "/authorize",
+			"token_endpoint":         p.URL + "/token",
+			"jwks_uri":               p.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		k, err := publicJWK(&key.PublicKey)
+		if err != nil {
+			t.Error(err)
+		}
+		k.Kid, k.Use, k.Alg = "idp-key", "sig", "RS256"
+		json.NewEncoder(w).Encode(jwkSet{Keys: []jwk{k}})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		if r.Form.Get("code") != "valid-code" || pkceChallenge(r.Form.Get("code_verifier")) != p.challenge {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+			return
+		}
+		token := jwt.NewWithClaims(jwt.SigningMethodRS256, &idTokenClaims{
+			Nonce: p.nonce,
+			Email: "user@example.com",
+			RegisteredClaims: jwt.RegisteredClaims{
+				Issuer:    p.URL,
+				Subject:   "subject-1",
+				Audience:  jwt.ClaimStrings{p.audience},
+				ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+			},
+		})
+		token.Header["kid"] = "idp-key"
+		signed, err := token.SignedString(key)
+		if err != nil {
+			t.Error(err)
+		}
+		json.NewEncoder(w).Encode(map[string]string{"access_token": "access", "token_type": "Bearer", "id_token": signed})
+	})
+	p.Server = httptest.NewServer(mux)
+	t.Cleanup(p.Close)
+	return p
+}
+
+// oauthLogin is a login started with GET /api/v1/auth/login
+type oauthLogin struct {
+	state  string
+	cookie *http.Cookie
+}
+
+// startOAuthLogin starts a login and records the nonce and PKCE challenge at the provider
+func startOAuthLogin(t *testing.T, s *Server, idp *fakeOIDCProvider) oauthLogin {
+	t.Helper()
+	rec := serve(s, httptest.NewRequest("GET", "/api/v1/auth/login", nil))
+	if rec.Code != http.StatusFound {
+		t.Fatalf("login: status %d", rec.Code)
+	}
+	location, err := url.Parse(rec.Header().Get("Location"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	query := location.Query()
+	if query.Get("code_challenge_method") != "S256" || query.Get("nonce") == "" {
+		t.Fatalf("login redirect lacks PKCE or nonce: %s", location)
+	}
+	idp.challenge, idp.nonce = query.Get("code_challenge"), query.Get("nonce")
+
+	login := oauthLogin{state: query.Get("state")}
+	for _, c := range rec.Result().Cookies() {
+		if c.Name == oauthStateCookieName {
+			login.cookie = c
+		}
+	}
+	if login.cookie == nil || !login.cookie.HttpOnly || login.cookie.SameSite != http.SameSiteLaxMode {
+		t.Fatalf("login state cookie missing or not HttpOnly/SameSite=Lax: %v", login.cookie)
+	}
+	return login
+}
+
+// callback completes a login with the given state and cookie (nil for none)
+func callback(s *Server, state string, cookie *http.Cookie) *httptest.ResponseRecorder {
+	r := httptest.NewRequest("GET", "/api/v1/auth/callback?code=valid-code&state="+url.QueryEscape(state), nil)
+	if cookie != nil {
+		r.AddCookie(cookie)
+	}
+	return serve(s, r)
+}
+
+func newOIDCTestServer(t *testing.T) (*Server, *fakeOIDCProvider) {
+	t.Helper()
+	idp := newFakeOIDCProvider(t)
+	s := newTestServer(t, &config.Config{
+		OAuthProvider:       "oidc",
+		OIDCIssuer:          idp.URL,
+		ClientID:            "client-id",
+		CallbackURL:         "http://localhost/api/v1/auth/callback",
+		DefaultRole:         RoleViewer,
+		AuthPendingMaxPerIP: 10,
+	})
+	return s, idp
+}
+
+func TestOAuthLogin(t *testing.T) {
+	s, idp := newOIDCTestServer(t)
+	login := startOAuthLogin(t, s, idp)
+
+	rec := callback(s, login.state, login.cookie)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("callback: status %d: %s", rec.Code, rec.Body)
+	}
+	var resp authResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil || resp.Token == "" {
+		t.Fatalf("callback returned no token: %v", err)
+	}
+	user, err := s.users.GetByIdentity(t.Context(), idp.URL, "subject-1")
+	if err != nil || user.Email != "user@example.com" {
+		t.Fatalf("user not provisioned: %v", err)
+	}
+
+	// Each state can only be used once
+	if rec := callback(s, login.state, login.cookie); rec.Code != http.StatusBadRequest {
+		t.Fatalf("reused state: status %d, want 400", rec.Code)
+	}
+}
+
+func TestOAuthCallbackRequiresStateCookie(t *testing.T) {
+	s, idp := newOIDCTestServer(t)
+	victim := startOAuthLogin(t, s, idp)
+	attacker := startOAuthLogin(t, s, idp)
+
+	if rec := callback(s, attacker.state, nil); rec.Code != http.StatusBadRequest {
+		t.Fatalf("callback without cookie: status %d, want 400", rec.Code)
+	}
+	// The attacker's callback URL opened in the victim's browser
+	if rec := callback(s, attacker.state, victim.cookie); rec.Code != http.StatusBadRequest {
+		t.Fatalf("callback with another login's cookie: status %d, want 400", rec.Code)
+	}
+	forged := &http.Cookie{Name: oauthStateCookieName, Value: hashOAuthState("unknown")}
+	if rec := callback(s, "unknown", forged); rec.Code != http.StatusBadRequest {
+		t.Fatalf("callback with unknown state: status %d, want 400", rec.Code)
+	}
+
+	// Rejected callbacks don't use up the state of the login they carried
+	if rec := callback(s, attacker.state, attacker.cookie); rec.Code != http.StatusOK {
+		t.Fatalf("callback from the browser that started the login: status %d: %s", rec.Code, rec.Body)
+	}
+}
+
+func TestOAuthCallbackRejectsNonceMismatch(t *testing.T) {
+	s, idp := newOIDCTestServer(t)
+	login := startOAuthLogin(t, s, idp)
+	idp.nonce = "replayed-nonce"
+
+	if rec := callback(s, login.state, login.cookie); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("nonce mismatch: status %d, want 401", rec.Code)
+	}
+}
+
+func TestOAuthCallbackRejectsWrongVerifier(t *testing.T) {
+	s, idp := newOIDCTestServer(t)
+	login := startOAuthLogin(t, s, idp)
+	// The provider saw a different challenge, as when a code is redeemed by another client
+	idp.challenge = pkceChallenge("another-verifier")
+
+	if rec := callback(s, login.state, login.cookie); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("PKCE mismatch: status %d, want 401", rec.Code)
+	}
+}
+
+func TestOAuthCallbackRejectsWrongAudience(t *testing.T) {
+	s, idp := newOIDCTestServer(t)
+	login := startOAuthLogin(t, s, idp)
+	idp.audience = "another-client"
+
+	if rec := callback(s, login.state, login.cookie); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("ID token for another client: status %d, want 401", rec.Code)
+	}
+}
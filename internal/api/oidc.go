@@ -0,0 +1,346 @@
+package api
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"fansly-api/internal/config"
+)
+
+// jwksRefreshInterval limits how often an unknown kid triggers a JWKS refetch
+const jwksRefreshInterval = time.Minute
+
+// externalIdentity is the user returned by an identity provider after login
+type externalIdentity struct {
+	Subject string
+	Email   string
+	Name    string
+}
+
+// identityProvider performs the OAuth2 authorization-code flow against an external IdP
+type identityProvider interface {
+	// Name identifies the provider on provisioned users
+	Name() string
+	// AuthCodeURL returns the URL the user is redirected to for login
+	AuthCodeURL(state, codeChallenge, nonce string) string
+	// Exchange trades the authorization code for the user's identity
+	Exchange(ctx context.Context, code, codeVerifier, nonce string) (*externalIdentity, error)
+}
+
+// oauthEndpoints are the provider URLs used by the authorization-code flow
+type oauthEndpoints struct {
+	AuthURL     string `json:"authorization_endpoint"`
+	TokenURL    string `json:"token_endpoint"`
+	UserInfoURL string `json:"userinfo_endpoint"`
+	JWKSURL     string `json:"jwks_uri"`
+	Issuer      string `json:"issuer"`
+}
+
+// tokenResponse is the token endpoint response (RFC 6749 section 5.1)
+type tokenResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	IDToken     string `json:"id_token"`
+	Error       string `json:"error"`
+	ErrorDesc   string `json:"error_description"`
+}
+
+// oauth2Provider is a plain OAuth2 provider that identifies users through its userinfo endpoint
+type oauth2Provider struct {
+	name         string
+	endpoints    oauthEndpoints
+	clientID     string
+	clientSecret string
+	redirectURL  string
+	scopes       []string
+	httpClient   *http.Client
+}
+
+// oidcProvider is an OpenID Connect provider that identifies users from the signed ID token
+type oidcProvider struct {
+	oauth2Provider
+
+	mu        sync.RWMutex
+	keys      map[string]interface{}
+	fetchedAt time.Time
+}
+
+// newIdentityProvider creates the provider selected by the configuration, or nil when
+// OAuth2 login is disabled
+func newIdentityProvider(ctx context.Context, cfg *config.Config) (identityProvider, error) {
+	base := oauth2Provider{
+		name:         cfg.OAuthProvider,
+		clientID:     cfg.ClientID,
+		clientSecret: cfg.ClientSecret,
+		redirectURL:  cfg.CallbackURL,
+		scopes:       cfg.GetScopes(),
+		httpClient:   &http.Client{Timeout: 30 * time.Second},
+		endpoints: oauthEndpoints{
+			AuthURL:     cfg.AuthURL,
+			TokenURL:    cfg.TokenURL,
+			UserInfoURL: cfg.UserInfoURL,
+		},
+	}
+
+	switch cfg.OAuthProvider {
+	case "":
+		return nil, nil
+	case "oauth2":
+		return &base, nil
+	case "oidc":
+		endpoints, err := discoverOIDC(ctx, base.httpClient, cfg.OIDCIssuer)
+		if err != nil {
+			return nil, err
+		}
+		base.name = endpoints.Issuer
+		base.endpoints = *endpoints
+		return &oidcProvider{oauth2Provider: base}, nil
+	default:
+		return nil, fmt.Errorf("unsupported OAuth provider: %s", cfg.OAuthProvider)
+	}
+}
+
+// discoverOIDC loads the provider metadata from the issuer's discovery document
+func discoverOIDC(ctx context.Context, client *http.Client, issuer string) (*oauthEndpoints, error) {
+	discoveryURL := strings.TrimSuffix(issuer, "/") + "/.well-known/openid-configuration"
+	var endpoints oauthEndpoints
+	if err := getJSON(ctx, client, discoveryURL, "", &endpoints); err != nil {
+		return nil, fmt.Errorf("error discovering OIDC provider: %w", err)
+	}
+	if endpoints.Issuer != issuer {
+		return nil, fmt.Errorf("OIDC issuer mismatch: got %q, want %q", endpoints.Issuer, issuer)
+	}
+	if endpoints.AuthURL == "" || endpoints.TokenURL == "" || endpoints.JWKSURL == "" {
+		return nil, errors.New("OIDC discovery document is missing required endpoints")
+	}
+	return &endpoints, nil
+}
+
+// Name identifies the provider on provisioned users
+func (p *oauth2Provider) Name() string {
+	return p.name
+}
+
+// AuthCodeURL returns the URL the user is redirected to for login
+func (p *oauth2Provider) AuthCodeURL(state, codeChallenge, nonce string) string {
+	params := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {p.clientID},
+		"redirect_uri":          {p.redirectURL},
+		"state":                 {state},
+		"code_challenge":        {codeChallenge},
+		"code_challenge_method": {"S256"},
+	}
+	if len(p.scopes) > 0 {
+		params.Set("scope", strings.Join(p.scopes, " "))
+	}
+	if nonce != "" {
+		params.Set("nonce", nonce)
+	}
+
+	sep := "?"
+	if strings.Contains(p.endpoints.AuthURL, "?") {
+		sep = "&"
+	}
+	return p.endpoints.AuthURL + sep + params.Encode()
+}
+
+// Exchange trades the code for an access token and looks the user up at the userinfo endpoint
+func (p *oauth2Provider) Exchange(ctx context.Context, code, codeVerifier, nonce string) (*externalIdentity, error) {
+	token, err := p.exchangeCode(ctx, code, codeVerifier)
+	if err != nil {
+		return nil, err
+	}
+
+	var info struct {
+		Sub   string `json:"sub"`
+		ID    string `json:"id"`
+		Email string `json:"email"`
+		Name  string `json:"name"`
+	}
+	if err := getJSON(ctx, p.httpClient, p.endpoints.UserInfoURL, token.AccessToken, &info); err != nil {
+		return nil, fmt.Errorf("error fetching user info: %w", err)
+	}
+
+	subject := info.Sub
+	if subject == "" {
+		subject = info.ID
+	}
+	if subject == "" {
+		return nil, errors.New("user info has no subject")
+	}
+	return &externalIdentity{Subject: subject, Email: info.Email, Name: info.Name}, nil
+}
+
+// exchangeCode calls the token endpoint with the authorization code and PKCE verifier
+func (p *oauth2Provider) exchangeCode(ctx context.Context, code, codeVerifier string) (*tokenResponse, error) {
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {p.redirectURL},
+		"client_id":     {p.clientID},
+		"code_verifier": {codeVerifier},
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", p.endpoints.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("error creating request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if p.clientSecret != "" {
+		req.SetBasicAuth(url.QueryEscape(p.clientID), url.QueryEscape(p.clientSecret))
+	}
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error making request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var token tokenResponse
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return nil, fmt.Errorf("error decoding token response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK || token.Error != "" {
+		return nil, fmt.Errorf("token exchange failed: %d %s %s", resp.StatusCode, token.Error, token.ErrorDesc)
+	}
+	if token.AccessToken == "" {
+		return nil, errors.New("token response has no access token")
+	}
+	return &token, nil
+}
+
+// idTokenClaims are the OIDC ID token claims we use
+type idTokenClaims struct {
+	Nonce string `json:"nonce"`
+	Email string `json:"email"`
+	Name  string `json:"name"`
+	jwt.RegisteredClaims
+}
+
+// Exchange trades the code for tokens and identifies the user from the verified ID token
+func (p *oidcProvider) Exchange(ctx context.Context, code, codeVerifier, nonce string) (*externalIdentity, error) {
+	token, err := p.exchangeCode(ctx, code, codeVerifier)
+	if err != nil {
+		return nil, err
+	}
+	if token.IDToken == "" {
+		return nil, errors.New("token response has no ID token")
+	}
+
+	claims := &idTokenClaims{}
+	_, err = jwt.ParseWithClaims(token.IDToken, claims,
+		func(t *jwt.Token) (interface{}, error) { return p.key(ctx, t) },
+		jwt.WithValidMethods([]string{"RS256", "RS384", "RS512", "ES256", "ES384", "ES512", "EdDSA"}),
+		jwt.WithIssuer(p.endpoints.Issuer),
+		jwt.WithAudience(p.clientID),
+		jwt.WithExpirationRequired(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("invalid ID token: %w", err)
+	}
+	if claims.Nonce != nonce {
+		return nil, errors.New("invalid ID token: nonce mismatch")
+	}
+	if claims.Subject == "" {
+		return nil, errors.New("invalid ID token: missing subject")
+	}
+	return &externalIdentity{Subject: claims.Subject, Email: claims.Email, Name: claims.Name}, nil
+}
+
+// key returns the provider's verification key for the token, refetching the JWKS
+// when the key ID is unknown (the provider may have rotated keys)
+func (p *oidcProvider) key(ctx context.Context, token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+
+	p.mu.RLock()
+	key, ok := p.keys[kid]
+	stale := time.Since(p.fetchedAt) > jwksRefreshInterval
+	p.mu.RUnlock()
+	if ok {
+		return key, nil
+	}
+	if !stale {
+		return nil, fmt.Errorf("unknown signing key: %q", kid)
+	}
+
+	if err := p.refreshKeys(ctx); err != nil {
+		return nil, err
+	}
+
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if key, ok := p.keys[kid]; ok {
+		return key, nil
+	}
+	return nil, fmt.Errorf("unknown signing key: %q", kid)
+}
+
+// refreshKeys fetches the provider's JWKS
+func (p *oidcProvider) refreshKeys(ctx context.Context) error {
+	var set jwkSet
+	if err := getJSON(ctx, p.httpClient, p.endpoints.JWKSURL, "", &set); err != nil {
+		return fmt.Errorf("error fetching provider keys: %w", err)
+	}
+
+	keys := make(map[string]interface{}, len(set.Keys))
+	for _, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		public, err := k.publicKey()
+		if err != nil {
+			continue
+		}
+		keys[k.Kid] = public
+	}
+
+	p.mu.Lock()
+	p.keys = keys
+	p.fetchedAt = time.Now()
+	p.mu.Unlock()
+	return nil
+}
+
+// getJSON performs a GET request, optionally with a bearer token, and decodes the JSON response
+func getJSON(ctx context.Context, client *http.Client, url, bearer string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return fmt.Errorf("error creating request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	if bearer != "" {
+		req.Header.Set("Authorization", "Bearer "+bearer)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("error making request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("error decoding response: %w", err)
+	}
+	return nil
+}
+
+// pkceChallenge returns the S256 code challenge for a PKCE verifier (RFC 7636)
+func pkceChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
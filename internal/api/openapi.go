@@ -0,0 +1,743 @@
+package api
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+
+	"fansly-api/internal/service"
+	"fansly-api/internal/storage"
+)
+
+//go:embed docs.html
+var docsHTML []byte
+
+// apiParam is a path or query parameter of an operation
+type apiParam struct {
+	Name        string
+	In          string // "path", "query" or "header"
+	Description string
+	Required    bool // path parameters are always required
+	Schema      schema
+}
+
+// apiOperation documents one route. Every route registered in setupRoutes must
+// have an entry in apiOperations; TestOpenAPICoversRoutes fails otherwise.
+type apiOperation struct {
+	Method      string
+	Path        string
+	Tag         string
+	Summary     string
+	Public      bool   // no authentication required
+	Permission  string // permission required in addition to authentication
+	Params      []apiParam
+	RequestBody string         // component schema of the JSON body
+	Responses   map[int]string // status code to component schema ("" for no body)
+}
+
+type schema = map[string]interface{}
+
+func ref(name string) schema {
+	return schema{"$ref": "#/components/schemas/" + name}
+}
+
+func arrayOf(items schema) schema {
+	return schema{"type": "array", "items": items}
+}
+
+func object(required []string, properties map[string]schema) schema {
+	s := schema{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		s["required"] = required
+	}
+	return s
+}
+
+var (
+	stringSchema   = schema{"type": "string"}
+	integerSchema  = schema{"type": "integer"}
+	booleanSchema  = schema{"type": "boolean"}
+	dateTimeSchema = schema{"type": "string", "format": "date-time"}
+)
+
+func idParam(description string) apiParam {
+	return apiParam{Name: "id", In: "path", Description: description, Schema: stringSchema}
+}
+
+// apiSchemas are the reusable component schemas referenced by apiOperations
+var apiSchemas = map[string]schema{
+	"Problem": object([]string{"type", "title", "status", "code"}, map[string]schema{
+		"type":       stringSchema,
+		"title":      stringSchema,
+		"status":     integerSchema,
+		"detail":     stringSchema,
+		"instance":   stringSchema,
+		"code":       schema{"type": "string", "description": "Machine-readable error code, e.g. validation_failed"},
+		"request_id": stringSchema,
+		"errors": arrayOf(object([]string{"field", "code", "message"}, map[string]schema{
+			"field":   stringSchema,
+			"code":    stringSchema,
+			"message": stringSchema,
+		})),
+		"permission": schema{"type": "string", "description": "Permission the caller lacks (403 only)"},
+	}),
+	"Status": object([]string{"status"}, map[string]schema{
+		"status": stringSchema,
+	}),
+	"HealthReport": object([]string{"status", "checks"}, map[string]schema{
+		"status": schema{"type": "string", "enum": []string{"ok", "fail"}},
+		"checks": arrayOf(object([]string{"name", "status", "latency_ms"}, map[string]schema{
+			"name":       stringSchema,
+			"status":     schema{"type": "string", "enum": []string{"ok", "fail"}},
+			"latency_ms": schema{"type": "number"},
+			"error":      stringSchema,
+		})),
+	}),
+	"JWKS": object([]string{"keys"}, map[string]schema{
+		"keys": arrayOf(schema{"type": "object"}),
+	}),
+	"AuthRequest": object([]string{"auth_token"}, map[string]schema{
+		"auth_token": stringSchema,
+		"user_agent": stringSchema,
+	}),
+	"AuthResponse": object(nil, map[string]schema{
+		"url":        stringSchema,
+		"token":      stringSchema,
+		"expires_in": integerSchema,
+		"csrf_token": stringSchema,
+	}),
+	"Session": object([]string{"id", "created_at", "last_seen_at", "expires_at", "current"}, map[string]schema{
+		"id":           stringSchema,
+		"user_agent":   stringSchema,
+		"ip_address":   stringSchema,
+		"created_at":   dateTimeSchema,
+		"last_seen_at": dateTimeSchema,
+		"expires_at":   dateTimeSchema,
+		"current":      booleanSchema,
+	}),
+	"SessionList": object([]string{"data"}, map[string]schema{
+		"data": arrayOf(ref("Session")),
+	}),
+	"CurrentSession": object([]string{"id", "user_id", "csrf_token", "expires_at"}, map[string]schema{
+		"id":         stringSchema,
+		"user_id":    stringSchema,
+		"csrf_token": stringSchema,
+		"expires_at": dateTimeSchema,
+	}),
+	"Creator": object([]string{"id", "name", "username", "is_verified", "is_following", "is_subscribed", "last_updated"}, map[string]schema{
+		"id":                   stringSchema,
+		"name":                 stringSchema,
+		"username":             stringSchema,
+		"avatar_url":           stringSchema,
+		"is_verified":          booleanSchema,
+		"is_following":         booleanSchema,
+		"is_subscribed":        schema{"type": "boolean", "description": "The account has an active paid subscription"},
+		"subscription_tier_id": schema{"type": "string", "description": "Tier of the active subscription"},
+		"last_updated":         dateTimeSchema,
+	}),
+	"CreatorDetail": object([]string{"data"}, map[string]schema{
+		"data": schema{"allOf": []schema{ref("Creator"), object(nil, map[string]schema{
+			"bio":        stringSchema,
+			"banner_url": stringSchema,
+			"avatar_variants": arrayOf(object([]string{"url", "width", "height"}, map[string]schema{
+				"url":    stringSchema,
+				"width":  integerSchema,
+				"height": integerSchema,
+			})),
+			"subscription_tiers": arrayOf(object([]string{"id", "name", "price", "currency", "duration_months"}, map[string]schema{
+				"id":              stringSchema,
+				"name":            stringSchema,
+				"price":           schema{"type": "integer", "description": "In the smallest currency unit, e.g. cents"},
+				"currency":        stringSchema,
+				"duration_months": integerSchema,
+			})),
+			"stats": object(nil, map[string]schema{
+				"posts":     integerSchema,
+				"media":     integerSchema,
+				"images":    integerSchema,
+				"videos":    integerSchema,
+				"followers": integerSchema,
+			}),
+			"last_active": dateTimeSchema,
+			"sync":        ref("CreatorSync"),
+		})}},
+	}),
+	"CreatorSync": object([]string{"creator_id", "status", "downloaded_media", "downloaded_bytes", "posts"}, map[string]schema{
+		"creator_id":       stringSchema,
+		"status":           schema{"type": "string", "enum": []string{storage.SyncStatusNeverSynced, storage.SyncStatusIdle, storage.SyncStatusSyncing, storage.SyncStatusFailed}},
+		"last_synced_at":   dateTimeSchema,
+		"last_error":       stringSchema,
+		"downloaded_media": integerSchema,
+		"downloaded_bytes": integerSchema,
+		"posts":            integerSchema,
+		"posts_synced_at":  dateTimeSchema,
+	}),
+	"CreatorSearchResults": object([]string{"data", "meta"}, map[string]schema{
+		"data": arrayOf(schema{"allOf": []schema{ref("Creator"), object([]string{"score", "source"}, map[string]schema{
+			"score":  schema{"type": "number"},
+			"source": schema{"type": "string", "enum": []string{service.SourceFansly, service.SourceLocal, service.SourceBoth}},
+		})}}),
+		"meta": object(nil, map[string]schema{
+			"query":         stringSchema,
+			"count":         integerSchema,
+			"fansly_search": schema{"type": "string", "enum": []string{"ok", "failed", "unavailable"}},
+			"local_search":  schema{"type": "string", "enum": []string{"ok", "unavailable"}},
+		}),
+	}),
+	"SearchResults": object([]string{"data", "meta"}, map[string]schema{
+		"data": arrayOf(object([]string{"post_id", "creator_id", "created_at", "score", "snippet"}, map[string]schema{
+			"post_id":    stringSchema,
+			"creator_id": stringSchema,
+			"created_at": dateTimeSchema,
+			"hashtags":   arrayOf(stringSchema),
+			"score":      schema{"type": "number"},
+			"snippet":    schema{"type": "string", "description": "HTML-escaped excerpt with matches wrapped in <mark>"},
+		})),
+		"meta": object(nil, map[string]schema{
+			"query":    stringSchema,
+			"total":    integerSchema,
+			"count":    integerSchema,
+			"offset":   integerSchema,
+			"per_page": integerSchema,
+		}),
+	}),
+	"Post": object([]string{"id", "creator_id", "text", "created_at", "synced_at"}, map[string]schema{
+		"id":         stringSchema,
+		"creator_id": stringSchema,
+		"text":       stringSchema,
+		"hashtags":   arrayOf(stringSchema),
+		"mentions":   arrayOf(stringSchema),
+		"tier_ids":   schema{"type": "array", "items": stringSchema, "description": "Subscription tiers that unlock the post; empty if followers can see it"},
+		"media": arrayOf(object([]string{"id", "type"}, map[string]schema{
+			"id":      stringSchema,
+			"type":    schema{"type": "string", "enum": []string{"image", "video"}},
+			"caption": stringSchema,
+		})),
+		"created_at": dateTimeSchema,
+		"synced_at":  dateTimeSchema,
+	}),
+	"PostList": object([]string{"data", "meta"}, map[string]schema{
+		"data": arrayOf(ref("Post")),
+		"meta": object(nil, map[string]schema{
+			"total":    integerSchema,
+			"count":    integerSchema,
+			"offset":   integerSchema,
+			"per_page": integerSchema,
+		}),
+	}),
+	"HashtagCounts": object([]string{"data"}, map[string]schema{
+		"data": arrayOf(object([]string{"name", "count"}, map[string]schema{
+			"name":  stringSchema,
+			"count": integerSchema,
+		})),
+	}),
+	"MentionGraph": object([]string{"data"}, map[string]schema{
+		"data": object([]string{"nodes", "edges"}, map[string]schema{
+			"nodes": arrayOf(object([]string{"id"}, map[string]schema{
+				"id":         schema{"type": "string", "description": "Creator ID, or @username for accounts that aren't known creators"},
+				"creator_id": stringSchema,
+				"username":   stringSchema,
+			})),
+			"edges": arrayOf(object([]string{"source", "target", "count"}, map[string]schema{
+				"source": stringSchema,
+				"target": stringSchema,
+				"count":  schema{"type": "integer", "description": "Number of posts with the mention"},
+			})),
+		}),
+	}),
+	"StoryList": object([]string{"data"}, map[string]schema{
+		"data": arrayOf(object([]string{"id", "creator_id", "created_at", "expires_at", "captured_at", "last_seen_at", "active"}, map[string]schema{
+			"id":         stringSchema,
+			"creator_id": stringSchema,
+			"media": arrayOf(object([]string{"id", "type"}, map[string]schema{
+				"id":       stringSchema,
+				"type":     schema{"type": "string", "enum": []string{"image", "video"}},
+				"location": schema{"type": "string", "description": "Download URL on Fansly, valid while the story is visible"},
+				"file":     schema{"type": "string", "description": "Local copy, relative to the stories directory; absent until downloaded"},
+			})),
+			"created_at":   dateTimeSchema,
+			"expires_at":   schema{"type": "string", "format": "date-time", "description": "When the story disappears from Fansly"},
+			"captured_at":  schema{"type": "string", "format": "date-time", "description": "When the story was first seen"},
+			"last_seen_at": schema{"type": "string", "format": "date-time", "description": "When the story was last seen on Fansly"},
+			"active":       schema{"type": "boolean", "description": "The story hasn't expired yet"},
+		})),
+	}),
+	"MessageGroup": object([]string{"id", "partner_id", "last_message_at", "message_count", "history_synced", "synced_at"}, map[string]schema{
+		"id":               stringSchema,
+		"partner_id":       schema{"type": "string", "description": "Account ID of the other participant"},
+		"partner_username": stringSchema,
+		"last_message_at":  dateTimeSchema,
+		"message_count":    schema{"type": "integer", "description": "Messages mirrored locally"},
+		"history_synced":   schema{"type": "boolean", "description": "Every message down to the oldest has been mirrored"},
+		"synced_at":        dateTimeSchema,
+	}),
+	"Message": object([]string{"id", "group_id", "sender_id", "content", "created_at"}, map[string]schema{
+		"id":        stringSchema,
+		"group_id":  stringSchema,
+		"sender_id": stringSchema,
+		"content":   stringSchema,
+		"attachments": arrayOf(object([]string{"id", "type"}, map[string]schema{
+			"id":   stringSchema,
+			"type": schema{"type": "string", "enum": []string{"media", "media_bundle", "tip", "other"}},
+		})),
+		"created_at": dateTimeSchema,
+	}),
+	"MessageGroupList": object([]string{"data", "meta"}, map[string]schema{
+		"data": arrayOf(ref("MessageGroup")),
+		"meta": object(nil, map[string]schema{
+			"total":    integerSchema,
+			"count":    integerSchema,
+			"offset":   integerSchema,
+			"per_page": integerSchema,
+		}),
+	}),
+	"MessageGroupDetail": object([]string{"data", "meta", "links"}, map[string]schema{
+		"data": object([]string{"group", "messages"}, map[string]schema{
+			"group":    ref("MessageGroup"),
+			"messages": arrayOf(ref("Message")),
+		}),
+		"meta": object(nil, map[string]schema{
+			"total":    integerSchema,
+			"count":    integerSchema,
+			"per_page": integerSchema,
+		}),
+		"links": object([]string{"self"}, map[string]schema{
+			"self": stringSchema,
+			"next": stringSchema,
+		}),
+	}),
+	"SubscriptionList": object([]string{"data", "meta"}, map[string]schema{
+		"data": arrayOf(object([]string{"creator_id", "tier_id", "price", "currency", "duration_months", "auto_renew", "synced_at", "active", "unlocked_posts"}, map[string]schema{
+			"creator_id":      stringSchema,
+			"tier_id":         stringSchema,
+			"tier_name":       stringSchema,
+			"price":           schema{"type": "integer", "description": "Paid per period, in the smallest currency unit, e.g. cents"},
+			"currency":        stringSchema,
+			"duration_months": integerSchema,
+			"auto_renew":      booleanSchema,
+			"subscribed_at":   dateTimeSchema,
+			"renews_at":       schema{"type": "string", "format": "date-time", "description": "Next renewal, or when access ends without auto-renewal; omitted when Fansly doesn't report it"},
+			"synced_at":       dateTimeSchema,
+			"active":          booleanSchema,
+			"unlocked_posts":  schema{"type": "integer", "description": "Synced posts restricted to tiers including this one"},
+		})),
+		"meta": object(nil, map[string]schema{
+			"count":       integerSchema,
+			"fansly_sync": schema{"type": "string", "enum": []string{"ok", "failed", "unavailable"}},
+		}),
+	}),
+	"VaultList": object([]string{"data", "meta"}, map[string]schema{
+		"data": arrayOf(object([]string{"media_id", "creator_id", "type", "source", "price", "purchased_at"}, map[string]schema{
+			"media_id":     stringSchema,
+			"bundle_id":    schema{"type": "string", "description": "Set when the media was bought as part of a bundle"},
+			"creator_id":   stringSchema,
+			"type":         schema{"type": "string", "enum": []string{"image", "video"}},
+			"source":       schema{"type": "string", "enum": []string{"post", "message"}},
+			"source_id":    schema{"type": "string", "description": "Post or message the purchase was made from"},
+			"price":        schema{"type": "integer", "description": "In the smallest currency unit, e.g. cents"},
+			"purchased_at": dateTimeSchema,
+		})),
+		"meta": object(nil, map[string]schema{
+			"total":    integerSchema,
+			"count":    integerSchema,
+			"offset":   integerSchema,
+			"per_page": integerSchema,
+		}),
+	}),
+	"DownloadJob": object([]string{"id", "status", "total", "downloaded", "skipped", "failed", "bytes", "created_by", "created_at"}, map[string]schema{
+		"id":          stringSchema,
+		"status":      schema{"type": "string", "enum": []string{"running", "completed", "failed", "cancelled"}},
+		"total":       integerSchema,
+		"downloaded":  integerSchema,
+		"skipped":     schema{"type": "integer", "description": "Items already downloaded by an earlier job"},
+		"failed":      integerSchema,
+		"bytes":       schema{"type": "integer", "description": "Bytes downloaded by this job"},
+		"last_error":  stringSchema,
+		"created_by":  stringSchema,
+		"created_at":  dateTimeSchema,
+		"finished_at": dateTimeSchema,
+	}),
+	"DownloadJobDetail": object([]string{"data"}, map[string]schema{
+		"data": ref("DownloadJob"),
+	}),
+	"DownloadJobList": object([]string{"data"}, map[string]schema{
+		"data": arrayOf(ref("DownloadJob")),
+	}),
+	"FollowState": object([]string{"data"}, map[string]schema{
+		"data": object([]string{"creator_id", "following"}, map[string]schema{
+			"creator_id": stringSchema,
+			"following":  booleanSchema,
+		}),
+	}),
+	"CreatorList": object([]string{"data", "meta"}, map[string]schema{
+		"data": arrayOf(ref("Creator")),
+		"meta": object(nil, map[string]schema{
+			"total":        integerSchema,
+			"count":        integerSchema,
+			"offset":       integerSchema,
+			"per_page":     integerSchema,
+			"current_page": integerSchema,
+			"total_pages":  integerSchema,
+			"next_cursor":  stringSchema,
+			"prev_cursor":  stringSchema,
+		}),
+		"links": object([]string{"self"}, map[string]schema{
+			"self": stringSchema,
+			"next": stringSchema,
+			"prev": stringSchema,
+		}),
+	}),
+	"APIKey": object([]string{"id", "name", "prefix", "scopes", "created_at"}, map[string]schema{
+		"id":           stringSchema,
+		"name":         stringSchema,
+		"prefix":       stringSchema,
+		"scopes":       arrayOf(schema{"type": "string", "enum": []string{ScopeRead, ScopeDownload, ScopeMessages, ScopeAdmin}}),
+		"created_at":   dateTimeSchema,
+		"expires_at":   dateTimeSchema,
+		"last_used_at": dateTimeSchema,
+		"revoked_at":   dateTimeSchema,
+	}),
+	"APIKeyList": object([]string{"data"}, map[string]schema{
+		"data": arrayOf(ref("APIKey")),
+	}),
+	"CreateAPIKeyRequest": object([]string{"name", "scopes"}, map[string]schema{
+		"name":       schema{"type": "string", "maxLength": 100},
+		"scopes":     schema{"type": "array", "minItems": 1, "items": schema{"type": "string", "enum": []string{ScopeRead, ScopeDownload, ScopeMessages, ScopeAdmin}}},
+		"expires_in": schema{"type": "integer", "minimum": 0, "description": "Lifetime in seconds, 0 for no expiry"},
+	}),
+	"CreateAPIKeyResponse": object([]string{"key", "api_key"}, map[string]schema{
+		"key":     schema{"type": "string", "description": "Plaintext key, only returned once"},
+		"api_key": ref("APIKey"),
+	}),
+	"LogLevel": object([]string{"level"}, map[string]schema{
+		"level": schema{"type": "string", "enum": []string{"debug", "info", "warn", "error"}},
+	}),
+}
+
+// apiOperations documents every route of the API
+var apiOperations = []apiOperation{
+	// Health and discovery
+	{Method: "GET", Path: "/health", Tag: "Health", Summary: "Liveness check (alias of /health/live)", Public: true,
+		Responses: map[int]string{200: "Status"}},
+	{Method: "GET", Path: "/health/live", Tag: "Health", Summary: "Liveness check", Public: true,
+		Responses: map[int]string{200: "Status"}},
+	{Method: "GET", Path: "/health/ready", Tag: "Health", Summary: "Readiness check with per-dependency results", Public: true,
+		Responses: map[int]string{200: "HealthReport", 503: "HealthReport"}},
+	{Method: "GET", Path: "/api/v1/health", Tag: "Health", Summary: "Liveness check", Public: true,
+		Responses: map[int]string{200: "Status"}},
+	{Method: "GET", Path: "/metrics", Tag: "Health", Summary: "Prometheus metrics (bearer METRICS_TOKEN when configured)", Public: true,
+		Responses: map[int]string{200: "", 401: "Problem"}},
+	{Method: "GET", Path: "/.well-known/jwks.json", Tag: "Authentication", Summary: "Public keys for verifying issued tokens", Public: true,
+		Responses: map[int]string{200: "JWKS"}},
+	{Method: "GET", Path: "/api/v1/openapi.json", Tag: "Documentation", Summary: "This OpenAPI document", Public: true,
+		Responses: map[int]string{200: ""}},
+	{Method: "GET", Path: "/api/v1/docs", Tag: "Documentation", Summary: "API documentation UI", Public: true,
+		Responses: map[int]string{200: ""}},
+
+	// Authentication
+	{Method: "POST", Path: "/api/v1/auth/initiate", Tag: "Authentication", Summary: "Start authentication", Public: true,
+		Responses: map[int]string{200: "AuthResponse", 429: "Problem"}},
+	{Method: "POST", Path: "/api/v1/auth/complete", Tag: "Authentication", Summary: "Complete authentication and start a session", Public: true,
+		RequestBody: "AuthRequest",
+		Responses:   map[int]string{200: "AuthResponse", 400: "Problem", 401: "Problem"}},
+	{Method: "GET", Path: "/api/v1/auth/login", Tag: "Authentication", Summary: "Redirect to the configured OAuth2/OIDC identity provider", Public: true,
+		Responses: map[int]string{302: "", 429: "Problem"}},
+	{Method: "GET", Path: "/api/v1/auth/callback", Tag: "Authentication", Summary: "Identity provider callback; provisions the user and returns a token", Public: true,
+		Params: []apiParam{
+			{Name: "code", In: "query", Schema: stringSchema},
+			{Name: "state", In: "query", Schema: stringSchema},
+		},
+		Responses: map[int]string{200: "AuthResponse", 400: "Problem", 401: "Problem"}},
+	{Method: "POST", Path: "/api/v1/auth/logout", Tag: "Authentication", Summary: "End the current session",
+		Responses: map[int]string{204: ""}},
+
+	// Sessions
+	{Method: "GET", Path: "/api/v1/sessions", Tag: "Sessions", Summary: "List the caller's active sessions",
+		Responses: map[int]string{200: "SessionList"}},
+	{Method: "GET", Path: "/api/v1/sessions/current", Tag: "Sessions", Summary: "Current session and its CSRF token",
+		Responses: map[int]string{200: "CurrentSession", 404: "Problem"}},
+	{Method: "DELETE", Path: "/api/v1/sessions/{id}", Tag: "Sessions", Summary: "End a session",
+		Params:    []apiParam{idParam("Session ID")},
+		Responses: map[int]string{204: "", 404: "Problem"}},
+
+	// Creators
+	{Method: "GET", Path: "/api/v1/creators", Tag: "Creators", Summary: "List creators", Permission: PermCreatorsRead,
+		Params: []apiParam{
+			{Name: "limit", In: "query", Description: "Number of creators to return (max 100)", Schema: schema{"type": "integer", "default": 20, "maximum": 100}},
+			{Name: "offset", In: "query", Description: "Number of creators to skip", Schema: schema{"type": "integer", "default": 0}},
+			{Name: "cursor", In: "query", Description: "Opaque cursor from a next or prev link; cannot be combined with offset", Schema: stringSchema},
+			{Name: "sort", In: "query", Schema: schema{"type": "string", "enum": []string{"name", "last_updated"}, "default": "name"}},
+			{Name: "order", In: "query", Schema: schema{"type": "string", "enum": []string{"asc", "desc"}, "default": "asc"}},
+			{Name: "verified", In: "query", Description: "Only verified (true) or unverified (false) creators", Schema: booleanSchema},
+			{Name: "following", In: "query", Description: "Only followed (true) or unfollowed (false) creators", Schema: booleanSchema},
+			{Name: "subscribed", In: "query", Description: "Only creators with (true) or without (false) an active subscription", Schema: booleanSchema},
+		},
+		Responses: map[int]string{200: "CreatorList", 400: "Problem", 503: "Problem"}},
+	{Method: "GET", Path: "/api/v1/creators/search", Tag: "Creators", Summary: "Search creators on Fansly and locally", Permission: PermCreatorsRead,
+		Params: []apiParam{
+			{Name: "q", In: "query", Description: "Username or display name; tolerates typos", Required: true, Schema: schema{"type": "string", "minLength": 2, "maxLength": 100}},
+			{Name: "limit", In: "query", Description: "Number of results to return (max 50)", Schema: schema{"type": "integer", "default": 20, "maximum": 50}},
+		},
+		Responses: map[int]string{200: "CreatorSearchResults", 400: "Problem", 503: "Problem"}},
+	{Method: "GET", Path: "/api/v1/creators/{id}", Tag: "Creators", Summary: "Get a creator's profile, stats and sync state", Permission: PermCreatorsRead,
+		Params: []apiParam{
+			idParam("Creator ID"),
+			{Name: "If-None-Match", In: "header", Description: "ETag of a cached response; 304 is returned if it is still current", Schema: stringSchema},
+		},
+		Responses: map[int]string{200: "CreatorDetail", 304: "", 404: "Problem", 503: "Problem"}},
+	{Method: "GET", Path: "/api/v1/creators/{id}/hashtags", Tag: "Creators", Summary: "A creator's most used hashtags", Permission: PermCreatorsRead,
+		Params: []apiParam{
+			idParam("Creator ID"),
+			{Name: "limit", In: "query", Schema: schema{"type": "integer", "default": 20, "maximum": 100}},
+		},
+		Responses: map[int]string{200: "HashtagCounts", 400: "Problem"}},
+	{Method: "GET", Path: "/api/v1/creators/{id}/stories", Tag: "Creators", Summary: "A creator's captured stories, including expired ones", Permission: PermCreatorsRead,
+		Params: []apiParam{
+			idParam("Creator ID"),
+			{Name: "active", In: "query", Description: "Only stories still visible (true) or expired (false)", Schema: booleanSchema},
+		},
+		Responses: map[int]string{200: "StoryList", 400: "Problem"}},
+	{Method: "POST", Path: "/api/v1/creators/{id}/follow", Tag: "Creators", Summary: "Follow a creator", Permission: PermCreatorsWrite,
+		Params:    []apiParam{idParam("Creator ID")},
+		Responses: map[int]string{200: "FollowState", 404: "Problem", 502: "Problem", 503: "Problem"}},
+	{Method: "DELETE", Path: "/api/v1/creators/{id}/follow", Tag: "Creators", Summary: "Unfollow a creator", Permission: PermCreatorsWrite,
+		Params:    []apiParam{idParam("Creator ID")},
+		Responses: map[int]string{200: "FollowState", 404: "Problem", 502: "Problem", 503: "Problem"}},
+
+	// Subscriptions
+	{Method: "GET", Path: "/api/v1/subscriptions", Tag: "Creators", Summary: "List paid subscriptions with renewal dates and prices", Permission: PermCreatorsRead,
+		Responses: map[int]string{200: "SubscriptionList"}},
+
+	// Search
+	{Method: "GET", Path: "/api/v1/search", Tag: "Search", Summary: "Search synced post text, hashtags and captions", Permission: PermCreatorsRead,
+		Params: []apiParam{
+			{Name: "q", In: "query", Description: `Terms and "quoted phrases"; all must match`, Required: true, Schema: schema{"type": "string", "maxLength": 200}},
+			{Name: "creator_id", In: "query", Description: "Comma-separated creator IDs", Schema: stringSchema},
+			{Name: "from", In: "query", Description: "Created at or after (RFC 3339 or YYYY-MM-DD)", Schema: stringSchema},
+			{Name: "to", In: "query", Description: "Created before (RFC 3339, or YYYY-MM-DD for the whole day)", Schema: stringSchema},
+			{Name: "limit", In: "query", Schema: schema{"type": "integer", "default": 20, "maximum": 100}},
+			{Name: "offset", In: "query", Schema: schema{"type": "integer", "default": 0}},
+		},
+		Responses: map[int]string{200: "SearchResults", 400: "Problem"}},
+	{Method: "GET", Path: "/api/v1/hashtags/{tag}/posts", Tag: "Search", Summary: "Synced posts with a hashtag, newest first", Permission: PermCreatorsRead,
+		Params: []apiParam{
+			{Name: "tag", In: "path", Description: "Hashtag, with or without #", Schema: stringSchema},
+			{Name: "limit", In: "query", Schema: schema{"type": "integer", "default": 20, "maximum": 100}},
+			{Name: "offset", In: "query", Schema: schema{"type": "integer", "default": 0}},
+		},
+		Responses: map[int]string{200: "PostList", 400: "Problem"}},
+	{Method: "GET", Path: "/api/v1/mentions", Tag: "Search", Summary: "Graph of creators mentioning other accounts", Permission: PermCreatorsRead,
+		Params: []apiParam{
+			{Name: "creator_id", In: "query", Description: "Only mentions made by this creator", Schema: stringSchema},
+			{Name: "min_count", In: "query", Description: "Only edges with at least this many posts", Schema: schema{"type": "integer", "default": 1, "minimum": 1}},
+		},
+		Responses: map[int]string{200: "MentionGraph", 400: "Problem"}},
+
+	// Messages
+	{Method: "GET", Path: "/api/v1/messages/groups", Tag: "Messages", Summary: "List mirrored message groups, most recently active first", Permission: PermMessagesRead,
+		Params: []apiParam{
+			{Name: "limit", In: "query", Schema: schema{"type": "integer", "default": 20, "maximum": 100}},
+			{Name: "offset", In: "query", Schema: schema{"type": "integer", "default": 0}},
+		},
+		Responses: map[int]string{200: "MessageGroupList", 400: "Problem"}},
+	{Method: "GET", Path: "/api/v1/messages/groups/{id}", Tag: "Messages", Summary: "Get a message group and its messages, newest first", Permission: PermMessagesRead,
+		Params: []apiParam{
+			idParam("Message group ID"),
+			{Name: "limit", In: "query", Schema: schema{"type": "integer", "default": 50, "maximum": 200}},
+			{Name: "before", In: "query", Description: "Only messages older than the message with this ID, as in the next link", Schema: stringSchema},
+		},
+		Responses: map[int]string{200: "MessageGroupDetail", 400: "Problem", 404: "Problem"}},
+
+	// Vault
+	{Method: "GET", Path: "/api/v1/vault", Tag: "Vault", Summary: "List purchased media from posts and messages, newest purchase first", Permission: PermCreatorsRead,
+		Params: []apiParam{
+			{Name: "creator_id", In: "query", Schema: stringSchema},
+			{Name: "type", In: "query", Schema: schema{"type": "string", "enum": []string{"image", "video"}}},
+			{Name: "source", In: "query", Schema: schema{"type": "string", "enum": []string{"post", "message"}}},
+			{Name: "limit", In: "query", Schema: schema{"type": "integer", "default": 50, "maximum": 200}},
+			{Name: "offset", In: "query", Schema: schema{"type": "integer", "default": 0}},
+		},
+		Responses: map[int]string{200: "VaultList", 400: "Problem", 502: "Problem", 503: "Problem"}},
+	{Method: "GET", Path: "/api/v1/vault/downloads", Tag: "Vault", Summary: "List download jobs, newest first", Permission: PermMediaDownload,
+		Responses: map[int]string{200: "DownloadJobList"}},
+	{Method: "POST", Path: "/api/v1/vault/downloads", Tag: "Vault", Summary: "Start downloading every purchased media item not downloaded yet", Permission: PermMediaDownload,
+		Responses: map[int]string{202: "DownloadJobDetail", 409: "Problem", 502: "Problem", 503: "Problem"}},
+	{Method: "GET", Path: "/api/v1/vault/downloads/{id}", Tag: "Vault", Summary: "Get a download job's progress", Permission: PermMediaDownload,
+		Params:    []apiParam{idParam("Download job ID")},
+		Responses: map[int]string{200: "DownloadJobDetail", 404: "Problem"}},
+	{Method: "DELETE", Path: "/api/v1/vault/downloads/{id}", Tag: "Vault", Summary: "Cancel a running download job", Permission: PermMediaDownload,
+		Params:    []apiParam{idParam("Download job ID")},
+		Responses: map[int]string{202: "DownloadJobDetail", 404: "Problem", 409: "Problem"}},
+
+	// Administration
+	{Method: "GET", Path: "/api/v1/admin/api-keys", Tag: "Administration", Summary: "List API keys", Permission: PermAdmin,
+		Responses: map[int]string{200: "APIKeyList"}},
+	{Method: "POST", Path: "/api/v1/admin/api-keys", Tag: "Administration", Summary: "Create an API key", Permission: PermAdmin,
+		RequestBody: "CreateAPIKeyRequest",
+		Responses:   map[int]string{201: "CreateAPIKeyResponse", 400: "Problem"}},
+	{Method: "DELETE", Path: "/api/v1/admin/api-keys/{id}", Tag: "Administration", Summary: "Revoke an API key", Permission: PermAdmin,
+		Params:    []apiParam{idParam("API key ID")},
+		Responses: map[int]string{204: "", 404: "Problem"}},
+	{Method: "GET", Path: "/api/v1/admin/log-level", Tag: "Administration", Summary: "Get the current log level", Permission: PermAdmin,
+		Responses: map[int]string{200: "LogLevel"}},
+	{Method: "PUT", Path: "/api/v1/admin/log-level", Tag: "Administration", Summary: "Change the log level until restart", Permission: PermAdmin,
+		RequestBody: "LogLevel",
+		Responses:   map[int]string{200: "LogLevel", 400: "Problem"}},
+}
+
+// buildOpenAPI returns the OpenAPI 3.1 document for the router, along with the
+// routes that have no entry in apiOperations and are left out of it
+func buildOpenAPI(router chi.Routes) (doc []byte, missing []string, err error) {
+	documented := make(map[string]apiOperation, len(apiOperations))
+	for _, op := range apiOperations {
+		documented[op.Method+" "+op.Path] = op
+	}
+
+	registered := make(map[string]bool)
+	err = chi.Walk(router, func(method, route string, _ http.Handler, _ ...func(http.Handler) http.Handler) error {
+		route = normalizeRoute(route)
+		key := method + " " + route
+		if _, ok := documented[key]; !ok {
+			missing = append(missing, key)
+		}
+		registered[key] = true
+		return nil
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("error walking routes: %w", err)
+	}
+	sort.Strings(missing)
+
+	paths := make(map[string]schema)
+	for _, op := range apiOperations {
+		// Optional routes, such as OAuth login when no provider is configured, are left out
+		if !registered[op.Method+" "+op.Path] {
+			continue
+		}
+		if paths[op.Path] == nil {
+			paths[op.Path] = schema{}
+		}
+		paths[op.Path][strings.ToLower(op.Method)] = op.document()
+	}
+
+	schemas := make(map[string]schema, len(apiSchemas))
+	for name, s := range apiSchemas {
+		schemas[name] = s
+	}
+
+	doc, err = json.Marshal(schema{
+		"openapi": "3.1.0",
+		"info": schema{
+			"title":   "Fansly API",
+			"version": "1.0.0",
+		},
+		"paths": paths,
+		"components": schema{
+			"schemas": schemas,
+			"securitySchemes": schema{
+				"bearerAuth": schema{"type": "http", "scheme": "bearer", "bearerFormat": "JWT"},
+				"apiKey":     schema{"type": "apiKey", "in": "header", "name": "X-API-Key"},
+				"session": schema{"type": "apiKey", "in": "cookie", "name": sessionCookieName,
+					"description": "Unsafe methods also require the X-CSRF-Token header"},
+			},
+		},
+	})
+	return doc, missing, err
+}
+
+// document returns the OpenAPI operation object
+func (op apiOperation) document() schema {
+	doc := schema{
+		"summary":     op.Summary,
+		"tags":        []string{op.Tag},
+		"operationId": operationID(op.Method, op.Path),
+	}
+
+	if op.Public {
+		doc["security"] = []schema{}
+	} else {
+		doc["security"] = []schema{{"bearerAuth": []string{}}, {"apiKey": []string{}}, {"session": []string{}}}
+		if op.Permission != "" {
+			doc["description"] = "Requires the `" + op.Permission + "` permission."
+		}
+	}
+
+	if len(op.Params) > 0 {
+		params := make([]schema, 0, len(op.Params))
+		for _, p := range op.Params {
+			param := schema{"name": p.Name, "in": p.In, "schema": p.Schema}
+			if p.In == "path" || p.Required {
+				param["required"] = true
+			}
+			if p.Description != "" {
+				param["description"] = p.Description
+			}
+			params = append(params, param)
+		}
+		doc["parameters"] = params
+	}
+
+	if op.RequestBody != "" {
+		doc["requestBody"] = schema{
+			"required": true,
+			"content":  schema{"application/json": schema{"schema": ref(op.RequestBody)}},
+		}
+	}
+
+	responses := schema{}
+	for code, name := range op.Responses {
+		responses[strconv.Itoa(code)] = response(code, name)
+	}
+	if !op.Public {
+		responses["401"] = response(http.StatusUnauthorized, "Problem")
+		if op.Permission != "" {
+			responses["403"] = response(http.StatusForbidden, "Problem")
+		}
+	}
+	doc["responses"] = responses
+	return doc
+}
+
+func response(code int, schemaName string) schema {
+	r := schema{"description": http.StatusText(code)}
+	if schemaName != "" {
+		contentType := "application/json"
+		if schemaName == "Problem" {
+			contentType = problemContentType
+		}
+		r["content"] = schema{contentType: schema{"schema": ref(schemaName)}}
+	}
+	return r
+}
+
+// operationID derives an identifier such as "get_api_v1_sessions_id"
+func operationID(method, path string) string {
+	replacer := strings.NewReplacer("/", "_", "{", "", "}", "", "-", "_", ".", "_")
+	return strings.ToLower(method) + strings.TrimRight(replacer.Replace(path), "_")
+}
+
+// normalizeRoute strips the trailing slash chi adds to sub-router index routes
+func normalizeRoute(route string) string {
+	if len(route) > 1 {
+		return strings.TrimSuffix(route, "/")
+	}
+	return route
+}
+
+// handleOpenAPI handles GET /api/v1/openapi.json
+func (s *Server) handleOpenAPI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(s.openAPI)
+}
+
+// handleDocs handles GET /api/v1/docs
+func (s *Server) handleDocs(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write(docsHTML)
+}
@@ -0,0 +1,50 @@
+package api
+
+import (
+	"encoding/json"
+	"testing"
+
+	"fansly-api/internal/config"
+)
+
+func TestOpenAPICoversRoutes(t *testing.T) {
+	oidc, _ := newOIDCTestServer(t) // registers the optional OAuth routes
+	servers := map[string]*Server{
+		"default": newTestServer(t, &config.Config{}),
+		"oidc":    oidc,
+	}
+	for name, s := range servers {
+		t.Run(name, func(t *testing.T) {
+			doc, missing, err := buildOpenAPI(s.router)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, route := range missing {
+				t.Errorf("route %s has no entry in apiOperations", route)
+			}
+
+			var parsed struct {
+				Paths map[string]map[string]json.RawMessage `json:"paths"`
+			}
+			if err := json.Unmarshal(doc, &parsed); err != nil {
+				t.Fatal(err)
+			}
+			if parsed.Paths["/api/v1/creators/{id}"]["get"] == nil {
+				t.Errorf("document lacks GET /api/v1/creators/{id}")
+			}
+		})
+	}
+}
+
+func TestOpenAPIReportsUndocumentedRoutes(t *testing.T) {
+	s := newTestServer(t, &config.Config{})
+	s.router.Get("/api/v1/undocumented", s.handleDocs)
+
+	_, missing, err := buildOpenAPI(s.router)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(missing) != 1 || missing[0] != "GET /api/v1/undocumented" {
+		t.Fatalf("missing = %v, want [GET /api/v1/undocumented]", missing)
+	}
+}
@@ -0,0 +1,72 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"path/filepath"
+	"time"
+
+	"fansly-api/internal/config"
+	"fansly-api/internal/storage"
+)
+
+// sweepInterval is how often expired pending-auth attempts and sessions are removed
+const sweepInterval = time.Minute
+
+// newPendingAuthStore creates the pending-auth backend selected by the configuration.
+// name distinguishes the file of each store when persisted.
+func newPendingAuthStore(cfg *config.Config, dataDir, name string) (storage.PendingAuthStore, error) {
+	switch cfg.AuthPendingStore {
+	case "", "memory":
+		return storage.NewMemoryPendingAuthStore(), nil
+	case "file":
+		return storage.NewFilePendingAuthStore(filepath.Join(dataDir, name+".json"))
+	default:
+		return nil, fmt.Errorf("unsupported pending auth store: %s", cfg.AuthPendingStore)
+	}
+}
+
+// pendingAuthTTL returns how long an initiated authentication attempt stays valid
+func (s *Server) pendingAuthTTL() time.Duration {
+	if s.config.AuthPendingTTL > 0 {
+		return s.config.AuthPendingTTL
+	}
+	return 10 * time.Minute
+}
+
+// runSweeper removes expired pending-auth attempts and sessions until ctx is cancelled
+func (s *Server) runSweeper(ctx context.Context) {
+	ticker := time.NewTicker(sweepInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			for _, store := range []storage.PendingAuthStore{s.pendingAuth, s.oauthStates} {
+				if n, err := store.DeleteExpired(ctx, now); err != nil {
+					s.log.Warnf("Failed to sweep pending auth tokens: %v", err)
+				} else if n > 0 {
+					s.log.Debugf("Removed %d expired pending auth tokens", n)
+				}
+			}
+			if n, err := s.sessions.DeleteExpired(ctx, now, s.config.SessionIdleTimeout); err != nil {
+				s.log.Warnf("Failed to sweep sessions: %v", err)
+			} else if n > 0 {
+				s.log.Debugf("Removed %d expired sessions", n)
+			}
+		}
+	}
+}
+
+// clientIP returns the client address without its port
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
@@ -0,0 +1,55 @@
+package api
+
+import (
+	"context"
+	"net/http"
+
+	"fansly-api/internal/logger"
+)
+
+// Principal types
+const (
+	principalUser   = "user"
+	principalAPIKey = "api_key"
+)
+
+// Principal is the authenticated caller of a request
+type Principal struct {
+	ID          string   `json:"id"`                    // user ID or API key ID
+	Type        string   `json:"type"`                  // "user" or "api_key"
+	Roles       []string `json:"roles,omitempty"`       // user roles from JWT claims
+	Scopes      []string `json:"scopes,omitempty"`      // API key scopes
+	Permissions []string `json:"permissions,omitempty"` // resolved from roles or scopes
+	SessionID   string   `json:"-"`                     // set when authenticated by session cookie
+}
+
+// Can reports whether the principal holds the given permission. Admin holds every permission.
+func (p *Principal) Can(perm string) bool {
+	for _, granted := range p.Permissions {
+		if granted == perm || granted == PermAdmin {
+			return true
+		}
+	}
+	return false
+}
+
+type principalKey struct{}
+
+// withPrincipal returns a copy of the request carrying the authenticated principal.
+// The request logger and access log entry are tagged with the principal's ID.
+func withPrincipal(r *http.Request, p *Principal) *http.Request {
+	ctx := context.WithValue(r.Context(), principalKey{}, p)
+	if info, ok := ctx.Value(requestInfoKey{}).(*requestInfo); ok {
+		info.principal = p
+	}
+	if l, ok := logger.FromContext(ctx); ok {
+		ctx = logger.WithContext(ctx, l.With("user_id", p.ID))
+	}
+	return r.WithContext(ctx)
+}
+
+// PrincipalFromContext returns the authenticated principal, if any
+func PrincipalFromContext(ctx context.Context) (*Principal, bool) {
+	p, ok := ctx.Value(principalKey{}).(*Principal)
+	return p, ok
+}
@@ -0,0 +1,170 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5/middleware"
+
+	"fansly-api/internal/validate"
+)
+
+// Machine-readable error codes returned in the "code" member of problem responses
+const (
+	codeBadRequest       = "bad_request"
+	codeInvalidBody      = "invalid_body"
+	codeValidationFailed = "validation_failed"
+	codeUnauthorized     = "unauthorized"
+	codeForbidden        = "insufficient_permissions"
+	codeNotFound         = "not_found"
+	codeConflict         = "conflict"
+	codeRateLimited      = "rate_limited"
+	codeNotImplemented   = "not_implemented"
+	codeUnavailable      = "service_unavailable"
+	codeUpstream         = "upstream_error"
+	codeInternal         = "internal_error"
+)
+
+// problemContentType is the media type of error responses (RFC 9457)
+const problemContentType = "application/problem+json"
+
+// problem is an RFC 9457 problem details object. Code, RequestID, Errors and
+// Permission are extension members.
+type problem struct {
+	Type       string                `json:"type"`
+	Title      string                `json:"title"`
+	Status     int                   `json:"status"`
+	Detail     string                `json:"detail,omitempty"`
+	Instance   string                `json:"instance,omitempty"`
+	Code       string                `json:"code"`
+	RequestID  string                `json:"request_id,omitempty"`
+	Errors     []validate.FieldError `json:"errors,omitempty"`
+	Permission string                `json:"permission,omitempty"`
+}
+
+// respondWithJSON writes payload as a JSON response
+func respondWithJSON(w http.ResponseWriter, code int, payload interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(payload)
+}
+
+// respondWithError writes a problem response with the default code for the status
+func respondWithError(w http.ResponseWriter, status int, detail string) {
+	respondWithProblem(w, problem{Status: status, Detail: detail})
+}
+
+// respondWithProblem fills in the standard members of p and writes it. The request
+// ID is read from the response header set by the accessLog middleware.
+func respondWithProblem(w http.ResponseWriter, p problem) {
+	if p.Type == "" {
+		p.Type = "about:blank"
+	}
+	if p.Title == "" {
+		p.Title = http.StatusText(p.Status)
+	}
+	if p.Code == "" {
+		p.Code = defaultProblemCode(p.Status)
+	}
+	if p.RequestID == "" {
+		p.RequestID = w.Header().Get(middleware.RequestIDHeader)
+	}
+
+	w.Header().Set("Content-Type", problemContentType)
+	w.WriteHeader(p.Status)
+	json.NewEncoder(w).Encode(p)
+}
+
+// respondValidationError writes a 400 listing every invalid field
+func respondValidationError(w http.ResponseWriter, r *http.Request, errs validate.Errors) {
+	respondWithProblem(w, problem{
+		Status:   http.StatusBadRequest,
+		Detail:   "One or more parameters are invalid",
+		Instance: r.URL.Path,
+		Code:     codeValidationFailed,
+		Errors:   errs,
+	})
+}
+
+func defaultProblemCode(status int) string {
+	switch status {
+	case http.StatusBadRequest:
+		return codeBadRequest
+	case http.StatusUnauthorized:
+		return codeUnauthorized
+	case http.StatusForbidden:
+		return codeForbidden
+	case http.StatusNotFound:
+		return codeNotFound
+	case http.StatusConflict:
+		return codeConflict
+	case http.StatusTooManyRequests:
+		return codeRateLimited
+	case http.StatusNotImplemented:
+		return codeNotImplemented
+	case http.StatusServiceUnavailable:
+		return codeUnavailable
+	case http.StatusBadGateway:
+		return codeUpstream
+	}
+	if status >= 500 {
+		return codeInternal
+	}
+	return strings.ReplaceAll(strings.ToLower(http.StatusText(status)), " ", "_")
+}
+
+// bindQuery binds and validates the query parameters into dst, writing a 400 and
+// returning false when they are invalid
+func bindQuery(w http.ResponseWriter, r *http.Request, dst interface{}) bool {
+	if err := validate.Query(r.URL.Query(), dst); err != nil {
+		var errs validate.Errors
+		if errors.As(err, &errs) {
+			respondValidationError(w, r, errs)
+			return false
+		}
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return false
+	}
+	return true
+}
+
+// maxBodySize limits JSON request bodies
+const maxBodySize = 1 << 20
+
+// bindJSON decodes and validates the JSON body into dst, writing a 400 and
+// returning false when it is malformed or invalid
+func bindJSON(w http.ResponseWriter, r *http.Request, dst interface{}) bool {
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBodySize))
+	if err := dec.Decode(dst); err != nil {
+		detail := "Request body must be a JSON object"
+		var syntaxErr *json.SyntaxError
+		var typeErr *json.UnmarshalTypeError
+		var maxErr *http.MaxBytesError
+		switch {
+		case errors.Is(err, io.EOF):
+			detail = "Request body is empty"
+		case errors.As(err, &syntaxErr):
+			detail = "Request body is not valid JSON"
+		case errors.As(err, &typeErr):
+			detail = "Field " + typeErr.Field + " has the wrong type"
+		case errors.As(err, &maxErr):
+			detail = "Request body is too large"
+		}
+		respondWithProblem(w, problem{Status: http.StatusBadRequest, Detail: detail, Instance: r.URL.Path, Code: codeInvalidBody})
+		return false
+	}
+
+	if err := validate.Struct(dst); err != nil {
+		var errs validate.Errors
+		if errors.As(err, &errs) {
+			respondValidationError(w, r, errs)
+			return false
+		}
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return false
+	}
+	return true
+}
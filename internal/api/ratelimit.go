@@ -0,0 +1,107 @@
+package api
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"fansly-api/internal/config"
+	"fansly-api/internal/metrics"
+	"fansly-api/internal/ratelimit"
+)
+
+// newRouteLimits parses the per-route rate limit overrides from the configuration
+func newRouteLimits(cfg *config.Config) (map[string]ratelimit.Limit, error) {
+	limits := make(map[string]ratelimit.Limit)
+	for route, value := range cfg.GetRateLimitRoutes() {
+		limit, err := ratelimit.ParseLimit(value)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", route, err)
+		}
+		limits[route] = limit
+	}
+	return limits, nil
+}
+
+// limitRoute declares a rate limit for a single route instead of the default.
+// Overrides from RATE_LIMIT_ROUTES take precedence.
+func (s *Server) limitRoute(method, pattern string, limit ratelimit.Limit) {
+	key := method + " " + pattern
+	if _, ok := s.routeLimits[key]; !ok {
+		s.routeLimits[key] = limit
+	}
+}
+
+// rateLimit is a middleware that limits requests per caller: the API key or user
+// when authenticated, otherwise the client IP. Routes with an override get their
+// own budget; all other routes share the default one.
+func (s *Server) rateLimit(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		route := r.Method + " " + s.resolveRoute(r)
+		limit, ok := s.routeLimits[route]
+		bucket := route
+		if !ok {
+			limit, bucket = s.defaultLimit, "default"
+		}
+		if limit.Requests <= 0 {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		key := rateLimitIdentity(r) + "|" + bucket
+		res, err := s.limiter.Allow(r.Context(), key, limit, time.Now())
+		if err != nil {
+			// Fail open: a broken limiter backend shouldn't take the API down
+			s.logFor(r.Context()).Errorf("Rate limiter error: %v", err)
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		h := w.Header()
+		h.Set("RateLimit-Limit", strconv.Itoa(res.Limit))
+		h.Set("RateLimit-Remaining", strconv.Itoa(res.Remaining))
+		h.Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(res.ResetAfter)))
+		h.Set("RateLimit-Policy", fmt.Sprintf("%d;w=%d", limit.Requests, ceilSeconds(limit.Window)))
+
+		if !res.Allowed {
+			h.Set("Retry-After", strconv.Itoa(ceilSeconds(res.RetryAfter)))
+			metrics.RateLimitRejected.Inc(route)
+			metrics.RateLimitWait.Observe(res.RetryAfter.Seconds(), route)
+			s.logFor(r.Context()).Warnf("Rate limit exceeded for %s on %s", key, route)
+			respondWithError(w, http.StatusTooManyRequests, "Rate limit exceeded")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// resolveRoute returns the pattern of the route that will serve the request. The
+// limiter runs as group middleware, before sub-routers declared with r.Route have
+// matched, when RoutePattern still ends in "/*" for every route below them.
+func (s *Server) resolveRoute(r *http.Request) string {
+	path := r.URL.RawPath
+	if path == "" {
+		path = r.URL.Path
+	}
+	if route := s.router.Find(chi.NewRouteContext(), r.Method, path); route != "" {
+		return normalizeRoute(route)
+	}
+	return chi.RouteContext(r.Context()).RoutePattern()
+}
+
+// rateLimitIdentity returns the key that requests are counted against
+func rateLimitIdentity(r *http.Request) string {
+	if p, ok := PrincipalFromContext(r.Context()); ok {
+		return p.Type + ":" + p.ID
+	}
+	return "ip:" + clientIP(r)
+}
+
+// ceilSeconds rounds a duration up to whole seconds
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,55 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"fansly-api/internal/config"
+)
+
+func TestRateLimitNestedRouteOverride(t *testing.T) {
+	s := newTestServer(t, &config.Config{
+		RateLimit:       100,
+		RateLimitWindow: time.Minute,
+		RateLimitRoutes: "GET /api/v1/creators/{id}=1/1m",
+	})
+	token, err := s.generateJWT("user", []string{RoleViewer})
+	if err != nil {
+		t.Fatal(err)
+	}
+	get := func(path string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest("GET", path, nil)
+		r.Header.Set("Authorization", "Bearer "+token)
+		return serve(s, r)
+	}
+
+	// /creators/{id} is declared inside r.Route("/creators", ...)
+	if rec := get("/api/v1/creators/1"); rec.Header().Get("RateLimit-Limit") != "1" {
+		t.Fatalf("override not applied: RateLimit-Limit %q", rec.Header().Get("RateLimit-Limit"))
+	}
+	if rec := get("/api/v1/creators/2"); rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("second request: status %d, want 429", rec.Code)
+	}
+
+	// Sibling routes keep the default budget
+	if rec := get("/api/v1/creators"); rec.Header().Get("RateLimit-Limit") != "100" || rec.Header().Get("RateLimit-Remaining") != "99" {
+		t.Fatalf("default route: RateLimit-Limit %q, RateLimit-Remaining %q", rec.Header().Get("RateLimit-Limit"), rec.Header().Get("RateLimit-Remaining"))
+	}
+}
+
+func TestRateLimitBuiltInOverrideOnSubRouterIndex(t *testing.T) {
+	s := newTestServer(t, &config.Config{RateLimit: 100, RateLimitWindow: time.Minute})
+	token, err := s.generateJWT("admin", []string{RoleAdmin})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// POST /api/v1/admin/api-keys is the index route of a sub-router
+	r := httptest.NewRequest("POST", "/api/v1/admin/api-keys", nil)
+	r.Header.Set("Authorization", "Bearer "+token)
+	if rec := serve(s, r); rec.Header().Get("RateLimit-Limit") != "10" {
+		t.Fatalf("RateLimit-Limit %q, want 10", rec.Header().Get("RateLimit-Limit"))
+	}
+}
@@ -0,0 +1,95 @@
+package api
+
+import (
+	"net/http"
+)
+
+// Permissions checked by route middleware
+const (
+	PermCreatorsRead  = "creators:read"
+	PermCreatorsWrite = "creators:write"
+	PermMediaDownload = "media:download"
+	PermMessagesRead  = "messages:read"
+	PermMonitorsRead  = "monitors:read"
+	PermMonitorsWrite = "monitors:write"
+	PermAdmin         = "admin" // implies every other permission
+)
+
+// Roles that can be granted to users in JWT claims
+const (
+	RoleViewer     = "viewer"
+	RoleDownloader = "downloader"
+	RoleOperator   = "operator"
+	RoleAdmin      = "admin"
+)
+
+// rolePermissions maps each role to the permissions it grants
+var rolePermissions = map[string][]string{
+	RoleViewer:     {PermCreatorsRead, PermMonitorsRead},
+	RoleDownloader: {PermCreatorsRead, PermMonitorsRead, PermMediaDownload},
+	RoleOperator:   {PermCreatorsRead, PermCreatorsWrite, PermMonitorsRead, PermMediaDownload, PermMonitorsWrite, PermMessagesRead},
+	RoleAdmin:      {PermAdmin},
+}
+
+// scopePermissions maps each API key scope to the permissions it grants
+var scopePermissions = map[string][]string{
+	ScopeRead:     {PermCreatorsRead, PermMonitorsRead},
+	ScopeDownload: {PermMediaDownload},
+	ScopeMessages: {PermMessagesRead},
+	ScopeAdmin:    {PermAdmin},
+}
+
+// isValidRole reports whether role is a known role
+func isValidRole(role string) bool {
+	_, ok := rolePermissions[role]
+	return ok
+}
+
+// resolvePermissions expands roles and API key scopes into a deduplicated permission list
+func resolvePermissions(grants map[string][]string, names []string, extra []string) []string {
+	seen := make(map[string]bool)
+	var perms []string
+	add := func(perm string) {
+		if !seen[perm] {
+			seen[perm] = true
+			perms = append(perms, perm)
+		}
+	}
+	for _, name := range names {
+		for _, perm := range grants[name] {
+			add(perm)
+		}
+	}
+	for _, perm := range extra {
+		add(perm)
+	}
+	return perms
+}
+
+// requirePermission is a middleware that rejects principals lacking the given permission
+func (s *Server) requirePermission(perm string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			p, ok := PrincipalFromContext(r.Context())
+			if !ok {
+				respondWithError(w, http.StatusUnauthorized, "Authentication required")
+				return
+			}
+			if !p.Can(perm) {
+				s.logFor(r.Context()).Warnf("Permission %s denied for %s %s", perm, p.Type, p.ID)
+				respondForbidden(w, perm)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// respondForbidden sends the 403 response used for every permission failure
+func respondForbidden(w http.ResponseWriter, perm string) {
+	respondWithProblem(w, problem{
+		Status:     http.StatusForbidden,
+		Detail:     "Insufficient permissions",
+		Permission: perm,
+	})
+}
@@ -0,0 +1,82 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"fansly-api/internal/config"
+)
+
+func TestRequirePermission(t *testing.T) {
+	s := newTestServer(t, &config.Config{})
+	allPerms := []string{PermCreatorsRead, PermCreatorsWrite, PermMediaDownload, PermMessagesRead, PermMonitorsRead, PermMonitorsWrite, PermAdmin}
+
+	tests := []struct {
+		name    string
+		grants  map[string][]string
+		granted []string // roles or scopes
+		allowed []string
+	}{
+		{"viewer role", rolePermissions, []string{RoleViewer}, []string{PermCreatorsRead, PermMonitorsRead}},
+		{"downloader role", rolePermissions, []string{RoleDownloader}, []string{PermCreatorsRead, PermMonitorsRead, PermMediaDownload}},
+		{"operator role", rolePermissions, []string{RoleOperator}, []string{PermCreatorsRead, PermCreatorsWrite, PermMediaDownload, PermMessagesRead, PermMonitorsRead, PermMonitorsWrite}},
+		{"admin role", rolePermissions, []string{RoleAdmin}, allPerms},
+		{"read scope", scopePermissions, []string{ScopeRead}, []string{PermCreatorsRead, PermMonitorsRead}},
+		{"download scope", scopePermissions, []string{ScopeDownload}, []string{PermMediaDownload}},
+		{"messages scope", scopePermissions, []string{ScopeMessages}, []string{PermMessagesRead}},
+		{"read and messages scopes", scopePermissions, []string{ScopeRead, ScopeMessages}, []string{PermCreatorsRead, PermMonitorsRead, PermMessagesRead}},
+		{"admin scope", scopePermissions, []string{ScopeAdmin}, allPerms},
+		{"no grants", scopePermissions, nil, nil},
+	}
+	for _, tt := range tests {
+		p := &Principal{ID: "p1", Type: principalAPIKey, Permissions: resolvePermissions(tt.grants, tt.granted, nil)}
+		for _, perm := range allPerms {
+			handler := s.requirePermission(perm)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusNoContent)
+			}))
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, withPrincipal(httptest.NewRequest("GET", "/", nil), p))
+
+			allowed := false
+			for _, a := range tt.allowed {
+				allowed = allowed || a == perm
+			}
+			if allowed {
+				if rec.Code != http.StatusNoContent {
+					t.Errorf("%s: %s denied with status %d", tt.name, perm, rec.Code)
+				}
+				continue
+			}
+			var body problem
+			if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+				t.Fatalf("%s: %s: %v", tt.name, perm, err)
+			}
+			if rec.Code != http.StatusForbidden || rec.Header().Get("Content-Type") != problemContentType ||
+				body.Status != http.StatusForbidden || body.Code != codeForbidden || body.Permission != perm {
+				t.Errorf("%s: %s: status %d, body %+v", tt.name, perm, rec.Code, body)
+			}
+		}
+	}
+
+	// Without a principal the request isn't authenticated
+	rec := httptest.NewRecorder()
+	s.requirePermission(PermCreatorsRead)(http.NotFoundHandler()).ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("no principal: status %d, want 401", rec.Code)
+	}
+}
+
+func TestMessagesScopeReachesMessageGroups(t *testing.T) {
+	s := newTestServer(t, &config.Config{})
+	for scope, want := range map[string]int{ScopeRead: http.StatusForbidden, ScopeMessages: http.StatusOK} {
+		key, _, err := s.createAPIKey(t.Context(), "test", []string{scope}, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if rec := withAPIKey(s, "GET", "/api/v1/messages/groups", key); rec.Code != want {
+			t.Errorf("%s scope: status %d, want %d", scope, rec.Code, want)
+		}
+	}
+}
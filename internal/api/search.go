@@ -0,0 +1,165 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"fansly-api/internal/events"
+	"fansly-api/internal/search"
+	"fansly-api/internal/storage"
+	"fansly-api/internal/tags"
+	"fansly-api/internal/validate"
+)
+
+// searchParams are the query parameters of GET /api/v1/search
+type searchParams struct {
+	Query      string   `query:"q" validate:"required,max=200"`
+	CreatorIDs []string `query:"creator_id" validate:"max=50"`
+	From       string   `query:"from"`
+	To         string   `query:"to"`
+	Limit      int      `query:"limit" default:"20" validate:"min=1,max=100"`
+	Offset     int      `query:"offset" default:"0" validate:"min=0"`
+}
+
+// buildPostIndexes indexes every stored post for search and hashtag browsing, and
+// keeps the indexes up to date as the sync engine publishes post events
+func (s *Server) buildPostIndexes(ctx context.Context) error {
+	posts, err := s.posts.List(ctx)
+	if err != nil {
+		return err
+	}
+	for _, post := range posts {
+		s.indexPost(post)
+	}
+	s.events.Subscribe(s.indexPostEvent)
+	return nil
+}
+
+// indexPostEvent applies a post.synced or post.deleted event to the indexes
+func (s *Server) indexPostEvent(e events.Event) {
+	id, _ := e.Data["post_id"].(string)
+	switch e.Type {
+	case events.PostSynced:
+		post, err := s.posts.Get(context.Background(), id)
+		if err != nil {
+			s.log.Warnf("Failed to index post %s: %v", id, err)
+			return
+		}
+		s.indexPost(post)
+	case events.PostDeleted:
+		s.searchIndex.Delete(id)
+		s.tagIndex.Delete(id)
+	}
+}
+
+// indexPost adds a post to the indexes. Hashtags and mentions written in the text
+// are extracted and merged with those Fansly reports for the post.
+func (s *Server) indexPost(post *storage.Post) {
+	hashtags, mentions := tags.Extract(post.Text)
+	hashtags = tags.Normalize(append(append([]string(nil), post.Hashtags...), hashtags...))
+	mentions = tags.Normalize(append(append([]string(nil), post.Mentions...), mentions...))
+
+	doc := search.Document{
+		ID:        post.ID,
+		CreatorID: post.CreatorID,
+		Text:      post.Text,
+		Hashtags:  hashtags,
+		CreatedAt: post.CreatedAt,
+	}
+	for _, media := range post.Media {
+		if media.Caption != "" {
+			doc.Captions = append(doc.Captions, media.Caption)
+		}
+	}
+	s.searchIndex.Upsert(doc)
+	s.tagIndex.Upsert(post.ID, post.CreatorID, post.CreatedAt, hashtags, mentions)
+}
+
+// handleSearch handles GET /api/v1/search
+// Query parameters:
+//   - q: terms and "quoted phrases" that must all match post text, hashtags or captions
+//   - creator_id: comma-separated creator IDs to restrict the search to
+//   - from, to: creation date range, as RFC 3339 timestamps or YYYY-MM-DD dates (to is exclusive)
+//   - limit: number of results to return (default: 20, max: 100)
+//   - offset: number of results to skip (default: 0)
+func (s *Server) handleSearch(w http.ResponseWriter, r *http.Request) {
+	var params searchParams
+	if !bindQuery(w, r, &params) {
+		return
+	}
+
+	var errs validate.Errors
+	from, err := parseDateParam(params.From, false)
+	if err != nil {
+		errs = append(errs, validate.FieldError{Field: "from", Code: validate.CodeInvalid, Message: err.Error()})
+	}
+	to, err := parseDateParam(params.To, true)
+	if err != nil {
+		errs = append(errs, validate.FieldError{Field: "to", Code: validate.CodeInvalid, Message: err.Error()})
+	}
+	if len(errs) > 0 {
+		respondValidationError(w, r, errs)
+		return
+	}
+
+	results, err := s.searchIndex.Search(search.Query{
+		Text:       params.Query,
+		CreatorIDs: params.CreatorIDs,
+		From:       from,
+		To:         to,
+		Limit:      params.Limit,
+		Offset:     params.Offset,
+	})
+	if errors.Is(err, search.ErrEmptyQuery) {
+		respondValidationError(w, r, validate.Errors{{Field: "q", Code: validate.CodeInvalid, Message: "must contain a word"}})
+		return
+	} else if err != nil {
+		s.logFor(r.Context()).Errorf("Search failed: %v", err)
+		respondWithError(w, http.StatusInternalServerError, "Search failed")
+		return
+	}
+
+	hits := make([]map[string]interface{}, len(results.Hits))
+	for i, hit := range results.Hits {
+		hits[i] = map[string]interface{}{
+			"post_id":    hit.ID,
+			"creator_id": hit.CreatorID,
+			"created_at": hit.CreatedAt,
+			"hashtags":   hit.Hashtags,
+			"score":      hit.Score,
+			"snippet":    hit.Snippet,
+		}
+	}
+	respondWithJSON(w, http.StatusOK, map[string]interface{}{
+		"data": hits,
+		"meta": map[string]interface{}{
+			"query":    params.Query,
+			"total":    results.Total,
+			"count":    len(hits),
+			"offset":   params.Offset,
+			"per_page": params.Limit,
+		},
+	})
+}
+
+// parseDateParam parses an RFC 3339 timestamp or a YYYY-MM-DD date. An end date
+// covers the whole day, so it is moved to the start of the next day.
+func parseDateParam(value string, end bool) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse(time.DateOnly, strings.TrimSpace(value))
+	if err != nil {
+		return time.Time{}, errors.New("must be an RFC 3339 timestamp or a YYYY-MM-DD date")
+	}
+	if end {
+		t = t.AddDate(0, 0, 1)
+	}
+	return t, nil
+}
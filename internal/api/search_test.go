@@ -0,0 +1,62 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"fansly-api/internal/config"
+	"fansly-api/internal/events"
+	"fansly-api/internal/storage"
+)
+
+func TestSearchFollowsPostEvents(t *testing.T) {
+	s := newTestServer(t, &config.Config{})
+	token, err := s.generateJWT("user", []string{RoleViewer})
+	if err != nil {
+		t.Fatal(err)
+	}
+	search := func() []string {
+		t.Helper()
+		r := httptest.NewRequest("GET", "/api/v1/search?q=beach", nil)
+		r.Header.Set("Authorization", "Bearer "+token)
+		rec := serve(s, r)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("status %d: %s", rec.Code, rec.Body)
+		}
+		var resp struct {
+			Data []struct {
+				PostID string `json:"post_id"`
+			} `json:"data"`
+		}
+		if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+			t.Fatal(err)
+		}
+		ids := make([]string, len(resp.Data))
+		for i, hit := range resp.Data {
+			ids[i] = hit.PostID
+		}
+		return ids
+	}
+
+	ctx := context.Background()
+	post := &storage.Post{ID: "p1", CreatorID: "c1", Text: "A day at the beach", CreatedAt: time.Now()}
+	if err := s.posts.Put(ctx, post); err != nil {
+		t.Fatal(err)
+	}
+	s.events.Publish(events.PostSynced, map[string]interface{}{"post_id": post.ID})
+	if got := search(); len(got) != 1 || got[0] != post.ID {
+		t.Fatalf("after post.synced: %v", got)
+	}
+
+	if err := s.posts.Delete(ctx, post.ID); err != nil {
+		t.Fatal(err)
+	}
+	s.events.Publish(events.PostDeleted, map[string]interface{}{"post_id": post.ID})
+	if got := search(); len(got) != 0 {
+		t.Fatalf("after post.deleted: %v", got)
+	}
+}
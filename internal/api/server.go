@@ -2,34 +2,219 @@ package api
 
 import (
 	"context"
-	"encoding/json"
+	"fmt"
 	"net/http"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/go-chi/cors"
 
+	"fansly-api/internal/config"
+	"fansly-api/internal/events"
+	"fansly-api/internal/health"
 	"fansly-api/internal/logger"
+	"fansly-api/internal/messaging"
+	"fansly-api/internal/metrics"
+	"fansly-api/internal/ratelimit"
+	"fansly-api/internal/search"
+	"fansly-api/internal/service"
+	"fansly-api/internal/storage"
+	"fansly-api/internal/stories"
+	"fansly-api/internal/tags"
+	"fansly-api/internal/timeline"
+	"fansly-api/internal/vault"
 )
 
 // Server represents the HTTP server
 type Server struct {
-	server  *http.Server
-	router *chi.Mux
-	log    logger.Logger
+	server        *http.Server
+	router        *chi.Mux
+	log           logger.Logger
+	config        *config.Config
+	keys          *keySet
+	apiKeys       storage.APIKeyStore
+	sessions      storage.SessionStore
+	pendingAuth   storage.PendingAuthStore
+	oauthStates   storage.PendingAuthStore
+	users         storage.UserStore
+	creatorSync   storage.CreatorSyncStore
+	oauth         identityProvider // nil when OAuth2 login is disabled
+	fansly        *FanslyClient    // nil when FANSLY_AUTH_TOKEN is not set
+	scraperSvc    *service.ScraperService
+	events        *events.Bus
+	posts         storage.PostStore
+	searchIndex   *search.Index
+	tagIndex      *tags.Index
+	messages      storage.MessageStore
+	stories       storage.StoryStore
+	subscriptions storage.SubscriptionStore
+	// subscriptionSyncFailed is set while the last refresh of the subscriptions failed
+	subscriptionSyncFailed atomic.Bool
+	downloadJobs           storage.DownloadJobStore
+	downloader             *vault.Downloader // nil when FANSLY_AUTH_TOKEN is not set
+	health                 *health.Checker
+	openAPI                []byte // OpenAPI document served at /api/v1/openapi.json
+
+	limiter      ratelimit.Store
+	defaultLimit ratelimit.Limit
+	routeLimits  map[string]ratelimit.Limit // keyed by "METHOD /pattern"
+
+	sessionSecret        []byte
+	stopSweeper          context.CancelFunc
+	stopMessageSync      context.CancelFunc // nil when the message sync isn't running
+	stopStories          context.CancelFunc // nil when the story monitor isn't running
+	stopPostSync         context.CancelFunc // nil when the post sync isn't running
+	stopSubscriptionSync context.CancelFunc // nil when subscriptions are only refreshed at startup
 }
 
 // NewServer creates a new HTTP server
-func NewServer(log logger.Logger) *Server {
+func NewServer(cfg *config.Config, log logger.Logger) (*Server, error) {
+	if cfg.DefaultRole != "" && !isValidRole(cfg.DefaultRole) {
+		return nil, fmt.Errorf("unknown default role: %s", cfg.DefaultRole)
+	}
+
+	keys, err := newKeySet(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load JWT keys: %w", err)
+	}
+
+	dataDir, err := cfg.GetDataDir()
+	if err != nil {
+		return nil, err
+	}
+	apiKeys, err := storage.NewFileAPIKeyStore(filepath.Join(dataDir, "api_keys.json"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load API keys: %w", err)
+	}
+
+	sessions, err := newSessionStore(cfg, dataDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load sessions: %w", err)
+	}
+	sessionSecret, err := newSessionSecret(cfg)
+	if err != nil {
+		return nil, err
+	}
+	pendingAuth, err := newPendingAuthStore(cfg, dataDir, "pending_auth")
+	if err != nil {
+		return nil, fmt.Errorf("failed to load pending auth tokens: %w", err)
+	}
+	oauthStates, err := newPendingAuthStore(cfg, dataDir, "oauth_states")
+	if err != nil {
+		return nil, fmt.Errorf("failed to load OAuth states: %w", err)
+	}
+	users, err := storage.NewFileUserStore(filepath.Join(dataDir, "users.json"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load users: %w", err)
+	}
+	creatorSync, err := storage.NewFileCreatorSyncStore(filepath.Join(dataDir, "creator_sync.json"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load creator sync state: %w", err)
+	}
+	follows, err := storage.NewFileFollowStore(filepath.Join(dataDir, "follows.json"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load follow state: %w", err)
+	}
+	subscriptions, err := storage.NewFileSubscriptionStore(filepath.Join(dataDir, "subscriptions.json"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load subscriptions: %w", err)
+	}
+	posts, err := storage.NewFilePostStore(filepath.Join(dataDir, "posts.json"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load posts: %w", err)
+	}
+	messages, err := storage.NewFileMessageStore(filepath.Join(dataDir, "messages.json"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load messages: %w", err)
+	}
+	storyStore, err := storage.NewFileStoryStore(filepath.Join(dataDir, "stories.json"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load stories: %w", err)
+	}
+	downloadJobs, err := storage.NewFileDownloadJobStore(filepath.Join(dataDir, "download_jobs.json"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load download jobs: %w", err)
+	}
+
+	routeLimits, err := newRouteLimits(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("invalid RATE_LIMIT_ROUTES: %w", err)
+	}
+
+	discoveryCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	oauth, err := newIdentityProvider(discoveryCtx, cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to set up OAuth provider: %w", err)
+	}
+
 	s := &Server{
-		router: chi.NewRouter(),
-		log:    log,
+		router:        chi.NewRouter(),
+		log:           log,
+		config:        cfg,
+		keys:          keys,
+		apiKeys:       apiKeys,
+		sessions:      sessions,
+		pendingAuth:   pendingAuth,
+		oauthStates:   oauthStates,
+		users:         users,
+		creatorSync:   creatorSync,
+		oauth:         oauth,
+		limiter:       ratelimit.NewMemoryStore(),
+		defaultLimit:  ratelimit.Limit{Requests: cfg.RateLimit, Window: cfg.RateLimitWindow},
+		routeLimits:   routeLimits,
+		sessionSecret: sessionSecret,
+		health:        health.NewChecker(5 * time.Second),
+		scraperSvc:    service.NewScraperService(log, follows, subscriptions),
+		events:        events.NewBus(),
+		posts:         posts,
+		searchIndex:   search.NewIndex(),
+		tagIndex:      tags.NewIndex(),
+		messages:      messages,
+		stories:       storyStore,
+		subscriptions: subscriptions,
+		downloadJobs:  downloadJobs,
+	}
+	s.events.Subscribe(func(e events.Event) {
+		log.With("event", e.Type).Infof("Event %s: %v", e.Type, e.Data)
+	})
+	if cfg.FanslyAuthToken != "" {
+		s.fansly = NewFanslyClient(cfg.FanslyAuthToken, log)
+		s.downloader = vault.NewDownloader(s.fansly, s.fansly, s.downloadJobs, s.creatorSync, filepath.Join(dataDir, "vault"), log)
+		// Start without Fansly access rather than fail; creator endpoints report 503 until it works
+		if err := s.scraperSvc.Authenticate(context.Background(), cfg.FanslyAuthToken); err != nil {
+			log.Warnf("Fansly authentication failed, creator endpoints are unavailable: %v", err)
+		}
+		// Subscription status on creators comes from the stored list; refresh it now
+		// and keep the stored list if Fansly can't be reached
+		refreshCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		s.syncSubscriptions(refreshCtx, nil)
+		cancel()
+	}
+	s.registerHealthChecks(dataDir)
+	if err := s.buildPostIndexes(context.Background()); err != nil {
+		return nil, fmt.Errorf("failed to build post indexes: %w", err)
+	}
+
+	if err := s.bootstrapAPIKey(context.Background(), dataDir); err != nil {
+		return nil, fmt.Errorf("failed to create bootstrap API key: %w", err)
 	}
 
 	// Initialize the router and middleware
 	s.setupMiddleware()
 	s.setupRoutes()
+	openAPI, undocumented, err := buildOpenAPI(s.router)
+	if err != nil {
+		return nil, err
+	}
+	if len(undocumented) > 0 {
+		log.Warnf("Routes missing from the OpenAPI document: %s", strings.Join(undocumented, ", "))
+	}
+	s.openAPI = openAPI
 
 	s.server = &http.Server{
 		Handler:      s.router,
@@ -38,42 +223,159 @@ func NewServer(log logger.Logger) *Server {
 		IdleTimeout:  60 * time.Second,
 	}
 
-	return s
+	// Remove expired pending auth tokens and sessions in the background
+	sweepCtx, stopSweeper := context.WithCancel(context.Background())
+	s.stopSweeper = stopSweeper
+	go s.runSweeper(sweepCtx)
+
+	// Mirror direct messages from Fansly in the background
+	if s.fansly != nil && cfg.MessageSyncInterval > 0 {
+		syncer := messaging.NewSyncer(s.fansly, s.messages, log, cfg.MessageSyncInterval)
+		s.health.Register("message_sync", syncer.Heartbeat().Check)
+		syncCtx, stopMessageSync := context.WithCancel(context.Background())
+		s.stopMessageSync = stopMessageSync
+		go syncer.Run(syncCtx)
+	}
+
+	// Capture followed creators' stories before they expire
+	if s.fansly != nil && cfg.StoryPollInterval > 0 {
+		monitor := stories.NewMonitor(s.fansly, s.fansly, s.stories, s.followedCreatorIDs, filepath.Join(dataDir, "stories"), log, cfg.StoryPollInterval)
+		s.health.Register("story_monitor", monitor.Heartbeat().Check)
+		storiesCtx, stopStories := context.WithCancel(context.Background())
+		s.stopStories = stopStories
+		go monitor.Run(storiesCtx)
+	}
+
+	// Sync followed creators' posts, which feed the search and hashtag indexes
+	if s.fansly != nil && cfg.PostSyncInterval > 0 {
+		syncer := timeline.NewSyncer(s.fansly, s.posts, s.creatorSync, s.followedCreatorIDs, s.events, log, cfg.PostSyncInterval)
+		s.health.Register("post_sync", syncer.Heartbeat().Check)
+		postSyncCtx, stopPostSync := context.WithCancel(context.Background())
+		s.stopPostSync = stopPostSync
+		go syncer.Run(postSyncCtx)
+	}
+
+	// Keep the stored subscriptions current; GET /api/v1/subscriptions only reads them
+	if s.fansly != nil && cfg.SubscriptionSyncInterval > 0 {
+		// Allow a refresh to fail twice before reporting the sync unhealthy
+		heartbeat := health.NewHeartbeat(3 * cfg.SubscriptionSyncInterval)
+		s.health.Register("subscription_sync", heartbeat.Check)
+		subscriptionSyncCtx, stopSubscriptionSync := context.WithCancel(context.Background())
+		s.stopSubscriptionSync = stopSubscriptionSync
+		go s.runSubscriptionSync(subscriptionSyncCtx, cfg.SubscriptionSyncInterval, heartbeat)
+	}
+
+	return s, nil
 }
 
 func (s *Server) setupMiddleware() {
 	// Basic middleware
 	s.router.Use(middleware.RequestID)
 	s.router.Use(middleware.RealIP)
-	s.router.Use(middleware.Logger)
+	s.router.Use(s.traceRequests)
+	s.router.Use(s.accessLog)
 	s.router.Use(middleware.Recoverer)
 	s.router.Use(middleware.Timeout(60 * time.Second))
 
-	// CORS configuration - simple for development
-	s.router.Use(cors.Handler(cors.Options{
-		AllowedOrigins:   []string{"*"},
-		AllowedMethods:   []string{"GET", "POST"},
-		AllowedHeaders:   []string{"Accept", "Content-Type", "Authorization"},
-		AllowCredentials: true,
-		MaxAge:           300,
-	}))
+	// CORS configuration from config
+	s.router.Use(cors.Handler(newCORSOptions(s.config)))
 }
 
 func (s *Server) setupRoutes() {
-	// Health check endpoint
+	// Stricter limits for endpoints that create server-side state
+	s.limitRoute("POST", "/api/v1/auth/initiate", ratelimit.Limit{Requests: 10, Window: time.Minute})
+	s.limitRoute("GET", "/api/v1/auth/login", ratelimit.Limit{Requests: 10, Window: time.Minute})
+	s.limitRoute("POST", "/api/v1/admin/api-keys", ratelimit.Limit{Requests: 10, Window: time.Minute})
+
+	// Health check endpoints
 	s.router.Get("/health", s.handleHealthCheck)
+	s.router.Get("/health/live", s.handleLiveness)
+	s.router.Get("/health/ready", s.handleReadiness)
+
+	// Prometheus metrics
+	s.router.With(s.requireMetricsToken).Get("/metrics", metrics.Default.Handler().ServeHTTP)
+
+	// Public keys for verifying our tokens
+	s.router.Get("/.well-known/jwks.json", s.handleJWKS)
 
 	// API v1 routes
 	s.router.Route("/api/v1", func(r chi.Router) {
 		// Public routes
+		r.Get("/health", s.handleHealthCheck)
+		r.Get("/openapi.json", s.handleOpenAPI)
+		r.Get("/docs", s.handleDocs)
 		r.Group(func(r chi.Router) {
-			r.Get("/health", s.handleHealthCheck)
+			r.Use(s.rateLimit)
+			r.Post("/auth/initiate", s.handleAuthInitiate)
+			r.Post("/auth/complete", s.handleAuthComplete)
+
+			// OAuth2/OIDC login through the configured identity provider
+			if s.oauth != nil {
+				r.Get("/auth/login", s.handleOAuthLogin)
+				r.Get("/auth/callback", s.handleOAuthCallback)
+			}
 		})
 
 		// Protected routes
 		r.Group(func(r chi.Router) {
 			r.Use(s.requireAuth)
-			r.Get("/creators", s.handleListCreators)
+			r.Use(s.rateLimit)
+			r.Post("/auth/logout", s.handleLogout)
+
+			// Session management
+			r.Get("/sessions", s.handleListSessions)
+			r.Get("/sessions/current", s.handleCurrentSession)
+			r.Delete("/sessions/{id}", s.handleDeleteSession)
+
+			r.Route("/creators", func(r chi.Router) {
+				r.Use(s.requirePermission(PermCreatorsRead))
+				r.Get("/", s.handleListCreators)
+				r.Get("/search", s.handleSearchCreators)
+				r.Get("/{id}", s.handleGetCreator)
+				r.Get("/{id}/hashtags", s.handleCreatorHashtags)
+				r.Get("/{id}/stories", s.handleCreatorStories)
+				r.With(s.requirePermission(PermCreatorsWrite)).Post("/{id}/follow", s.handleFollowCreator)
+				r.With(s.requirePermission(PermCreatorsWrite)).Delete("/{id}/follow", s.handleUnfollowCreator)
+			})
+
+			r.With(s.requirePermission(PermCreatorsRead)).Get("/subscriptions", s.handleListSubscriptions)
+			r.With(s.requirePermission(PermCreatorsRead)).Get("/search", s.handleSearch)
+			r.With(s.requirePermission(PermCreatorsRead)).Get("/hashtags/{tag}/posts", s.handleHashtagPosts)
+			r.With(s.requirePermission(PermCreatorsRead)).Get("/mentions", s.handleMentionGraph)
+
+			// Direct messages mirrored from Fansly
+			r.Route("/messages/groups", func(r chi.Router) {
+				r.Use(s.requirePermission(PermMessagesRead))
+				r.Get("/", s.handleListMessageGroups)
+				r.Get("/{id}", s.handleGetMessageGroup)
+			})
+
+			// Purchased media and download-all jobs
+			r.Route("/vault", func(r chi.Router) {
+				r.With(s.requirePermission(PermCreatorsRead)).Get("/", s.handleListVault)
+				r.Route("/downloads", func(r chi.Router) {
+					r.Use(s.requirePermission(PermMediaDownload))
+					r.Get("/", s.handleListVaultDownloads)
+					r.Post("/", s.handleStartVaultDownload)
+					r.Get("/{id}", s.handleGetVaultDownload)
+					r.Delete("/{id}", s.handleCancelVaultDownload)
+				})
+			})
+
+			// API key management
+			r.Route("/admin/api-keys", func(r chi.Router) {
+				r.Use(s.requirePermission(PermAdmin))
+				r.Get("/", s.handleListAPIKeys)
+				r.Post("/", s.handleCreateAPIKey)
+				r.Delete("/{id}", s.handleRevokeAPIKey)
+			})
+
+			// Runtime log level
+			r.Route("/admin/log-level", func(r chi.Router) {
+				r.Use(s.requirePermission(PermAdmin))
+				r.Get("/", s.handleGetLogLevel)
+				r.Put("/", s.handleSetLogLevel)
+			})
 		})
 	})
 }
@@ -87,24 +389,26 @@ func (s *Server) Start(addr string) error {
 
 // Shutdown gracefully shuts down the server
 func (s *Server) Shutdown(ctx context.Context) error {
+	s.stopSweeper()
+	if s.stopMessageSync != nil {
+		s.stopMessageSync()
+	}
+	if s.stopStories != nil {
+		s.stopStories()
+	}
+	if s.stopPostSync != nil {
+		s.stopPostSync()
+	}
+	if s.stopSubscriptionSync != nil {
+		s.stopSubscriptionSync()
+	}
+	if s.downloader != nil {
+		s.downloader.Stop()
+	}
 	return s.server.Shutdown(ctx)
 }
 
 // Health check handler
 func (s *Server) handleHealthCheck(w http.ResponseWriter, r *http.Request) {
-	s.respondWithJSON(w, http.StatusOK, map[string]string{"status": "ok"})
-}
-
-// Helper function to send JSON responses
-func (s *Server) respondWithJSON(w http.ResponseWriter, code int, payload interface{}) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(code)
-	if err := json.NewEncoder(w).Encode(payload); err != nil {
-		s.log.Errorf("Error encoding JSON response: %v", err)
-	}
-}
-
-// Helper function to send error responses
-func (s *Server) respondWithError(w http.ResponseWriter, code int, message string) {
-	s.respondWithJSON(w, code, map[string]string{"error": message})
+	respondWithJSON(w, http.StatusOK, map[string]string{"status": "ok"})
 }
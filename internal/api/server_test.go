@@ -0,0 +1,34 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"fansly-api/internal/config"
+	"fansly-api/internal/logger"
+)
+
+// newTestServer creates a server keeping its data in a temporary directory
+func newTestServer(t *testing.T, cfg *config.Config) *Server {
+	t.Helper()
+	t.Setenv("HOME", t.TempDir())
+	if cfg.JWTSecret == "" {
+		cfg.JWTSecret = "test-secret"
+	}
+	if cfg.Environment == "" {
+		cfg.Environment = "development"
+	}
+	s, err := NewServer(cfg, logger.New())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+// serve sends a request through the server's router
+func serve(s *Server, r *http.Request) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	s.router.ServeHTTP(rec, r)
+	return rec
+}
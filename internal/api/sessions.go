@@ -0,0 +1,284 @@
+package api
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"fansly-api/internal/config"
+	"fansly-api/internal/storage"
+)
+
+const (
+	sessionCookieName = "session_id"
+	csrfHeaderName    = "X-CSRF-Token"
+	// sessionTouchInterval limits how often session activity is written back to storage
+	sessionTouchInterval = time.Minute
+)
+
+// sessionResponse is the public view of a session
+type sessionResponse struct {
+	ID         string    `json:"id"`
+	UserAgent  string    `json:"user_agent,omitempty"`
+	IPAddress  string    `json:"ip_address,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	Current    bool      `json:"current"`
+}
+
+// newSessionStore creates the session backend selected by the configuration
+func newSessionStore(cfg *config.Config, dataDir string) (storage.SessionStore, error) {
+	switch cfg.SessionStore {
+	case "", "memory":
+		return storage.NewMemorySessionStore(), nil
+	case "file":
+		return storage.NewFileSessionStore(filepath.Join(dataDir, "sessions.json"))
+	default:
+		return nil, fmt.Errorf("unsupported session store: %s", cfg.SessionStore)
+	}
+}
+
+// newSessionSecret returns the configured cookie signing secret, or a random one in development
+func newSessionSecret(cfg *config.Config) ([]byte, error) {
+	if cfg.SessionSecret != "" {
+		return []byte(cfg.SessionSecret), nil
+	}
+	// Development only: config.Validate rejects an empty secret in production
+	random, err := generateRandomString(64)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate development session secret: %w", err)
+	}
+	return []byte(random), nil
+}
+
+// createSession starts a session for the user and sets the session cookie
+func (s *Server) createSession(w http.ResponseWriter, r *http.Request, userID string, roles []string) (*storage.Session, error) {
+	now := time.Now()
+	if _, err := s.sessions.DeleteExpired(r.Context(), now, s.config.SessionIdleTimeout); err != nil {
+		s.logFor(r.Context()).Warnf("Failed to sweep expired sessions: %v", err)
+	}
+
+	id, err := generateRandomString(64)
+	if err != nil {
+		return nil, err
+	}
+	csrfToken, err := generateRandomString(32)
+	if err != nil {
+		return nil, err
+	}
+
+	session := &storage.Session{
+		ID:         id,
+		UserID:     userID,
+		Roles:      roles,
+		CSRFToken:  csrfToken,
+		UserAgent:  r.UserAgent(),
+		IPAddress:  r.RemoteAddr,
+		CreatedAt:  now,
+		LastSeenAt: now,
+		ExpiresAt:  now.Add(s.config.GetSessionMaxAge()),
+	}
+	if err := s.sessions.Create(r.Context(), session); err != nil {
+		return nil, err
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     sessionCookieName,
+		Value:    s.signSessionID(id),
+		Path:     "/",
+		Expires:  session.ExpiresAt,
+		MaxAge:   int(s.config.GetSessionMaxAge().Seconds()),
+		HttpOnly: true,
+		Secure:   s.config.Environment == "production",
+		SameSite: http.SameSiteLaxMode,
+	})
+	return session, nil
+}
+
+// resolveSession validates the session cookie and returns the live session
+func (s *Server) resolveSession(ctx context.Context, cookieValue string) (*storage.Session, error) {
+	id, ok := s.verifySessionCookie(cookieValue)
+	if !ok {
+		return nil, errors.New("invalid session signature")
+	}
+
+	session, err := s.sessions.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if session.Expired(now, s.config.SessionIdleTimeout) {
+		if err := s.sessions.Delete(ctx, id); err != nil && !errors.Is(err, storage.ErrNotFound) {
+			s.logFor(ctx).Warnf("Failed to delete expired session: %v", err)
+		}
+		return nil, errors.New("session expired")
+	}
+
+	if now.Sub(session.LastSeenAt) > sessionTouchInterval {
+		if err := s.sessions.Touch(ctx, id, now); err != nil {
+			s.logFor(ctx).Warnf("Failed to record session activity: %v", err)
+		}
+	}
+	return session, nil
+}
+
+// clearSessionCookie removes the session cookie from the client
+func (s *Server) clearSessionCookie(w http.ResponseWriter) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     sessionCookieName,
+		Value:    "",
+		Path:     "/",
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   s.config.Environment == "production",
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+// signSessionID returns the cookie value "<id>.<signature>"
+func (s *Server) signSessionID(id string) string {
+	return id + "." + s.sessionSignature(id)
+}
+
+// verifySessionCookie checks the cookie signature and returns the session ID
+func (s *Server) verifySessionCookie(value string) (string, bool) {
+	id, signature, ok := strings.Cut(value, ".")
+	if !ok || id == "" {
+		return "", false
+	}
+	if !hmac.Equal([]byte(signature), []byte(s.sessionSignature(id))) {
+		return "", false
+	}
+	return id, true
+}
+
+func (s *Server) sessionSignature(id string) string {
+	mac := hmac.New(sha256.New, s.sessionSecret)
+	mac.Write([]byte(id))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// validCSRFToken checks the CSRF header of a state-changing request against the session
+func validCSRFToken(r *http.Request, session *storage.Session) bool {
+	switch r.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	}
+	token := r.Header.Get(csrfHeaderName)
+	return token != "" && subtle.ConstantTimeCompare([]byte(token), []byte(session.CSRFToken)) == 1
+}
+
+// handleListSessions handles GET /api/v1/sessions
+func (s *Server) handleListSessions(w http.ResponseWriter, r *http.Request) {
+	p, ok := PrincipalFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "Authentication required")
+		return
+	}
+	sessions, err := s.sessions.ListByUser(r.Context(), p.ID)
+	if err != nil {
+		s.logFor(r.Context()).Errorf("Failed to list sessions: %v", err)
+		respondWithError(w, http.StatusInternalServerError, "Failed to list sessions")
+		return
+	}
+
+	now := time.Now()
+	data := make([]sessionResponse, 0, len(sessions))
+	for _, session := range sessions {
+		if session.Expired(now, s.config.SessionIdleTimeout) {
+			continue
+		}
+		data = append(data, sessionResponse{
+			ID:         session.ID,
+			UserAgent:  session.UserAgent,
+			IPAddress:  session.IPAddress,
+			CreatedAt:  session.CreatedAt,
+			LastSeenAt: session.LastSeenAt,
+			ExpiresAt:  session.ExpiresAt,
+			Current:    session.ID == p.SessionID,
+		})
+	}
+	respondWithJSON(w, http.StatusOK, map[string]interface{}{"data": data})
+}
+
+// handleCurrentSession handles GET /api/v1/sessions/current and returns the CSRF token
+func (s *Server) handleCurrentSession(w http.ResponseWriter, r *http.Request) {
+	p, ok := PrincipalFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "Authentication required")
+		return
+	}
+	if p.SessionID == "" {
+		respondWithError(w, http.StatusNotFound, "Not authenticated with a session")
+		return
+	}
+
+	session, err := s.sessions.Get(r.Context(), p.SessionID)
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Session not found")
+		return
+	}
+	respondWithJSON(w, http.StatusOK, map[string]interface{}{
+		"id":         session.ID,
+		"user_id":    session.UserID,
+		"csrf_token": session.CSRFToken,
+		"expires_at": session.ExpiresAt,
+	})
+}
+
+// handleDeleteSession handles DELETE /api/v1/sessions/{id}
+// Users can end their own sessions; admins can end any session.
+func (s *Server) handleDeleteSession(w http.ResponseWriter, r *http.Request) {
+	p, ok := PrincipalFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "Authentication required")
+		return
+	}
+	id := chi.URLParam(r, "id")
+
+	session, err := s.sessions.Get(r.Context(), id)
+	if err != nil || (session.UserID != p.ID && !p.Can(PermAdmin)) {
+		respondWithError(w, http.StatusNotFound, "Session not found")
+		return
+	}
+
+	if err := s.sessions.Delete(r.Context(), id); err != nil && !errors.Is(err, storage.ErrNotFound) {
+		s.logFor(r.Context()).Errorf("Failed to delete session: %v", err)
+		respondWithError(w, http.StatusInternalServerError, "Failed to end session")
+		return
+	}
+	if id == p.SessionID {
+		s.clearSessionCookie(w)
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleLogout handles POST /api/v1/auth/logout and ends the current session
+func (s *Server) handleLogout(w http.ResponseWriter, r *http.Request) {
+	p, ok := PrincipalFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "Authentication required")
+		return
+	}
+	if p.SessionID != "" {
+		if err := s.sessions.Delete(r.Context(), p.SessionID); err != nil && !errors.Is(err, storage.ErrNotFound) {
+			s.logFor(r.Context()).Errorf("Failed to delete session: %v", err)
+			respondWithError(w, http.StatusInternalServerError, "Failed to log out")
+			return
+		}
+	}
+	s.clearSessionCookie(w)
+	w.WriteHeader(http.StatusNoContent)
+}
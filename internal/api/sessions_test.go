@@ -0,0 +1,126 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"fansly-api/internal/config"
+	"fansly-api/internal/storage"
+)
+
+// addSession stores a session for the user that was last seen at lastSeen and
+// expires at expires
+func addSession(t *testing.T, s *Server, id, userID string, roles []string, lastSeen, expires time.Time) *storage.Session {
+	t.Helper()
+	session := &storage.Session{
+		ID:         id,
+		UserID:     userID,
+		Roles:      roles,
+		CSRFToken:  "csrf-" + id,
+		CreatedAt:  lastSeen,
+		LastSeenAt: lastSeen,
+		ExpiresAt:  expires,
+	}
+	if err := s.sessions.Create(context.Background(), session); err != nil {
+		t.Fatal(err)
+	}
+	return session
+}
+
+// withSession sends a request with the session's cookie and the given CSRF token
+func withSession(s *Server, method, path, cookie, csrfToken string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(method, path, nil)
+	r.AddCookie(&http.Cookie{Name: sessionCookieName, Value: cookie})
+	if csrfToken != "" {
+		r.Header.Set(csrfHeaderName, csrfToken)
+	}
+	return serve(s, r)
+}
+
+// clearsCookie reports whether the response removes the session cookie
+func clearsCookie(rec *httptest.ResponseRecorder) bool {
+	for _, c := range rec.Result().Cookies() {
+		if c.Name == sessionCookieName && c.MaxAge < 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func TestSessionCookieAuth(t *testing.T) {
+	s := newTestServer(t, &config.Config{SessionIdleTimeout: time.Hour})
+	now := time.Now()
+	addSession(t, s, "live", "user", []string{RoleViewer}, now, now.Add(time.Hour))
+	addSession(t, s, "expired", "user", []string{RoleViewer}, now.Add(-time.Minute), now.Add(-time.Second))
+	addSession(t, s, "idle", "user", []string{RoleViewer}, now.Add(-2*time.Hour), now.Add(time.Hour))
+
+	if rec := withSession(s, "GET", "/api/v1/sessions", s.signSessionID("live"), ""); rec.Code != http.StatusOK {
+		t.Fatalf("live session: status %d", rec.Code)
+	}
+
+	// A cookie whose signature doesn't match
+	tampered := s.signSessionID("live")
+	tampered = tampered[:len(tampered)-2] + "xx"
+	if rec := withSession(s, "GET", "/api/v1/sessions", tampered, ""); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("tampered cookie: status %d, want 401", rec.Code)
+	}
+	if rec := withSession(s, "GET", "/api/v1/sessions", "live", ""); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("unsigned cookie: status %d, want 401", rec.Code)
+	}
+
+	for _, id := range []string{"expired", "idle"} {
+		rec := withSession(s, "GET", "/api/v1/sessions", s.signSessionID(id), "")
+		if rec.Code != http.StatusUnauthorized || !clearsCookie(rec) {
+			t.Fatalf("%s session: status %d, cookie cleared %v; want 401 and cleared", id, rec.Code, clearsCookie(rec))
+		}
+		if _, err := s.sessions.Get(context.Background(), id); err == nil {
+			t.Fatalf("%s session not deleted", id)
+		}
+	}
+}
+
+func TestSessionCSRF(t *testing.T) {
+	s := newTestServer(t, &config.Config{})
+	now := time.Now()
+	session := addSession(t, s, "live", "user", []string{RoleViewer}, now, now.Add(time.Hour))
+	cookie := s.signSessionID(session.ID)
+
+	if rec := withSession(s, "GET", "/api/v1/sessions/current", cookie, ""); rec.Code != http.StatusOK {
+		t.Fatalf("GET without CSRF token: status %d, want 200", rec.Code)
+	}
+	if rec := withSession(s, "POST", "/api/v1/auth/logout", cookie, ""); rec.Code != http.StatusForbidden {
+		t.Fatalf("POST without CSRF token: status %d, want 403", rec.Code)
+	}
+	if rec := withSession(s, "POST", "/api/v1/auth/logout", cookie, "csrf-other"); rec.Code != http.StatusForbidden {
+		t.Fatalf("POST with the wrong CSRF token: status %d, want 403", rec.Code)
+	}
+	if rec := withSession(s, "POST", "/api/v1/auth/logout", cookie, session.CSRFToken); rec.Code != http.StatusNoContent {
+		t.Fatalf("POST with the CSRF token: status %d, want 204", rec.Code)
+	}
+}
+
+func TestDeleteSession(t *testing.T) {
+	s := newTestServer(t, &config.Config{})
+	now := time.Now()
+	alice := addSession(t, s, "alice", "alice", []string{RoleViewer}, now, now.Add(time.Hour))
+	bob := addSession(t, s, "bob", "bob", []string{RoleViewer}, now, now.Add(time.Hour))
+	admin := addSession(t, s, "admin", "admin", []string{RoleAdmin}, now, now.Add(time.Hour))
+
+	// Other users' sessions look like they don't exist
+	if rec := withSession(s, "DELETE", "/api/v1/sessions/bob", s.signSessionID(alice.ID), alice.CSRFToken); rec.Code != http.StatusNotFound {
+		t.Fatalf("non-admin ending another user's session: status %d, want 404", rec.Code)
+	}
+	if _, err := s.sessions.Get(context.Background(), bob.ID); err != nil {
+		t.Fatal("session ended by another user")
+	}
+
+	if rec := withSession(s, "DELETE", "/api/v1/sessions/bob", s.signSessionID(admin.ID), admin.CSRFToken); rec.Code != http.StatusNoContent {
+		t.Fatalf("admin ending another user's session: status %d, want 204", rec.Code)
+	}
+	if _, err := s.sessions.Get(context.Background(), bob.ID); err == nil {
+		t.Fatal("session not ended by the admin")
+	}
+}
@@ -0,0 +1,65 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"fansly-api/internal/storage"
+)
+
+// creatorStoriesParams are the query parameters of GET /api/v1/creators/{id}/stories
+type creatorStoriesParams struct {
+	Active string `query:"active" validate:"oneof=true false"`
+}
+
+// storyResponse is a captured story and whether it is still visible on Fansly
+type storyResponse struct {
+	*storage.Story
+	Active bool `json:"active"`
+}
+
+// handleCreatorStories handles GET /api/v1/creators/{id}/stories, listing a creator's
+// captured stories, newest first, including those that have expired on Fansly
+func (s *Server) handleCreatorStories(w http.ResponseWriter, r *http.Request) {
+	var params creatorStoriesParams
+	if !bindQuery(w, r, &params) {
+		return
+	}
+
+	id := chi.URLParam(r, "id")
+	captured, err := s.stories.ListByCreator(r.Context(), id)
+	if err != nil {
+		s.logFor(r.Context()).Errorf("Failed to list stories of creator %s: %v", id, err)
+		respondWithError(w, http.StatusInternalServerError, "Failed to fetch stories")
+		return
+	}
+
+	now := time.Now()
+	active := parseBoolFilter(params.Active)
+	data := make([]storyResponse, 0, len(captured))
+	for _, story := range captured {
+		if active != nil && story.Active(now) != *active {
+			continue
+		}
+		data = append(data, storyResponse{Story: story, Active: story.Active(now)})
+	}
+	respondWithJSON(w, http.StatusOK, map[string]interface{}{"data": data})
+}
+
+// followedCreatorIDs returns the IDs of the creators the Fansly account follows
+func (s *Server) followedCreatorIDs(ctx context.Context) ([]string, error) {
+	creators, err := s.scraperSvc.AllCreators(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var ids []string
+	for _, c := range creators {
+		if c.IsFollowing {
+			ids = append(ids, c.ID)
+		}
+	}
+	return ids, nil
+}
@@ -0,0 +1,141 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"time"
+
+	"fansly-api/internal/health"
+	"fansly-api/internal/storage"
+)
+
+// subscriptionResponse is a subscription with whether it is active and how many
+// synced posts its tier unlocks
+type subscriptionResponse struct {
+	*storage.Subscription
+	Active        bool `json:"active"`
+	UnlockedPosts int  `json:"unlocked_posts"` // synced posts restricted to tiers including this one
+}
+
+// handleListSubscriptions handles GET /api/v1/subscriptions, listing the account's
+// paid subscriptions, soonest renewal first. The stored list is returned without
+// calling Fansly; meta.fansly_sync is "failed" when its last refresh failed.
+func (s *Server) handleListSubscriptions(w http.ResponseWriter, r *http.Request) {
+	fanslySync := "unavailable"
+	if s.fansly != nil {
+		fanslySync = "ok"
+		if s.subscriptionSyncFailed.Load() {
+			fanslySync = "failed"
+		}
+	}
+
+	subs, err := s.subscriptions.List(r.Context())
+	if err != nil {
+		s.logFor(r.Context()).Errorf("Failed to list subscriptions: %v", err)
+		respondWithError(w, http.StatusInternalServerError, "Failed to fetch subscriptions")
+		return
+	}
+	posts, err := s.posts.List(r.Context())
+	if err != nil {
+		s.logFor(r.Context()).Errorf("Failed to list posts: %v", err)
+		respondWithError(w, http.StatusInternalServerError, "Failed to fetch posts")
+		return
+	}
+
+	now := time.Now()
+	data := make([]subscriptionResponse, 0, len(subs))
+	for _, sub := range subs {
+		unlocked := 0
+		for _, post := range posts {
+			if post.CreatorID == sub.CreatorID && slices.Contains(post.TierIDs, sub.TierID) {
+				unlocked++
+			}
+		}
+		data = append(data, subscriptionResponse{Subscription: sub, Active: sub.Active(now), UnlockedPosts: unlocked})
+	}
+
+	respondWithJSON(w, http.StatusOK, map[string]interface{}{
+		"data": data,
+		"meta": map[string]interface{}{
+			"count":       len(data),
+			"fansly_sync": fanslySync,
+		},
+	})
+}
+
+// runSubscriptionSync refreshes the stored subscriptions every interval until ctx
+// is cancelled
+func (s *Server) runSubscriptionSync(ctx context.Context, interval time.Duration, heartbeat *health.Heartbeat) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.syncSubscriptions(ctx, heartbeat)
+		}
+	}
+}
+
+// syncSubscriptions refreshes the stored subscriptions and records whether it
+// worked; heartbeat, if any, beats on success
+func (s *Server) syncSubscriptions(ctx context.Context, heartbeat *health.Heartbeat) {
+	err := s.refreshSubscriptions(ctx)
+	s.subscriptionSyncFailed.Store(err != nil)
+	if err != nil {
+		if ctx.Err() == nil {
+			s.log.Warnf("Failed to refresh subscriptions: %v", err)
+		}
+		return
+	}
+	if heartbeat != nil {
+		heartbeat.Beat()
+	}
+}
+
+// refreshSubscriptions replaces the stored subscriptions with the current list from
+// Fansly. Tier names missing from the list are taken from the stored list, or else
+// looked up in the creator's tiers.
+func (s *Server) refreshSubscriptions(ctx context.Context) error {
+	fetched, err := s.fansly.ListSubscriptions(ctx)
+	if err != nil {
+		return fmt.Errorf("error listing subscriptions: %w", err)
+	}
+	stored, err := s.subscriptions.List(ctx)
+	if err != nil {
+		return fmt.Errorf("error listing stored subscriptions: %w", err)
+	}
+	tierNames := make(map[string]string, len(stored))
+	for _, sub := range stored {
+		tierNames[sub.TierID] = sub.TierName
+	}
+
+	now := time.Now().UTC()
+	subs := make([]*storage.Subscription, len(fetched))
+	for i := range fetched {
+		sub := &fetched[i]
+		sub.SyncedAt = now
+		if sub.TierName == "" {
+			sub.TierName = tierNames[sub.TierID]
+		}
+		if sub.TierName == "" {
+			tiers, err := s.fansly.GetSubscriptionTiers(ctx, sub.CreatorID)
+			if err != nil {
+				s.logFor(ctx).Warnf("Failed to get subscription tiers of creator %s: %v", sub.CreatorID, err)
+			}
+			for _, tier := range tiers {
+				if tier.ID == sub.TierID {
+					sub.TierName = tier.Name
+				}
+			}
+		}
+		subs[i] = sub
+	}
+	if err := s.subscriptions.Replace(ctx, subs); err != nil {
+		return fmt.Errorf("error storing subscriptions: %w", err)
+	}
+	return nil
+}
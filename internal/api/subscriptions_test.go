@@ -0,0 +1,152 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"fansly-api/internal/config"
+	"fansly-api/internal/logger"
+)
+
+func TestListSubscriptionsServesStoredList(t *testing.T) {
+	var calls atomic.Int32
+	var fail atomic.Bool
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		switch {
+		case fail.Load():
+			w.WriteHeader(http.StatusBadGateway)
+		case r.URL.Path == "/subscriptions":
+			w.Write([]byte(`{"response":{"subscriptions":[{"accountId":"c1","subscriptionTierId":"t1","price":999,"planDuration":1}]}}`))
+		case r.URL.Path == "/account/c1/subscriptiontiers":
+			w.Write([]byte(`{"response":[{"id":"t1","name":"Gold","price":999}]}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(upstream.Close)
+
+	s := newTestServer(t, &config.Config{})
+	s.fansly = NewFanslyClient("token", logger.New())
+	s.fansly.baseURL = upstream.URL
+	token, err := s.generateJWT("user", []string{RoleViewer})
+	if err != nil {
+		t.Fatal(err)
+	}
+	list := func() (data []subscriptionResponse, fanslySync string) {
+		t.Helper()
+		r := httptest.NewRequest("GET", "/api/v1/subscriptions", nil)
+		r.Header.Set("Authorization", "Bearer "+token)
+		rec := serve(s, r)
+		var resp struct {
+			Data []subscriptionResponse `json:"data"`
+			Meta struct {
+				FanslySync string `json:"fansly_sync"`
+			} `json:"meta"`
+		}
+		if rec.Code != http.StatusOK || json.NewDecoder(rec.Body).Decode(&resp) != nil {
+			t.Fatalf("status %d: %s", rec.Code, rec.Body)
+		}
+		return resp.Data, resp.Meta.FanslySync
+	}
+
+	s.syncSubscriptions(t.Context(), nil)
+	if calls.Load() != 2 {
+		t.Fatalf("%d upstream calls for the refresh, want 2", calls.Load())
+	}
+
+	// Reads don't call Fansly
+	calls.Store(0)
+	for range 3 {
+		data, fanslySync := list()
+		if len(data) != 1 || data[0].TierName != "Gold" || fanslySync != "ok" {
+			t.Fatalf("data %+v, fansly_sync %q", data, fanslySync)
+		}
+	}
+	if calls.Load() != 0 {
+		t.Fatalf("%d upstream calls while listing, want 0", calls.Load())
+	}
+
+	// Known tier names aren't looked up again
+	s.syncSubscriptions(t.Context(), nil)
+	if calls.Load() != 1 {
+		t.Fatalf("%d upstream calls for the second refresh, want 1", calls.Load())
+	}
+
+	// A failed refresh keeps the stored list
+	fail.Store(true)
+	s.syncSubscriptions(t.Context(), nil)
+	if data, fanslySync := list(); len(data) != 1 || fanslySync != "failed" {
+		t.Fatalf("data %+v, fansly_sync %q", data, fanslySync)
+	}
+}
+
+func TestSubscriptionWithoutRenewDateIsActive(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/subscriptions":
+			w.Write([]byte(`{"response":{"subscriptions":[
+				{"accountId":"c1","subscriptionTierId":"t1","subscriptionTierName":"Gold","price":999,"planDuration":1},
+				{"accountId":"c2","subscriptionTierId":"t2","subscriptionTierName":"Gold","price":999,"planDuration":1,"createdAt":1700000000,"renewDate":1702592000},
+				{"accountId":"c3","subscriptionTierId":"t3","subscriptionTierName":"Gold","price":999,"planDuration":1,"createdAt":1700000000,"renewDate":4102444800}
+			]}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(upstream.Close)
+
+	s := newTestServer(t, &config.Config{})
+	s.fansly = NewFanslyClient("token", logger.New())
+	s.fansly.baseURL = upstream.URL
+	token, err := s.generateJWT("user", []string{RoleViewer})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.syncSubscriptions(t.Context(), nil)
+
+	r := httptest.NewRequest("GET", "/api/v1/subscriptions", nil)
+	r.Header.Set("Authorization", "Bearer "+token)
+	rec := serve(s, r)
+	var resp struct {
+		Data []map[string]interface{} `json:"data"`
+	}
+	if rec.Code != http.StatusOK || json.NewDecoder(rec.Body).Decode(&resp) != nil {
+		t.Fatalf("status %d: %s", rec.Code, rec.Body)
+	}
+
+	// Soonest renewal first, and those without a renewal date last
+	want := []struct {
+		creatorID string
+		active    bool
+	}{{"c2", false}, {"c3", true}, {"c1", true}}
+	if len(resp.Data) != len(want) {
+		t.Fatalf("data %+v", resp.Data)
+	}
+	for i, w := range want {
+		sub := resp.Data[i]
+		if sub["creator_id"] != w.creatorID || sub["active"] != w.active {
+			t.Errorf("subscription %d: %+v, want %s active %v", i, sub, w.creatorID, w.active)
+		}
+	}
+	// Missing dates are omitted rather than reported as 1970
+	if _, ok := resp.Data[2]["renews_at"]; ok {
+		t.Errorf("renews_at reported without a renewal date: %v", resp.Data[2]["renews_at"])
+	}
+	if _, ok := resp.Data[2]["subscribed_at"]; ok {
+		t.Errorf("subscribed_at reported without a creation date: %v", resp.Data[2]["subscribed_at"])
+	}
+
+	// Creators are subscribed according to the stored subscription
+	sub, err := s.subscriptions.Get(t.Context(), "c1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !sub.RenewsAt.IsZero() || !sub.Active(time.Now()) {
+		t.Fatalf("stored subscription: %+v", sub)
+	}
+}
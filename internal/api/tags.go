@@ -0,0 +1,137 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+
+	"fansly-api/internal/service"
+	"fansly-api/internal/storage"
+)
+
+// creatorHashtagsParams are the query parameters of GET /api/v1/creators/{id}/hashtags
+type creatorHashtagsParams struct {
+	Limit int `query:"limit" default:"20" validate:"min=1,max=100"`
+}
+
+// handleCreatorHashtags handles GET /api/v1/creators/{id}/hashtags, listing the
+// hashtags a creator uses most in synced posts
+func (s *Server) handleCreatorHashtags(w http.ResponseWriter, r *http.Request) {
+	var params creatorHashtagsParams
+	if !bindQuery(w, r, &params) {
+		return
+	}
+	counts := s.tagIndex.TopHashtags(chi.URLParam(r, "id"), params.Limit)
+	respondWithJSON(w, http.StatusOK, map[string]interface{}{"data": counts})
+}
+
+// hashtagPostsParams are the query parameters of GET /api/v1/hashtags/{tag}/posts
+type hashtagPostsParams struct {
+	Limit  int `query:"limit" default:"20" validate:"min=1,max=100"`
+	Offset int `query:"offset" default:"0" validate:"min=0"`
+}
+
+// handleHashtagPosts handles GET /api/v1/hashtags/{tag}/posts, listing synced posts
+// with a hashtag, newest first
+func (s *Server) handleHashtagPosts(w http.ResponseWriter, r *http.Request) {
+	var params hashtagPostsParams
+	if !bindQuery(w, r, &params) {
+		return
+	}
+
+	ids, total := s.tagIndex.PostsByHashtag(chi.URLParam(r, "tag"), params.Limit, params.Offset)
+	posts := make([]*storage.Post, 0, len(ids))
+	for _, id := range ids {
+		post, err := s.posts.Get(r.Context(), id)
+		if errors.Is(err, storage.ErrNotFound) {
+			// Deleted since the index was queried
+			continue
+		} else if err != nil {
+			s.logFor(r.Context()).Errorf("Failed to get post %s: %v", id, err)
+			respondWithError(w, http.StatusInternalServerError, "Failed to fetch posts")
+			return
+		}
+		posts = append(posts, post)
+	}
+
+	respondWithJSON(w, http.StatusOK, map[string]interface{}{
+		"data": posts,
+		"meta": map[string]interface{}{
+			"total":    total,
+			"count":    len(posts),
+			"offset":   params.Offset,
+			"per_page": params.Limit,
+		},
+	})
+}
+
+// mentionGraphParams are the query parameters of GET /api/v1/mentions
+type mentionGraphParams struct {
+	CreatorID string `query:"creator_id"`
+	MinCount  int    `query:"min_count" default:"1" validate:"min=1"`
+}
+
+// mentionNode is an account in the mention graph. Mentioned accounts that aren't
+// known creators have no creator_id and are identified by "@username".
+type mentionNode struct {
+	ID        string `json:"id"`
+	CreatorID string `json:"creator_id,omitempty"`
+	Username  string `json:"username,omitempty"`
+}
+
+// mentionEdge is a creator mentioning an account in Count posts
+type mentionEdge struct {
+	Source string `json:"source"`
+	Target string `json:"target"`
+	Count  int    `json:"count"`
+}
+
+// handleMentionGraph handles GET /api/v1/mentions, returning who mentions whom in
+// synced posts as a graph of nodes and weighted edges
+func (s *Server) handleMentionGraph(w http.ResponseWriter, r *http.Request) {
+	var params mentionGraphParams
+	if !bindQuery(w, r, &params) {
+		return
+	}
+
+	// Resolve usernames to known creators where possible; without a Fansly
+	// connection, mentioned accounts are identified by username only
+	byID := make(map[string]service.Creator)
+	byUsername := make(map[string]service.Creator)
+	creators, err := s.scraperSvc.AllCreators(r.Context())
+	if err != nil && !errors.Is(err, service.ErrNotAuthenticated) {
+		s.logFor(r.Context()).Errorf("Failed to get creators: %v", err)
+		respondWithError(w, http.StatusInternalServerError, "Failed to fetch creators")
+		return
+	}
+	for _, c := range creators {
+		byID[c.ID] = c
+		byUsername[strings.ToLower(c.Username)] = c
+	}
+
+	nodes := []mentionNode{}
+	seen := make(map[string]bool)
+	addNode := func(n mentionNode) string {
+		if !seen[n.ID] {
+			seen[n.ID] = true
+			nodes = append(nodes, n)
+		}
+		return n.ID
+	}
+
+	edges := []mentionEdge{}
+	for _, m := range s.tagIndex.Mentions(params.CreatorID, params.MinCount) {
+		source := addNode(mentionNode{ID: m.CreatorID, CreatorID: m.CreatorID, Username: byID[m.CreatorID].Username})
+		target := mentionNode{ID: "@" + m.Username, Username: m.Username}
+		if c, ok := byUsername[m.Username]; ok {
+			target = mentionNode{ID: c.ID, CreatorID: c.ID, Username: c.Username}
+		}
+		edges = append(edges, mentionEdge{Source: source, Target: addNode(target), Count: m.Count})
+	}
+
+	respondWithJSON(w, http.StatusOK, map[string]interface{}{
+		"data": map[string]interface{}{"nodes": nodes, "edges": edges},
+	})
+}
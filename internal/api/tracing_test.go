@@ -0,0 +1,100 @@
+package api
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+
+	"fansly-api/internal/config"
+	"fansly-api/internal/tracing"
+)
+
+// recordSpans installs a global tracer provider that keeps every ended span in memory
+func recordSpans(t *testing.T) *tracetest.InMemoryExporter {
+	t.Helper()
+	exporter := tracetest.NewInMemoryExporter()
+	provider := tracing.NewProvider(sdktrace.NewSimpleSpanProcessor(exporter), 1, "")
+	prevProvider, prevPropagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() {
+		provider.Shutdown(t.Context())
+		otel.SetTracerProvider(prevProvider)
+		otel.SetTextMapPropagator(prevPropagator)
+	})
+	return exporter
+}
+
+// findSpan returns the recorded span with the given name
+func findSpan(t *testing.T, spans tracetest.SpanStubs, name string) tracetest.SpanStub {
+	t.Helper()
+	for _, span := range spans {
+		if span.Name == name {
+			return span
+		}
+	}
+	t.Fatalf("no span named %q in %d spans", name, len(spans))
+	return tracetest.SpanStub{}
+}
+
+// hasAttribute reports whether attrs contain want
+func hasAttribute(attrs []attribute.KeyValue, want attribute.KeyValue) bool {
+	for _, attr := range attrs {
+		if attr == want {
+			return true
+		}
+	}
+	return false
+}
+
+func TestTraceRequests(t *testing.T) {
+	exporter := recordSpans(t)
+	s := newTestServer(t, &config.Config{})
+	token, err := s.generateJWT("user", []string{RoleViewer})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := httptest.NewRequest("GET", "/api/v1/creators/search?q=alice", nil)
+	r.Header.Set("Authorization", "Bearer "+token)
+	r.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	rec := serve(s, r)
+
+	spans := exporter.GetSpans()
+	server := findSpan(t, spans, "GET /api/v1/creators/search")
+	if server.SpanKind != trace.SpanKindServer {
+		t.Errorf("server span kind %v", server.SpanKind)
+	}
+	if server.SpanContext.TraceID().String() != "4bf92f3577b34da6a3ce929d0e0e4736" || server.Parent.SpanID().String() != "00f067aa0ba902b7" {
+		t.Errorf("inbound traceparent not continued: trace %s, parent %s", server.SpanContext.TraceID(), server.Parent.SpanID())
+	}
+	for _, want := range []attribute.KeyValue{
+		attribute.String("http.request.method", "GET"),
+		attribute.String("http.route", "/api/v1/creators/search"),
+		attribute.String("url.path", "/api/v1/creators/search"),
+		attribute.Int("http.response.status_code", rec.Code),
+	} {
+		if !hasAttribute(server.Attributes, want) {
+			t.Errorf("server span lacks %s=%s: %v", want.Key, want.Value.Emit(), server.Attributes)
+		}
+	}
+
+	// The Fansly account isn't connected, so the service call fails inside the request span
+	call := findSpan(t, spans, "ScraperService.SearchCreators")
+	if call.Parent.SpanID() != server.SpanContext.SpanID() {
+		t.Errorf("service span is not a child of the request span")
+	}
+	if !hasAttribute(call.Attributes, attribute.Int("limit", 20)) {
+		t.Errorf("service span lacks limit: %v", call.Attributes)
+	}
+	if call.Status.Code != codes.Error || len(call.Events) == 0 {
+		t.Errorf("service span error not recorded: status %v, %d events", call.Status, len(call.Events))
+	}
+}
@@ -0,0 +1,160 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"fansly-api/internal/service"
+	"fansly-api/internal/storage"
+	"fansly-api/internal/vault"
+)
+
+// vaultParams are the query parameters of GET /api/v1/vault
+type vaultParams struct {
+	CreatorID string `query:"creator_id"`
+	Type      string `query:"type" validate:"oneof=image video"`
+	Source    string `query:"source" validate:"oneof=post message"`
+	Limit     int    `query:"limit" default:"50" validate:"min=1,max=200"`
+	Offset    int    `query:"offset" default:"0" validate:"min=0"`
+}
+
+// handleListVault handles GET /api/v1/vault, listing every media item the account
+// has bought, whether through a post or a message, newest purchase first
+func (s *Server) handleListVault(w http.ResponseWriter, r *http.Request) {
+	var params vaultParams
+	if !bindQuery(w, r, &params) {
+		return
+	}
+
+	items, ok := s.vaultItems(w, r)
+	if !ok {
+		return
+	}
+
+	matched := make([]service.VaultItem, 0, len(items))
+	for _, item := range items {
+		if (params.CreatorID == "" || item.CreatorID == params.CreatorID) &&
+			(params.Type == "" || item.Type == params.Type) &&
+			(params.Source == "" || item.Source == params.Source) {
+			matched = append(matched, item)
+		}
+	}
+	total := len(matched)
+	start := min(params.Offset, total)
+	end := min(start+params.Limit, total)
+	page := matched[start:end]
+
+	respondWithJSON(w, http.StatusOK, map[string]interface{}{
+		"data": page,
+		"meta": map[string]interface{}{
+			"total":    total,
+			"count":    len(page),
+			"offset":   params.Offset,
+			"per_page": params.Limit,
+		},
+	})
+}
+
+// handleStartVaultDownload handles POST /api/v1/vault/downloads, starting a background
+// job that downloads every purchased media item not downloaded yet
+func (s *Server) handleStartVaultDownload(w http.ResponseWriter, r *http.Request) {
+	// The downloader exists whenever the Fansly client does, which vaultItems checks
+	items, ok := s.vaultItems(w, r)
+	if !ok {
+		return
+	}
+
+	id, err := generateRandomString(16)
+	if err != nil {
+		s.logFor(r.Context()).Errorf("Failed to generate download job ID: %v", err)
+		respondWithError(w, http.StatusInternalServerError, "Failed to start download")
+		return
+	}
+	var createdBy string
+	if p, ok := PrincipalFromContext(r.Context()); ok {
+		createdBy = p.ID
+	}
+
+	job, err := s.downloader.Start(r.Context(), id, createdBy, items)
+	if errors.Is(err, vault.ErrJobRunning) {
+		respondWithError(w, http.StatusConflict, "A download job is already running")
+		return
+	} else if err != nil {
+		s.logFor(r.Context()).Errorf("Failed to start download job: %v", err)
+		respondWithError(w, http.StatusInternalServerError, "Failed to start download")
+		return
+	}
+
+	w.Header().Set("Location", "/api/v1/vault/downloads/"+job.ID)
+	respondWithJSON(w, http.StatusAccepted, map[string]interface{}{"data": job})
+}
+
+// handleListVaultDownloads handles GET /api/v1/vault/downloads, newest first
+func (s *Server) handleListVaultDownloads(w http.ResponseWriter, r *http.Request) {
+	jobs, err := s.downloadJobs.List(r.Context())
+	if err != nil {
+		s.logFor(r.Context()).Errorf("Failed to list download jobs: %v", err)
+		respondWithError(w, http.StatusInternalServerError, "Failed to fetch download jobs")
+		return
+	}
+	respondWithJSON(w, http.StatusOK, map[string]interface{}{"data": jobs})
+}
+
+// handleGetVaultDownload handles GET /api/v1/vault/downloads/{id}, reporting a job's progress
+func (s *Server) handleGetVaultDownload(w http.ResponseWriter, r *http.Request) {
+	job, ok := s.downloadJob(w, r)
+	if !ok {
+		return
+	}
+	respondWithJSON(w, http.StatusOK, map[string]interface{}{"data": job})
+}
+
+// handleCancelVaultDownload handles DELETE /api/v1/vault/downloads/{id}. The job
+// stops after aborting the download in progress; files already saved are kept.
+func (s *Server) handleCancelVaultDownload(w http.ResponseWriter, r *http.Request) {
+	job, ok := s.downloadJob(w, r)
+	if !ok {
+		return
+	}
+	if s.downloader == nil || s.downloader.Cancel(job.ID) != nil {
+		respondWithError(w, http.StatusConflict, "Download job is not running")
+		return
+	}
+	respondWithJSON(w, http.StatusAccepted, map[string]interface{}{"data": job})
+}
+
+// vaultItems fetches the vault, writing the error response when that fails
+func (s *Server) vaultItems(w http.ResponseWriter, r *http.Request) ([]service.VaultItem, bool) {
+	if s.fansly == nil {
+		respondWithError(w, http.StatusServiceUnavailable, "Fansly account is not connected")
+		return nil, false
+	}
+	items, err := s.scraperSvc.Vault(r.Context(), s.fansly)
+	switch {
+	case errors.Is(err, service.ErrNotAuthenticated):
+		respondWithError(w, http.StatusServiceUnavailable, "Fansly account is not connected")
+		return nil, false
+	case err != nil:
+		s.logFor(r.Context()).Errorf("Failed to list purchases: %v", err)
+		respondWithError(w, http.StatusBadGateway, "Failed to fetch purchases from Fansly")
+		return nil, false
+	}
+	return items, true
+}
+
+// downloadJob loads the job named in the URL, writing the error response when that fails
+func (s *Server) downloadJob(w http.ResponseWriter, r *http.Request) (*storage.DownloadJob, bool) {
+	id := chi.URLParam(r, "id")
+	job, err := s.downloadJobs.Get(r.Context(), id)
+	if errors.Is(err, storage.ErrNotFound) {
+		respondWithError(w, http.StatusNotFound, "Download job not found")
+		return nil, false
+	} else if err != nil {
+		s.logFor(r.Context()).Errorf("Failed to get download job %s: %v", id, err)
+		respondWithError(w, http.StatusInternalServerError, "Failed to fetch download job")
+		return nil, false
+	}
+	return job, true
+}
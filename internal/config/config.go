@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
+	"time"
 
 	"github.com/spf13/viper"
 )
@@ -11,10 +13,42 @@ import (
 type Config struct {
 	ServerAddress string `mapstructure:"SERVER_ADDRESS"`  // e.g., ":8080"
 	LogLevel      string `mapstructure:"LOG_LEVEL"`       // "debug", "info", "warn", "error"
+	LogFormat     string `mapstructure:"LOG_FORMAT"`      // "text" or "json"
 	Environment   string `mapstructure:"ENV"`            // "development" or "production"
 	JWTSecret     string `mapstructure:"JWT_SECRET"`     // Secret for signing JWT tokens
-	
+
+	// Fansly account used for upstream API calls
+	FanslyAuthToken          string        `mapstructure:"FANSLY_AUTH_TOKEN"`
+	MessageSyncInterval      time.Duration `mapstructure:"MESSAGE_SYNC_INTERVAL"`      // how often direct messages are mirrored, 0 disables
+	StoryPollInterval        time.Duration `mapstructure:"STORY_POLL_INTERVAL"`        // how often followed creators' stories are captured, 0 disables
+	PostSyncInterval         time.Duration `mapstructure:"POST_SYNC_INTERVAL"`         // how often followed creators' posts are synced, 0 disables
+	SubscriptionSyncInterval time.Duration `mapstructure:"SUBSCRIPTION_SYNC_INTERVAL"` // how often paid subscriptions are refreshed, 0 only at startup
+
+	// Log output
+	LogFile       string `mapstructure:"LOG_FILE"`        // log file path, empty for stdout
+	LogMaxSizeMB  int    `mapstructure:"LOG_MAX_SIZE_MB"` // rotate the log file at this size
+	LogMaxBackups int    `mapstructure:"LOG_MAX_BACKUPS"` // rotated log files to keep
+
+	// Metrics
+	MetricsToken string `mapstructure:"METRICS_TOKEN"` // bearer token for /metrics, empty for none
+
+	// Tracing
+	TracingExporter    string  `mapstructure:"TRACING_EXPORTER"`     // "otlp", "stdout" or "none"
+	TracingEndpoint    string  `mapstructure:"TRACING_ENDPOINT"`     // OTLP/HTTP host:port, empty for OTEL_EXPORTER_OTLP_ENDPOINT
+	TracingInsecure    bool    `mapstructure:"TRACING_INSECURE"`     // send OTLP without TLS
+	TracingSampleRatio float64 `mapstructure:"TRACING_SAMPLE_RATIO"` // fraction of new traces to record
+
+	// JWT signing configuration
+	JWTAlgorithm       string        `mapstructure:"JWT_ALGORITHM"`         // "HS256" (development), "RS256" or "EdDSA"
+	JWTPrivateKeyFile  string        `mapstructure:"JWT_PRIVATE_KEY_FILE"`  // PEM private key used to sign new tokens
+	JWTVerifyKeyFiles  string        `mapstructure:"JWT_VERIFY_KEY_FILES"`  // PEM public keys still accepted after rotation (comma-separated)
+	JWTExpiry          time.Duration `mapstructure:"JWT_EXPIRY"`            // Lifetime of issued tokens
+	DefaultRole        string        `mapstructure:"DEFAULT_ROLE"`          // Role granted to users on login
+
 	// OAuth2 Configuration
+	OAuthProvider string `mapstructure:"OAUTH_PROVIDER"` // "" (disabled), "oauth2" or "oidc"
+	OIDCIssuer    string `mapstructure:"OIDC_ISSUER"`    // OIDC issuer URL, endpoints are discovered from it
+	UserInfoURL   string `mapstructure:"USERINFO_URL"`   // OAuth2 userinfo URL (oauth2 provider only)
 	AuthURL       string `mapstructure:"AUTH_URL"`       // OAuth2 authorization URL
 	TokenURL      string `mapstructure:"TOKEN_URL"`      // OAuth2 token URL
 	CallbackURL   string `mapstructure:"CALLBACK_URL"`   // OAuth2 callback URL
@@ -23,21 +57,76 @@ type Config struct {
 	Scopes        string `mapstructure:"SCOPES"`         // OAuth2 scopes (comma-separated)
 	
 	// Session configuration
-	SessionSecret string `mapstructure:"SESSION_SECRET"` // Secret for encrypting sessions
-	SessionMaxAge int    `mapstructure:"SESSION_MAX_AGE"` // Session max age in seconds
+	SessionSecret      string        `mapstructure:"SESSION_SECRET"`       // Secret for signing session cookies
+	SessionMaxAge      int           `mapstructure:"SESSION_MAX_AGE"`      // Absolute session lifetime in seconds
+	SessionIdleTimeout time.Duration `mapstructure:"SESSION_IDLE_TIMEOUT"` // Sessions expire after this long without requests
+	SessionStore       string        `mapstructure:"SESSION_STORE"`        // "memory" or "file"
+
+	// CORS (all lists comma-separated)
+	CORSAllowedOrigins   string `mapstructure:"CORS_ALLOWED_ORIGINS"`   // Origins or patterns such as "https://*.example.com"
+	CORSAllowedMethods   string `mapstructure:"CORS_ALLOWED_METHODS"`   // Methods allowed in cross-origin requests
+	CORSAllowedHeaders   string `mapstructure:"CORS_ALLOWED_HEADERS"`   // Request headers allowed in cross-origin requests
+	CORSExposedHeaders   string `mapstructure:"CORS_EXPOSED_HEADERS"`   // Response headers readable by browser clients
+	CORSAllowCredentials bool   `mapstructure:"CORS_ALLOW_CREDENTIALS"` // Allow cookies; ignored when any origin is allowed
+	CORSMaxAge           int    `mapstructure:"CORS_MAX_AGE"`           // Preflight cache lifetime in seconds
+
+	// Inbound rate limiting
+	RateLimit       int           `mapstructure:"RATE_LIMIT"`        // Requests allowed per window and caller, 0 disables limiting
+	RateLimitWindow time.Duration `mapstructure:"RATE_LIMIT_WINDOW"` // Sliding window length
+	RateLimitRoutes string        `mapstructure:"RATE_LIMIT_ROUTES"` // Per-route overrides, e.g. "POST /api/v1/auth/initiate=5/1m"
+
+	// Pending authentication attempts (/auth/initiate)
+	AuthPendingTTL      time.Duration `mapstructure:"AUTH_PENDING_TTL"`        // How long an initiated attempt stays valid
+	AuthPendingMaxPerIP int           `mapstructure:"AUTH_PENDING_MAX_PER_IP"` // Outstanding attempts allowed per client IP
+	AuthPendingStore    string        `mapstructure:"AUTH_PENDING_STORE"`      // "memory" or "file"
 }
 
 func Load() (*Config, error) {
 	// Set default values
 	viper.SetDefault("SERVER_ADDRESS", ":8080")
 	viper.SetDefault("LOG_LEVEL", "info")
+	viper.SetDefault("LOG_FORMAT", "text")
+	viper.SetDefault("LOG_MAX_SIZE_MB", 100)
+	viper.SetDefault("LOG_MAX_BACKUPS", 5)
+	viper.SetDefault("TRACING_EXPORTER", "none")
+	viper.SetDefault("TRACING_SAMPLE_RATIO", 1.0)
 	viper.SetDefault("ENV", "development")
+	viper.SetDefault("JWT_ALGORITHM", "HS256")
+	viper.SetDefault("JWT_EXPIRY", "24h")
+	viper.SetDefault("DEFAULT_ROLE", "viewer")
+	viper.SetDefault("SESSION_MAX_AGE", 7*24*60*60)
+	viper.SetDefault("SESSION_IDLE_TIMEOUT", "30m")
+	viper.SetDefault("SESSION_STORE", "memory")
+	viper.SetDefault("CORS_ALLOWED_METHODS", "GET,POST,PUT,PATCH,DELETE,OPTIONS")
+	viper.SetDefault("CORS_ALLOWED_HEADERS", "Accept,Content-Type,Authorization,X-API-Key,X-CSRF-Token,X-Request-ID")
+	viper.SetDefault("CORS_EXPOSED_HEADERS", "X-Request-ID,RateLimit-Limit,RateLimit-Remaining,RateLimit-Reset,RateLimit-Policy,Retry-After")
+	viper.SetDefault("CORS_ALLOW_CREDENTIALS", true)
+	viper.SetDefault("CORS_MAX_AGE", 300)
+	viper.SetDefault("RATE_LIMIT", 100)
+	viper.SetDefault("RATE_LIMIT_WINDOW", "1m")
+	viper.SetDefault("AUTH_PENDING_TTL", "10m")
+	viper.SetDefault("AUTH_PENDING_MAX_PER_IP", 5)
+	viper.SetDefault("AUTH_PENDING_STORE", "memory")
 	viper.SetDefault("AUTH_URL", "https://fansly.com/oauth2/authorize")
 	viper.SetDefault("TOKEN_URL", "https://fansly.com/oauth2/token")
 	viper.SetDefault("CALLBACK_URL", "http://localhost:8080/api/v1/auth/callback")
+	viper.SetDefault("MESSAGE_SYNC_INTERVAL", "15m")
+	viper.SetDefault("STORY_POLL_INTERVAL", "15m")
+	viper.SetDefault("POST_SYNC_INTERVAL", "30m")
+	viper.SetDefault("SUBSCRIPTION_SYNC_INTERVAL", "1h")
 
 	// Read from environment variables
 	viper.AutomaticEnv()
+	// Keys without a default are only unmarshalled from the environment when bound
+	bindEnv("FANSLY_AUTH_TOKEN")
+	bindEnv("JWT_SECRET", "JWT_PRIVATE_KEY_FILE", "JWT_VERIFY_KEY_FILES")
+	bindEnv("SESSION_SECRET")
+	bindEnv("OAUTH_PROVIDER", "OIDC_ISSUER", "USERINFO_URL", "CLIENT_ID", "CLIENT_SECRET", "SCOPES")
+	bindEnv("RATE_LIMIT_ROUTES")
+	bindEnv("CORS_ALLOWED_ORIGINS")
+	bindEnv("LOG_FILE")
+	bindEnv("TRACING_ENDPOINT", "TRACING_INSECURE")
+	bindEnv("METRICS_TOKEN")
 
 	// Read from .env file if it exists
 	viper.SetConfigName(".env")
@@ -59,21 +148,153 @@ func Load() (*Config, error) {
 	return &config, nil
 }
 
+// bindEnv makes viper read each key from the environment variable of the same name
+func bindEnv(keys ...string) {
+	for _, key := range keys {
+		viper.BindEnv(key)
+	}
+}
+
 // Validate checks if the configuration is valid
 func (c *Config) Validate() error {
-	if c.JWTSecret == "" && c.Environment == "production" {
-		return fmt.Errorf("JWT_SECRET is required in production")
+	switch strings.ToLower(c.LogLevel) {
+	case "", "debug", "info", "warn", "warning", "error":
+	default:
+		return fmt.Errorf("unsupported LOG_LEVEL: %s", c.LogLevel)
+	}
+	switch strings.ToUpper(c.JWTAlgorithm) {
+	case "", "HS256":
+		if c.JWTSecret == "" && c.Environment == "production" {
+			return fmt.Errorf("JWT_SECRET is required in production")
+		}
+	case "RS256", "EDDSA":
+		if c.JWTPrivateKeyFile == "" {
+			return fmt.Errorf("JWT_PRIVATE_KEY_FILE is required for %s", c.JWTAlgorithm)
+		}
+	default:
+		return fmt.Errorf("unsupported JWT_ALGORITHM: %s", c.JWTAlgorithm)
+	}
+	switch strings.ToLower(c.TracingExporter) {
+	case "", "none", "stdout", "otlp":
+	default:
+		return fmt.Errorf("unsupported TRACING_EXPORTER: %s", c.TracingExporter)
+	}
+	if c.TracingSampleRatio < 0 || c.TracingSampleRatio > 1 {
+		return fmt.Errorf("TRACING_SAMPLE_RATIO must be between 0 and 1")
+	}
+	switch c.OAuthProvider {
+	case "":
+	case "oauth2":
+		if c.ClientID == "" || c.UserInfoURL == "" {
+			return fmt.Errorf("CLIENT_ID and USERINFO_URL are required for the oauth2 provider")
+		}
+	case "oidc":
+		if c.ClientID == "" || c.OIDCIssuer == "" {
+			return fmt.Errorf("CLIENT_ID and OIDC_ISSUER are required for the oidc provider")
+		}
+	default:
+		return fmt.Errorf("unsupported OAUTH_PROVIDER: %s", c.OAuthProvider)
+	}
+	for _, origin := range c.GetCORSAllowedOrigins() {
+		if origin == "*" && c.Environment == "production" {
+			return fmt.Errorf("CORS_ALLOWED_ORIGINS must list explicit origins in production")
+		}
+	}
+	if c.SessionSecret == "" && c.Environment == "production" {
+		return fmt.Errorf("SESSION_SECRET is required in production")
+	}
+	switch c.SessionStore {
+	case "", "memory", "file":
+	default:
+		return fmt.Errorf("unsupported SESSION_STORE: %s", c.SessionStore)
+	}
+	switch c.AuthPendingStore {
+	case "", "memory", "file":
+	default:
+		return fmt.Errorf("unsupported AUTH_PENDING_STORE: %s", c.AuthPendingStore)
+	}
+	if c.MessageSyncInterval < 0 {
+		return fmt.Errorf("MESSAGE_SYNC_INTERVAL must not be negative")
+	}
+	if c.StoryPollInterval < 0 {
+		return fmt.Errorf("STORY_POLL_INTERVAL must not be negative")
+	}
+	if c.PostSyncInterval < 0 {
+		return fmt.Errorf("POST_SYNC_INTERVAL must not be negative")
+	}
+	if c.SubscriptionSyncInterval < 0 {
+		return fmt.Errorf("SUBSCRIPTION_SYNC_INTERVAL must not be negative")
 	}
 	return nil
 }
 
-// IsValidAPIKey checks if the provided API key is valid
-// In a real application, you would validate against a database or configuration
-// For this example, we'll just check if it's not empty
-func (c *Config) IsValidAPIKey(apiKey string) bool {
-	// In a real application, you would validate the API key against a database or configuration
-	// For now, we'll just check if it's not empty
-	return apiKey != ""
+// GetSessionMaxAge returns the absolute session lifetime
+func (c *Config) GetSessionMaxAge() time.Duration {
+	if c.SessionMaxAge <= 0 {
+		return 7 * 24 * time.Hour
+	}
+	return time.Duration(c.SessionMaxAge) * time.Second
+}
+
+// GetJWTVerifyKeyFiles returns the list of additional verification key files
+func (c *Config) GetJWTVerifyKeyFiles() []string {
+	return splitList(c.JWTVerifyKeyFiles)
+}
+
+// GetCORSAllowedOrigins returns the allowed origins. Without configuration, development
+// allows local origins and production allows none.
+func (c *Config) GetCORSAllowedOrigins() []string {
+	origins := splitList(c.CORSAllowedOrigins)
+	if len(origins) == 0 && c.Environment != "production" {
+		return []string{"http://localhost:*", "http://127.0.0.1:*"}
+	}
+	return origins
+}
+
+// GetCORSAllowedMethods returns the methods allowed in cross-origin requests
+func (c *Config) GetCORSAllowedMethods() []string {
+	return splitList(c.CORSAllowedMethods)
+}
+
+// GetCORSAllowedHeaders returns the request headers allowed in cross-origin requests
+func (c *Config) GetCORSAllowedHeaders() []string {
+	return splitList(c.CORSAllowedHeaders)
+}
+
+// GetCORSExposedHeaders returns the response headers exposed to browser clients
+func (c *Config) GetCORSExposedHeaders() []string {
+	return splitList(c.CORSExposedHeaders)
+}
+
+// GetRateLimitRoutes returns the per-route rate limit overrides keyed by "METHOD /pattern"
+func (c *Config) GetRateLimitRoutes() map[string]string {
+	routes := make(map[string]string)
+	for _, entry := range splitList(c.RateLimitRoutes) {
+		if route, limit, ok := strings.Cut(entry, "="); ok {
+			routes[strings.TrimSpace(route)] = strings.TrimSpace(limit)
+		}
+	}
+	return routes
+}
+
+// GetScopes returns the OAuth2 scopes to request
+func (c *Config) GetScopes() []string {
+	scopes := splitList(c.Scopes)
+	if len(scopes) == 0 && c.OAuthProvider == "oidc" {
+		return []string{"openid", "email", "profile"}
+	}
+	return scopes
+}
+
+// splitList splits a comma-separated config value, dropping empty entries
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
 }
 
 // GetDataDir returns the directory where data should be stored
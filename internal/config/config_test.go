@@ -0,0 +1,71 @@
+package config
+
+import (
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// load reads the configuration with a fresh viper instance, away from any .env file
+func load(t *testing.T) *Config {
+	t.Helper()
+	viper.Reset()
+	t.Cleanup(viper.Reset)
+	cfg, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cfg
+}
+
+func TestLoadFromEnvironment(t *testing.T) {
+	t.Chdir(t.TempDir())
+	fields := reflect.VisibleFields(reflect.TypeFor[Config]())
+	for _, field := range fields {
+		key := field.Tag.Get("mapstructure")
+		t.Setenv(key, "")
+		os.Unsetenv(key)
+	}
+	defaults := reflect.ValueOf(load(t)).Elem()
+
+	// Set every variable to a value that differs from its default
+	want := reflect.New(reflect.TypeFor[Config]()).Elem()
+	for _, field := range fields {
+		key := field.Tag.Get("mapstructure")
+		value := want.FieldByIndex(field.Index)
+		var env string
+		switch field.Type {
+		case reflect.TypeFor[string]():
+			env = "env-" + strings.ToLower(key)
+			value.SetString(env)
+		case reflect.TypeFor[int]():
+			env = "7"
+			value.SetInt(7)
+		case reflect.TypeFor[bool]():
+			b := !defaults.FieldByIndex(field.Index).Bool()
+			env = strconv.FormatBool(b)
+			value.SetBool(b)
+		case reflect.TypeFor[float64]():
+			env = "0.5"
+			value.SetFloat(0.5)
+		case reflect.TypeFor[time.Duration]():
+			env = "7s"
+			value.SetInt(int64(7 * time.Second))
+		default:
+			t.Fatalf("%s: no test value for type %s", key, field.Type)
+		}
+		t.Setenv(key, env)
+	}
+
+	got := reflect.ValueOf(load(t)).Elem()
+	for _, field := range fields {
+		if g, w := got.FieldByIndex(field.Index).Interface(), want.FieldByIndex(field.Index).Interface(); g != w {
+			t.Errorf("%s: got %v, want %v", field.Tag.Get("mapstructure"), g, w)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+// Package events distributes notifications about changes made through the API,
+// such as a creator being followed, to subscribers inside the process
+package events
+
+import (
+	"sync"
+	"time"
+)
+
+// Event types
+const (
+	CreatorFollowed   = "creator.followed"
+	CreatorUnfollowed = "creator.unfollowed"
+
+	// Published by the sync engine after saving or removing a post, with "post_id" in Data
+	PostSynced  = "post.synced"
+	PostDeleted = "post.deleted"
+)
+
+// Event is a change that subscribers are notified of
+type Event struct {
+	Type string                 `json:"type"`
+	Time time.Time              `json:"time"`
+	Data map[string]interface{} `json:"data,omitempty"`
+}
+
+// Handler receives published events. Handlers run synchronously in the
+// publisher's goroutine and must not block.
+type Handler func(Event)
+
+// Bus delivers published events to every subscriber
+type Bus struct {
+	mu       sync.RWMutex
+	nextID   int
+	handlers map[int]Handler
+}
+
+// NewBus creates a bus without subscribers
+func NewBus() *Bus {
+	return &Bus{handlers: make(map[int]Handler)}
+}
+
+// Subscribe registers h for every event and returns a function that removes it
+func (b *Bus) Subscribe(h Handler) (unsubscribe func()) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	id := b.nextID
+	b.nextID++
+	b.handlers[id] = h
+	return func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		delete(b.handlers, id)
+	}
+}
+
+// Publish delivers an event of the given type to every subscriber
+func (b *Bus) Publish(eventType string, data map[string]interface{}) {
+	event := Event{Type: eventType, Time: time.Now().UTC(), Data: data}
+
+	b.mu.RLock()
+	handlers := make([]Handler, 0, len(b.handlers))
+	for _, h := range b.handlers {
+		handlers = append(handlers, h)
+	}
+	b.mu.RUnlock()
+
+	for _, h := range handlers {
+		h(event)
+	}
+}
@@ -0,0 +1,224 @@
+// Package health runs the dependency checks behind the readiness endpoint
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Check statuses
+const (
+	StatusOK   = "ok"
+	StatusFail = "fail"
+)
+
+// CheckFunc reports whether a dependency is healthy
+type CheckFunc func(ctx context.Context) error
+
+// Result is the outcome of one check
+type Result struct {
+	Name      string  `json:"name"`
+	Status    string  `json:"status"`
+	LatencyMS float64 `json:"latency_ms"`
+	Error     string  `json:"error,omitempty"`
+}
+
+// Report is the outcome of every registered check
+type Report struct {
+	Status string   `json:"status"`
+	Checks []Result `json:"checks"`
+}
+
+// OK reports whether every check passed
+func (r Report) OK() bool {
+	return r.Status == StatusOK
+}
+
+type namedCheck struct {
+	name  string
+	check CheckFunc
+}
+
+// Checker runs registered checks concurrently, each with its own timeout
+type Checker struct {
+	timeout time.Duration
+
+	mu     sync.RWMutex
+	checks []namedCheck
+}
+
+// NewChecker creates a checker that fails any check taking longer than timeout
+func NewChecker(timeout time.Duration) *Checker {
+	return &Checker{timeout: timeout}
+}
+
+// Register adds a check. Checks are reported in registration order.
+func (c *Checker) Register(name string, check CheckFunc) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.checks = append(c.checks, namedCheck{name: name, check: check})
+}
+
+// Run runs every check and returns the combined report
+func (c *Checker) Run(ctx context.Context) Report {
+	c.mu.RLock()
+	checks := append([]namedCheck(nil), c.checks...)
+	c.mu.RUnlock()
+
+	results := make([]Result, len(checks))
+	var wg sync.WaitGroup
+	for i, nc := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = c.run(ctx, nc)
+		}()
+	}
+	wg.Wait()
+
+	report := Report{Status: StatusOK, Checks: results}
+	for _, result := range results {
+		if result.Status != StatusOK {
+			report.Status = StatusFail
+		}
+	}
+	return report
+}
+
+func (c *Checker) run(ctx context.Context, nc namedCheck) Result {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	start := time.Now()
+	done := make(chan error, 1)
+	go func() { done <- nc.check(ctx) }()
+
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = fmt.Errorf("timed out after %s", c.timeout)
+	}
+
+	result := Result{
+		Name:      nc.name,
+		Status:    StatusOK,
+		LatencyMS: float64(time.Since(start).Microseconds()) / 1000,
+	}
+	if err != nil {
+		result.Status = StatusFail
+		result.Error = err.Error()
+	}
+	return result
+}
+
+// Cached wraps check so its result is reused for ttl. Use it for checks that
+// call external services, so readiness probes don't hammer them.
+func Cached(check CheckFunc, ttl time.Duration) CheckFunc {
+	var (
+		mu        sync.Mutex
+		lastErr   error
+		checkedAt time.Time
+	)
+	return func(ctx context.Context) error {
+		mu.Lock()
+		defer mu.Unlock()
+		if !checkedAt.IsZero() && time.Since(checkedAt) < ttl {
+			return lastErr
+		}
+		lastErr = check(ctx)
+		checkedAt = time.Now()
+		return lastErr
+	}
+}
+
+// DirWritable checks that a file can be created in dir
+func DirWritable(dir string) CheckFunc {
+	return func(ctx context.Context) error {
+		f, err := os.CreateTemp(dir, ".health-*")
+		if err != nil {
+			// Report the cause without the path, which the readiness response would expose
+			var pathErr *fs.PathError
+			if errors.As(err, &pathErr) {
+				err = pathErr.Err
+			}
+			return fmt.Errorf("data directory is not writable: %w", err)
+		}
+		name := f.Name()
+		f.Close()
+		return os.Remove(name)
+	}
+}
+
+// FilesReadable checks that each of paths can be opened and read. Missing files
+// pass, since stores only create their file on the first write.
+func FilesReadable(paths ...string) CheckFunc {
+	return func(ctx context.Context) error {
+		for _, path := range paths {
+			if err := readable(path); err != nil {
+				// Name the file without its directory, which the readiness response would expose
+				var pathErr *fs.PathError
+				if errors.As(err, &pathErr) {
+					err = pathErr.Err
+				}
+				return fmt.Errorf("%s is not readable: %w", filepath.Base(path), err)
+			}
+		}
+		return nil
+	}
+}
+
+// readable opens path and reads its first byte
+func readable(path string) error {
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if _, err := f.Read(make([]byte, 1)); err != nil && err != io.EOF {
+		return err
+	}
+	return nil
+}
+
+// Heartbeat tracks the liveness of a background worker, such as a download
+// queue or scheduler, that calls Beat each time it makes progress
+type Heartbeat struct {
+	maxAge time.Duration
+
+	mu   sync.Mutex
+	last time.Time
+}
+
+// NewHeartbeat creates a heartbeat that is healthy while the last beat is younger than maxAge
+func NewHeartbeat(maxAge time.Duration) *Heartbeat {
+	return &Heartbeat{maxAge: maxAge, last: time.Now()}
+}
+
+// Beat records progress
+func (h *Heartbeat) Beat() {
+	h.mu.Lock()
+	h.last = time.Now()
+	h.mu.Unlock()
+}
+
+// Check fails when the worker hasn't beaten within maxAge
+func (h *Heartbeat) Check(ctx context.Context) error {
+	h.mu.Lock()
+	age := time.Since(h.last)
+	h.mu.Unlock()
+	if age > h.maxAge {
+		return fmt.Errorf("no progress for %s", age.Round(time.Second))
+	}
+	return nil
+}
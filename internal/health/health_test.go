@@ -0,0 +1,39 @@
+package health
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFilesReadable(t *testing.T) {
+	dir := t.TempDir()
+	present := filepath.Join(dir, "present.json")
+	if err := os.WriteFile(present, []byte("[]"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	empty := filepath.Join(dir, "empty.json")
+	if err := os.WriteFile(empty, nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	if err := FilesReadable(present, empty, filepath.Join(dir, "missing.json"))(ctx); err != nil {
+		t.Fatalf("readable and missing files: %v", err)
+	}
+
+	// A directory where a store file should be can be opened but not read
+	broken := filepath.Join(dir, "broken.json")
+	if err := os.Mkdir(broken, 0700); err != nil {
+		t.Fatal(err)
+	}
+	err := FilesReadable(present, broken)(ctx)
+	if err == nil || !strings.HasPrefix(err.Error(), "broken.json is not readable") {
+		t.Fatalf("unreadable file: got %v", err)
+	}
+	if strings.Contains(err.Error(), dir) {
+		t.Errorf("error exposes the data directory: %v", err)
+	}
+}
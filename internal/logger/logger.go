@@ -1,38 +1,203 @@
+// Package logger provides the structured, leveled logger used across the API
 package logger
 
-import "log"
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+)
+
+// LevelFatal is logged by Fatal and Fatalf before the process exits
+const LevelFatal = slog.Level(12)
 
 // Logger defines the interface for logging
 type Logger interface {
+	Debug(args ...interface{})
+	Info(args ...interface{})
+	Warn(args ...interface{})
+	Error(args ...interface{})
+	Fatal(args ...interface{})
 	Debugf(format string, args ...interface{})
 	Infof(format string, args ...interface{})
 	Warnf(format string, args ...interface{})
 	Errorf(format string, args ...interface{})
+	Fatalf(format string, args ...interface{})
+	// With returns a logger that adds the given key-value pairs to every entry.
+	// The returned logger shares the level of its parent.
+	With(args ...interface{}) Logger
+	// Level returns the current minimum level
+	Level() string
+	// SetLevel changes the minimum level of this logger and every logger derived from it
+	SetLevel(level string) error
+}
+
+// Options configures a logger
+type Options struct {
+	Level      string    // "debug", "info" (default), "warn" or "error"
+	Format     string    // "text" (default) or "json"
+	File       string    // log file path; empty writes to Output
+	MaxSizeMB  int       // rotate the log file when it exceeds this size, 0 to disable
+	MaxBackups int       // number of rotated files to keep
+	Output     io.Writer // defaults to stdout
 }
 
-// New creates a new console logger
+// New creates a text logger writing to stdout at info level
 func New() Logger {
-	return &consoleLogger{}
+	l, _ := NewWithOptions(Options{})
+	return l
+}
+
+// NewWithOptions creates a logger from the given options
+func NewWithOptions(opts Options) (Logger, error) {
+	level := new(slog.LevelVar)
+	if opts.Level != "" {
+		parsed, err := ParseLevel(opts.Level)
+		if err != nil {
+			return nil, err
+		}
+		level.Set(parsed)
+	}
+
+	out := opts.Output
+	if out == nil {
+		out = os.Stdout
+	}
+	if opts.File != "" {
+		file, err := newRotatingFile(opts.File, int64(opts.MaxSizeMB)*1024*1024, opts.MaxBackups)
+		if err != nil {
+			return nil, err
+		}
+		out = file
+	}
+
+	handlerOpts := &slog.HandlerOptions{Level: level, ReplaceAttr: replaceLevel}
+	var handler slog.Handler
+	if strings.EqualFold(opts.Format, "json") {
+		handler = slog.NewJSONHandler(out, handlerOpts)
+	} else {
+		handler = slog.NewTextHandler(out, handlerOpts)
+	}
+	return &slogLogger{log: slog.New(handler), level: level}, nil
+}
+
+// ParseLevel converts a level name into a slog level
+func ParseLevel(level string) (slog.Level, error) {
+	switch strings.ToLower(level) {
+	case "debug":
+		return slog.LevelDebug, nil
+	case "info":
+		return slog.LevelInfo, nil
+	case "warn", "warning":
+		return slog.LevelWarn, nil
+	case "error":
+		return slog.LevelError, nil
+	default:
+		return 0, fmt.Errorf("invalid log level: %s", level)
+	}
 }
 
-type consoleLogger struct{}
+// levelName returns the lower-case name used by ParseLevel and the admin API
+func levelName(level slog.Level) string {
+	switch {
+	case level >= LevelFatal:
+		return "fatal"
+	case level >= slog.LevelError:
+		return "error"
+	case level >= slog.LevelWarn:
+		return "warn"
+	case level >= slog.LevelInfo:
+		return "info"
+	default:
+		return "debug"
+	}
+}
+
+// replaceLevel prints LevelFatal as FATAL instead of ERROR+4
+func replaceLevel(groups []string, a slog.Attr) slog.Attr {
+	if a.Key == slog.LevelKey && len(groups) == 0 {
+		if level, ok := a.Value.Any().(slog.Level); ok && level >= LevelFatal {
+			a.Value = slog.StringValue("FATAL")
+		}
+	}
+	return a
+}
+
+type slogLogger struct {
+	log   *slog.Logger
+	level *slog.LevelVar
+}
+
+func (l *slogLogger) Debug(args ...interface{}) {
+	l.log.Debug(fmt.Sprint(args...))
+}
+
+func (l *slogLogger) Info(args ...interface{}) {
+	l.log.Info(fmt.Sprint(args...))
+}
 
-func (l *consoleLogger) Debugf(format string, args ...interface{}) {
-	logf("DEBUG", format, args...)
+func (l *slogLogger) Warn(args ...interface{}) {
+	l.log.Warn(fmt.Sprint(args...))
 }
 
-func (l *consoleLogger) Infof(format string, args ...interface{}) {
-	logf("INFO ", format, args...)
+func (l *slogLogger) Error(args ...interface{}) {
+	l.log.Error(fmt.Sprint(args...))
 }
 
-func (l *consoleLogger) Warnf(format string, args ...interface{}) {
-	logf("WARN ", format, args...)
+func (l *slogLogger) Fatal(args ...interface{}) {
+	l.log.Log(context.Background(), LevelFatal, fmt.Sprint(args...))
+	os.Exit(1)
 }
 
-func (l *consoleLogger) Errorf(format string, args ...interface{}) {
-	logf("ERROR", format, args...)
+func (l *slogLogger) Debugf(format string, args ...interface{}) {
+	l.log.Debug(fmt.Sprintf(format, args...))
+}
+
+func (l *slogLogger) Infof(format string, args ...interface{}) {
+	l.log.Info(fmt.Sprintf(format, args...))
+}
+
+func (l *slogLogger) Warnf(format string, args ...interface{}) {
+	l.log.Warn(fmt.Sprintf(format, args...))
+}
+
+func (l *slogLogger) Errorf(format string, args ...interface{}) {
+	l.log.Error(fmt.Sprintf(format, args...))
+}
+
+func (l *slogLogger) Fatalf(format string, args ...interface{}) {
+	l.log.Log(context.Background(), LevelFatal, fmt.Sprintf(format, args...))
+	os.Exit(1)
+}
+
+func (l *slogLogger) With(args ...interface{}) Logger {
+	return &slogLogger{log: l.log.With(args...), level: l.level}
+}
+
+func (l *slogLogger) Level() string {
+	return levelName(l.level.Level())
+}
+
+func (l *slogLogger) SetLevel(level string) error {
+	parsed, err := ParseLevel(level)
+	if err != nil {
+		return err
+	}
+	l.level.Set(parsed)
+	return nil
+}
+
+type contextKey struct{}
+
+// WithContext returns a copy of ctx carrying the logger
+func WithContext(ctx context.Context, l Logger) context.Context {
+	return context.WithValue(ctx, contextKey{}, l)
 }
 
-func logf(level, format string, args ...interface{}) {
-	log.Printf("["+level+"] "+format, args...)
+// FromContext returns the logger stored in ctx, if any
+func FromContext(ctx context.Context) (Logger, bool) {
+	l, ok := ctx.Value(contextKey{}).(Logger)
+	return l, ok
 }
@@ -0,0 +1,114 @@
+// Package media saves creators' media files downloaded from Fansly to disk
+package media
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"time"
+
+	"fansly-api/internal/metrics"
+)
+
+// ErrStalled is returned when a download stops receiving data
+var ErrStalled = errors.New("download stalled")
+
+// IdleTimeout is how long a download may go without receiving data by default
+const IdleTimeout = time.Minute
+
+// Fetcher downloads media files
+type Fetcher interface {
+	Download(ctx context.Context, mediaURL string, w io.Writer) (int64, error)
+}
+
+// ValidID reports whether id can be used as a file name below a download directory
+func ValidID(id string) bool {
+	return id != "" && id == filepath.Base(id) && id != "." && id != ".."
+}
+
+// Path returns where a media file is saved relative to the download directory:
+// <creator ID>/<media ID><ext>. The file extension is taken from the download URL,
+// falling back to one matching the media type.
+func Path(creatorID, mediaID, mediaType, mediaURL string) (string, error) {
+	for _, id := range []string{creatorID, mediaID} {
+		if !ValidID(id) {
+			return "", fmt.Errorf("invalid ID %q", id)
+		}
+	}
+
+	ext := ".jpg"
+	if mediaType == "video" {
+		ext = ".mp4"
+	}
+	if u, err := url.Parse(mediaURL); err == nil {
+		if e := path.Ext(u.Path); len(e) > 1 && len(e) <= 5 {
+			ext = e
+		}
+	}
+	return filepath.Join(creatorID, mediaID+ext), nil
+}
+
+// Save downloads mediaURL to dest and returns its size. The download is written to
+// a temporary file that is renamed once complete, so a partial download is never
+// taken for a complete one. It fails with ErrStalled when no data arrives for
+// idleTimeout; progress, if not nil, is called whenever data arrives.
+func Save(ctx context.Context, fetcher Fetcher, mediaURL, dest string, idleTimeout time.Duration, progress func()) (int64, error) {
+	if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		return 0, fmt.Errorf("error creating directory: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(dest), ".download-*")
+	if err != nil {
+		return 0, fmt.Errorf("error creating file: %w", err)
+	}
+
+	start := time.Now()
+	n, err := fetch(ctx, fetcher, mediaURL, tmp, idleTimeout, progress)
+	if closeErr := tmp.Close(); err == nil && closeErr != nil {
+		err = fmt.Errorf("error writing file: %w", closeErr)
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), dest)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+	}
+	if ctx.Err() == nil {
+		metrics.ObserveDownload(n, time.Since(start), err)
+	}
+	return n, err
+}
+
+// fetch downloads mediaURL into w, aborting when no data arrives for idleTimeout
+func fetch(ctx context.Context, fetcher Fetcher, mediaURL string, w io.Writer, idleTimeout time.Duration, progress func()) (int64, error) {
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+	timer := time.AfterFunc(idleTimeout, func() { cancel(ErrStalled) })
+	defer timer.Stop()
+
+	n, err := fetcher.Download(ctx, mediaURL, &idleWriter{w: w, timer: timer, timeout: idleTimeout, progress: progress})
+	if err != nil && errors.Is(context.Cause(ctx), ErrStalled) {
+		return n, fmt.Errorf("no data received for %s: %w", idleTimeout, ErrStalled)
+	}
+	return n, err
+}
+
+// idleWriter restarts the idle timer of a download on every write
+type idleWriter struct {
+	w        io.Writer
+	timer    *time.Timer
+	timeout  time.Duration
+	progress func()
+}
+
+func (iw *idleWriter) Write(p []byte) (int, error) {
+	iw.timer.Reset(iw.timeout)
+	if iw.progress != nil {
+		iw.progress()
+	}
+	return iw.w.Write(p)
+}
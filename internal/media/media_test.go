@@ -0,0 +1,132 @@
+package media
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeFetcher writes the URL as the file content one byte at a time, pausing
+// between writes; URLs containing "fail" fail and URLs containing "hang" block
+// until the download is aborted
+type fakeFetcher struct {
+	pause time.Duration
+}
+
+func (f fakeFetcher) Download(ctx context.Context, mediaURL string, w io.Writer) (int64, error) {
+	if strings.Contains(mediaURL, "fail") {
+		return 0, errors.New("upstream error")
+	}
+	if strings.Contains(mediaURL, "hang") {
+		<-ctx.Done()
+		return 0, ctx.Err()
+	}
+	var n int64
+	for i := range len(mediaURL) {
+		if i > 0 && f.pause > 0 {
+			select {
+			case <-time.After(f.pause):
+			case <-ctx.Done():
+				return n, ctx.Err()
+			}
+		}
+		if _, err := io.WriteString(w, mediaURL[i:i+1]); err != nil {
+			return n, err
+		}
+		n++
+	}
+	return n, nil
+}
+
+func TestPath(t *testing.T) {
+	tests := []struct {
+		creatorID, mediaID, mediaType, url string
+		want                               string
+	}{
+		{"c1", "m1", "image", "https://cdn.example.com/a/m1.png?sig=abc", "c1/m1.png"},
+		{"c1", "m1", "image", "https://cdn.example.com/m1", "c1/m1.jpg"},
+		{"c1", "m1", "video", "https://cdn.example.com/m1", "c1/m1.mp4"},
+		// Long extensions aren't taken from the URL
+		{"c1", "m1", "video", "https://cdn.example.com/m1.download", "c1/m1.mp4"},
+		{"c1", "m1", "image", "", "c1/m1.jpg"},
+	}
+	for _, tt := range tests {
+		got, err := Path(tt.creatorID, tt.mediaID, tt.mediaType, tt.url)
+		if err != nil || filepath.ToSlash(got) != tt.want {
+			t.Errorf("%s: %q, %v; want %q", tt.url, got, err, tt.want)
+		}
+	}
+
+	for _, ids := range [][2]string{{"", "m1"}, {"c1", ""}, {"..", "m1"}, {"c1", "."}, {"c1", "a/b"}, {"c1/..", "m1"}} {
+		if _, err := Path(ids[0], ids[1], "image", ""); err == nil {
+			t.Errorf("%q: expected an invalid ID error", ids)
+		}
+	}
+}
+
+func TestSave(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	dest := filepath.Join(dir, "c1", "m1.jpg")
+	const url = "https://cdn.example.com/m1.jpg"
+
+	progress := 0
+	n, err := Save(ctx, fakeFetcher{}, url, dest, time.Minute, func() { progress++ })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data, err := os.ReadFile(dest); err != nil || string(data) != url || n != int64(len(url)) {
+		t.Fatalf("file %q, %v; %d bytes", data, err, n)
+	}
+	if progress != len(url) {
+		t.Fatalf("progress reported %d times, want %d", progress, len(url))
+	}
+
+	// A failed download leaves neither the file nor the temporary file behind
+	failed := filepath.Join(dir, "c1", "m2.jpg")
+	if _, err := Save(ctx, fakeFetcher{}, "https://cdn.example.com/fail.jpg", failed, time.Minute, nil); err == nil {
+		t.Fatal("expected the download to fail")
+	}
+	if entries, _ := os.ReadDir(filepath.Join(dir, "c1")); len(entries) != 1 {
+		t.Fatalf("%d files after a failed download, want 1", len(entries))
+	}
+}
+
+func TestSaveIdleTimeout(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	start := time.Now()
+	_, err := Save(ctx, fakeFetcher{}, "https://cdn.example.com/hang.jpg", filepath.Join(dir, "m1.jpg"), 20*time.Millisecond, nil)
+	if !errors.Is(err, ErrStalled) {
+		t.Fatalf("error %v, want ErrStalled", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Fatalf("stalled download aborted after %s", elapsed)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Fatalf("%d files after a stalled download, want 0", len(entries))
+	}
+
+	// A pause longer than the timeout stalls the download
+	if _, err := Save(ctx, fakeFetcher{pause: 100 * time.Millisecond}, "https://cdn.example.com/m2.jpg", filepath.Join(dir, "m2.jpg"), 20*time.Millisecond, nil); !errors.Is(err, ErrStalled) {
+		t.Fatalf("pause longer than the timeout: error %v, want ErrStalled", err)
+	}
+	// The timeout restarts whenever data arrives, so a download taking longer than
+	// the timeout completes as long as data keeps arriving
+	if _, err := Save(ctx, fakeFetcher{pause: 5 * time.Millisecond}, "https://cdn.example.com/m3.jpg", filepath.Join(dir, "m3.jpg"), 50*time.Millisecond, nil); err != nil {
+		t.Fatalf("pauses shorter than the timeout: %v", err)
+	}
+
+	// Cancelling the context isn't reported as a stall
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	if _, err := Save(cancelled, fakeFetcher{}, "https://cdn.example.com/hang.jpg", filepath.Join(dir, "m4.jpg"), time.Minute, nil); errors.Is(err, ErrStalled) || err == nil {
+		t.Fatalf("cancelled download: error %v", err)
+	}
+}
@@ -0,0 +1,147 @@
+// Package messaging mirrors direct messages from Fansly into local storage
+package messaging
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"fansly-api/internal/health"
+	"fansly-api/internal/logger"
+	"fansly-api/internal/storage"
+)
+
+// pageSize is the number of messages requested from Fansly at a time
+const pageSize = 50
+
+// Source lists message groups and messages on Fansly
+type Source interface {
+	ListMessageGroups(ctx context.Context) ([]storage.MessageGroup, error)
+	// ListMessages returns up to limit messages of a group older than the message
+	// with ID before (or the newest when before is empty), newest first
+	ListMessages(ctx context.Context, groupID, before string, limit int) ([]storage.Message, error)
+}
+
+// Syncer periodically mirrors every message group and its messages into a store.
+// Each pass fetches messages newest first. Once a group's full history has been
+// downloaded, later passes stop at the first page containing messages already stored.
+type Syncer struct {
+	source    Source
+	store     storage.MessageStore
+	log       logger.Logger
+	interval  time.Duration
+	heartbeat *health.Heartbeat
+}
+
+// NewSyncer creates a syncer that runs every interval
+func NewSyncer(source Source, store storage.MessageStore, log logger.Logger, interval time.Duration) *Syncer {
+	return &Syncer{
+		source:   source,
+		store:    store,
+		log:      log,
+		interval: interval,
+		// Allow a pass to fail twice before reporting the syncer unhealthy
+		heartbeat: health.NewHeartbeat(3 * interval),
+	}
+}
+
+// Heartbeat beats after every successful pass
+func (s *Syncer) Heartbeat() *health.Heartbeat {
+	return s.heartbeat
+}
+
+// Run syncs immediately and then every interval until ctx is cancelled
+func (s *Syncer) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	for {
+		if err := s.SyncOnce(ctx); err != nil && ctx.Err() == nil {
+			s.log.Warnf("Message sync failed: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// SyncOnce mirrors every group. A failing group doesn't stop the others; the
+// errors are returned together.
+func (s *Syncer) SyncOnce(ctx context.Context) error {
+	groups, err := s.source.ListMessageGroups(ctx)
+	if err != nil {
+		return fmt.Errorf("error listing message groups: %w", err)
+	}
+
+	var errs []error
+	added := 0
+	for _, group := range groups {
+		n, err := s.syncGroup(ctx, group)
+		added += n
+		if err != nil {
+			errs = append(errs, fmt.Errorf("group %s: %w", group.ID, err))
+		}
+	}
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+
+	s.heartbeat.Beat()
+	s.log.Debugf("Message sync finished: %d groups, %d new messages", len(groups), added)
+	return nil
+}
+
+// syncGroup stores the messages of a group that aren't stored yet and returns how many there were
+func (s *Syncer) syncGroup(ctx context.Context, group storage.MessageGroup) (int, error) {
+	stored, err := s.store.GetGroup(ctx, group.ID)
+	if err != nil && !errors.Is(err, storage.ErrNotFound) {
+		return 0, err
+	}
+	// Until a pass has reached the oldest message, keep paging past stored messages
+	historySynced := stored != nil && stored.HistorySynced
+
+	added := 0
+	before := ""
+	for {
+		page, err := s.source.ListMessages(ctx, group.ID, before, pageSize)
+		if err != nil {
+			return added, fmt.Errorf("error listing messages: %w", err)
+		}
+
+		var fresh []*storage.Message
+		for i := range page {
+			_, err := s.store.GetMessage(ctx, page[i].ID)
+			if errors.Is(err, storage.ErrNotFound) {
+				page[i].GroupID = group.ID
+				fresh = append(fresh, &page[i])
+			} else if err != nil {
+				return added, err
+			}
+		}
+		if len(fresh) > 0 {
+			if err := s.store.PutMessages(ctx, fresh); err != nil {
+				return added, fmt.Errorf("error storing messages: %w", err)
+			}
+			added += len(fresh)
+		}
+
+		if len(page) < pageSize {
+			historySynced = true
+			break
+		}
+		// Stop where the previous pass left off
+		if historySynced && len(fresh) < len(page) {
+			break
+		}
+		before = page[len(page)-1].ID
+	}
+
+	group.HistorySynced = historySynced
+	group.SyncedAt = time.Now().UTC()
+	if err := s.store.PutGroup(ctx, &group); err != nil {
+		return added, fmt.Errorf("error storing group: %w", err)
+	}
+	return added, nil
+}
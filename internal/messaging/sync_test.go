@@ -0,0 +1,156 @@
+package messaging
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+
+	"fansly-api/internal/logger"
+	"fansly-api/internal/storage"
+)
+
+// fakeSource serves one group's messages newest first, optionally failing from a page on
+type fakeSource struct {
+	messages []storage.Message // newest first
+	calls    int
+	failPage int // 1-based page that fails, 0 for none
+}
+
+func (f *fakeSource) ListMessageGroups(ctx context.Context) ([]storage.MessageGroup, error) {
+	return []storage.MessageGroup{{ID: "g1", PartnerID: "a1"}}, nil
+}
+
+func (f *fakeSource) ListMessages(ctx context.Context, groupID, before string, limit int) ([]storage.Message, error) {
+	f.calls++
+	start := 0
+	if before != "" {
+		start = slices.IndexFunc(f.messages, func(m storage.Message) bool { return m.ID == before }) + 1
+	}
+	if f.failPage > 0 && start/limit+1 >= f.failPage {
+		return nil, errors.New("upstream error")
+	}
+	end := min(start+limit, len(f.messages))
+	return slices.Clone(f.messages[start:end]), nil
+}
+
+// add prepends n messages newer than every message in the source, m000 being
+// the oldest message ever added
+func (f *fakeSource) add(n int) {
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	for range n {
+		i := len(f.messages)
+		f.messages = append([]storage.Message{{ID: fmt.Sprintf("m%03d", i), Content: "hi", CreatedAt: base.Add(time.Duration(i) * time.Minute)}}, f.messages...)
+	}
+}
+
+func newTestSyncer(source Source) (*Syncer, *storage.MemoryMessageStore) {
+	store := storage.NewMemoryMessageStore()
+	return NewSyncer(source, store, logger.New(), time.Minute), store
+}
+
+// storedCount returns the number of messages stored for the group
+func storedCount(t *testing.T, store *storage.MemoryMessageStore) int {
+	t.Helper()
+	messages, err := store.ListMessages(context.Background(), "g1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return len(messages)
+}
+
+// historySynced reports whether the stored group has its full history
+func historySynced(t *testing.T, store *storage.MemoryMessageStore) bool {
+	t.Helper()
+	group, err := store.GetGroup(context.Background(), "g1")
+	if errors.Is(err, storage.ErrNotFound) {
+		return false
+	} else if err != nil {
+		t.Fatal(err)
+	}
+	return group.HistorySynced
+}
+
+func TestSyncOnceResumesInterruptedHistory(t *testing.T) {
+	ctx := context.Background()
+	source := &fakeSource{failPage: 2}
+	source.add(120)
+	s, store := newTestSyncer(source)
+
+	if err := s.SyncOnce(ctx); err == nil {
+		t.Fatal("expected the interrupted pass to fail")
+	}
+	if n := storedCount(t, store); n != pageSize || historySynced(t, store) {
+		t.Fatalf("interrupted pass: %d messages, history synced %v; want %d, false", n, historySynced(t, store), pageSize)
+	}
+
+	// The next pass pages past the stored messages down to the oldest
+	source.add(3)
+	source.calls, source.failPage = 0, 0
+	if err := s.SyncOnce(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if n := storedCount(t, store); source.calls != 3 || n != 123 || !historySynced(t, store) {
+		t.Fatalf("resumed pass: %d calls, %d messages, history synced %v; want 3, 123, true", source.calls, n, historySynced(t, store))
+	}
+	if m, err := store.GetMessage(ctx, "m000"); err != nil || m.GroupID != "g1" {
+		t.Fatalf("oldest message: %+v, %v", m, err)
+	}
+}
+
+func TestSyncOnceStopsAtStoredPage(t *testing.T) {
+	ctx := context.Background()
+	source := &fakeSource{}
+	source.add(120)
+	s, store := newTestSyncer(source)
+
+	if err := s.SyncOnce(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if source.calls != 3 || !historySynced(t, store) {
+		t.Fatalf("first pass: %d calls, history synced %v; want 3, true", source.calls, historySynced(t, store))
+	}
+
+	// A full page of new messages is followed by the page that reaches stored ones
+	source.add(60)
+	source.calls = 0
+	if err := s.SyncOnce(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if n := storedCount(t, store); source.calls != 2 || n != 180 {
+		t.Fatalf("incremental pass: %d calls, %d messages; want 2, 180", source.calls, n)
+	}
+
+	source.calls = 0
+	if err := s.SyncOnce(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if source.calls != 1 || storedCount(t, store) != 180 {
+		t.Fatalf("unchanged pass: %d calls, %d messages; want 1, 180", source.calls, storedCount(t, store))
+	}
+}
+
+func TestSyncOnceHistorySyncedOnShortPage(t *testing.T) {
+	ctx := context.Background()
+	// Exactly two full pages: only the empty third page shows the history is complete
+	source := &fakeSource{failPage: 3}
+	source.add(2 * pageSize)
+	s, store := newTestSyncer(source)
+
+	if err := s.SyncOnce(ctx); err == nil {
+		t.Fatal("expected the pass to fail on the third page")
+	}
+	if n := storedCount(t, store); n != 2*pageSize || historySynced(t, store) {
+		t.Fatalf("full pages only: %d messages, history synced %v; want %d, false", n, historySynced(t, store), 2*pageSize)
+	}
+
+	source.calls, source.failPage = 0, 0
+	if err := s.SyncOnce(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if source.calls != 3 || !historySynced(t, store) {
+		t.Fatalf("after the short page: %d calls, history synced %v; want 3, true", source.calls, historySynced(t, store))
+	}
+}
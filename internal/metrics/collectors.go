@@ -0,0 +1,85 @@
+package metrics
+
+import (
+	"runtime"
+	"time"
+)
+
+// sizeBuckets are byte-size buckets from 1KB to 1GB
+var sizeBuckets = []float64{1 << 10, 1 << 14, 1 << 17, 1 << 20, 1 << 23, 1 << 26, 1 << 30}
+
+// throughputBuckets are bytes-per-second buckets from 64KB/s to 256MB/s
+var throughputBuckets = []float64{1 << 16, 1 << 18, 1 << 20, 1 << 22, 1 << 24, 1 << 26, 1 << 28}
+
+// HTTP server
+var (
+	HTTPRequests = NewCounter("fansly_api_http_requests_total",
+		"HTTP requests served, by method, route pattern and status code.", "method", "route", "status")
+	HTTPRequestDuration = NewHistogram("fansly_api_http_request_duration_seconds",
+		"HTTP request latency by method and route pattern.", DefBuckets, "method", "route")
+	HTTPRequestsInFlight = NewGauge("fansly_api_http_requests_in_flight",
+		"HTTP requests currently being served.")
+)
+
+// Rate limiting
+var (
+	RateLimitRejected = NewCounter("fansly_api_rate_limit_rejected_total",
+		"Requests rejected by the rate limiter, by route pattern.", "route")
+	RateLimitWait = NewHistogram("fansly_api_rate_limit_wait_seconds",
+		"Time rejected callers are told to wait before retrying (Retry-After).",
+		[]float64{1, 5, 15, 30, 60, 300, 900, 3600}, "route")
+)
+
+// Outbound Fansly API calls
+var (
+	FanslyRequests = NewCounter("fansly_api_upstream_requests_total",
+		"Requests made to the Fansly API, by endpoint and status code (\"error\" for transport failures).", "endpoint", "status")
+	FanslyRequestDuration = NewHistogram("fansly_api_upstream_request_duration_seconds",
+		"Fansly API request latency by endpoint.", DefBuckets, "endpoint")
+)
+
+// Downloads
+var (
+	DownloadBytes = NewCounter("fansly_api_download_bytes_total",
+		"Bytes of media downloaded.")
+	Downloads = NewCounter("fansly_api_downloads_total",
+		"Media downloads by result (\"success\" or \"failure\").", "result")
+	DownloadSize = NewHistogram("fansly_api_download_size_bytes",
+		"Size of completed downloads.", sizeBuckets)
+	DownloadThroughput = NewHistogram("fansly_api_download_throughput_bytes_per_second",
+		"Average throughput of completed downloads.", throughputBuckets)
+)
+
+// Monitoring
+var (
+	MonitorPolls = NewCounter("fansly_api_monitor_polls_total",
+		"Creator monitor polls by outcome (\"new_content\", \"no_change\" or \"error\").", "outcome")
+	MonitorQueueDepth = NewGauge("fansly_api_monitor_queue_depth",
+		"Creator monitor polls waiting to run.")
+)
+
+// Process
+var startTime = time.Now()
+
+var (
+	_ = NewGaugeFunc("process_start_time_seconds",
+		"Start time of the process since the Unix epoch in seconds.",
+		func() float64 { return float64(startTime.UnixNano()) / 1e9 })
+	_ = NewGaugeFunc("go_goroutines",
+		"Number of goroutines that currently exist.",
+		func() float64 { return float64(runtime.NumGoroutine()) })
+)
+
+// ObserveDownload records a finished download of n bytes that took d
+func ObserveDownload(n int64, d time.Duration, err error) {
+	DownloadBytes.Add(float64(n))
+	if err != nil {
+		Downloads.Inc("failure")
+		return
+	}
+	Downloads.Inc("success")
+	DownloadSize.Observe(float64(n))
+	if d > 0 {
+		DownloadThroughput.Observe(float64(n) / d.Seconds())
+	}
+}
@@ -0,0 +1,384 @@
+// Package metrics provides counters, gauges and histograms exposed in the
+// Prometheus text exposition format
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// contentType is the Prometheus text exposition format, version 0.0.4
+const contentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// DefBuckets are latency buckets in seconds suited to HTTP requests
+var DefBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// collector writes one metric family
+type collector interface {
+	name() string
+	write(w *bufio.Writer)
+}
+
+// Registry holds the metrics exposed by a /metrics endpoint
+type Registry struct {
+	mu         sync.Mutex
+	collectors map[string]collector
+}
+
+// newRegistry creates an empty registry
+func newRegistry() *Registry {
+	return &Registry{collectors: make(map[string]collector)}
+}
+
+// Default is the registry the package-level metrics are registered in
+var Default = newRegistry()
+
+func (r *Registry) register(c collector) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.collectors[c.name()]; ok {
+		panic("metrics: duplicate metric " + c.name())
+	}
+	r.collectors[c.name()] = c
+}
+
+// WriteTo writes every metric in the text exposition format, sorted by name
+func (r *Registry) WriteTo(w io.Writer) (int64, error) {
+	r.mu.Lock()
+	collectors := make([]collector, 0, len(r.collectors))
+	for _, c := range r.collectors {
+		collectors = append(collectors, c)
+	}
+	r.mu.Unlock()
+	sort.Slice(collectors, func(i, j int) bool { return collectors[i].name() < collectors[j].name() })
+
+	cw := &countingWriter{w: w}
+	bw := bufio.NewWriter(cw)
+	for _, c := range collectors {
+		c.write(bw)
+	}
+	err := bw.Flush()
+	return cw.n, err
+}
+
+// Handler serves the registry for Prometheus to scrape
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", contentType)
+		r.WriteTo(w)
+	})
+}
+
+// vec stores one value per label combination
+type vec[T any] struct {
+	metricName string
+	help       string
+	labels     []string
+	newValue   func() T
+
+	mu     sync.RWMutex
+	values map[string]T
+}
+
+func newVec[T any](name, help string, labels []string, newValue func() T) *vec[T] {
+	v := &vec[T]{metricName: name, help: help, labels: labels, newValue: newValue, values: make(map[string]T)}
+	if len(labels) == 0 {
+		// Unlabelled metrics have a single series, exposed from the start
+		v.get(nil)
+	}
+	return v
+}
+
+func (v *vec[T]) name() string {
+	return v.metricName
+}
+
+// get returns the value for the label values, creating it on first use
+func (v *vec[T]) get(labelValues []string) T {
+	if len(labelValues) != len(v.labels) {
+		panic(fmt.Sprintf("metrics: %s expects %d label values, got %d", v.metricName, len(v.labels), len(labelValues)))
+	}
+	key := strings.Join(labelValues, "\xff")
+
+	v.mu.RLock()
+	value, ok := v.values[key]
+	v.mu.RUnlock()
+	if ok {
+		return value
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if value, ok := v.values[key]; ok {
+		return value
+	}
+	value = v.newValue()
+	v.values[key] = value
+	return value
+}
+
+// each calls fn with the label values and value of every series, sorted by labels
+func (v *vec[T]) each(fn func(labelValues []string, value T)) {
+	v.mu.RLock()
+	keys := make([]string, 0, len(v.values))
+	for key := range v.values {
+		keys = append(keys, key)
+	}
+	values := make(map[string]T, len(v.values))
+	for key, value := range v.values {
+		values[key] = value
+	}
+	v.mu.RUnlock()
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		var labelValues []string
+		if len(v.labels) > 0 {
+			labelValues = strings.Split(key, "\xff")
+		}
+		fn(labelValues, values[key])
+	}
+}
+
+func (v *vec[T]) writeHeader(w *bufio.Writer, typ string) {
+	fmt.Fprintf(w, "# HELP %s %s\n", v.metricName, escapeHelp(v.help))
+	fmt.Fprintf(w, "# TYPE %s %s\n", v.metricName, typ)
+}
+
+// atomicFloat is a float64 guarded by a mutex
+type atomicFloat struct {
+	mu    sync.Mutex
+	value float64
+}
+
+func (f *atomicFloat) add(delta float64) {
+	f.mu.Lock()
+	f.value += delta
+	f.mu.Unlock()
+}
+
+func (f *atomicFloat) set(value float64) {
+	f.mu.Lock()
+	f.value = value
+	f.mu.Unlock()
+}
+
+func (f *atomicFloat) load() float64 {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.value
+}
+
+// Counter is a monotonically increasing value per label combination
+type Counter struct {
+	*vec[*atomicFloat]
+}
+
+// NewCounter registers a counter in the default registry
+func NewCounter(name, help string, labels ...string) *Counter {
+	c := &Counter{newVec(name, help, labels, func() *atomicFloat { return &atomicFloat{} })}
+	Default.register(c)
+	return c
+}
+
+// Inc adds one to the series with the given label values
+func (c *Counter) Inc(labelValues ...string) {
+	c.get(labelValues).add(1)
+}
+
+// Add adds delta, which must not be negative, to the series with the given label values
+func (c *Counter) Add(delta float64, labelValues ...string) {
+	if delta < 0 {
+		panic("metrics: counter cannot decrease")
+	}
+	c.get(labelValues).add(delta)
+}
+
+func (c *Counter) write(w *bufio.Writer) {
+	c.writeHeader(w, "counter")
+	c.each(func(labelValues []string, value *atomicFloat) {
+		writeSample(w, c.metricName, c.labels, labelValues, "", "", value.load())
+	})
+}
+
+// Gauge is a value that can go up and down per label combination
+type Gauge struct {
+	*vec[*atomicFloat]
+}
+
+// NewGauge registers a gauge in the default registry
+func NewGauge(name, help string, labels ...string) *Gauge {
+	g := &Gauge{newVec(name, help, labels, func() *atomicFloat { return &atomicFloat{} })}
+	Default.register(g)
+	return g
+}
+
+// Set sets the series with the given label values
+func (g *Gauge) Set(value float64, labelValues ...string) {
+	g.get(labelValues).set(value)
+}
+
+// Add adds delta to the series with the given label values
+func (g *Gauge) Add(delta float64, labelValues ...string) {
+	g.get(labelValues).add(delta)
+}
+
+func (g *Gauge) write(w *bufio.Writer) {
+	g.writeHeader(w, "gauge")
+	g.each(func(labelValues []string, value *atomicFloat) {
+		writeSample(w, g.metricName, g.labels, labelValues, "", "", value.load())
+	})
+}
+
+// GaugeFunc is an unlabelled gauge whose value is read when the registry is scraped
+type GaugeFunc struct {
+	metricName string
+	help       string
+	fn         func() float64
+}
+
+// NewGaugeFunc registers a gauge function in the default registry
+func NewGaugeFunc(name, help string, fn func() float64) *GaugeFunc {
+	g := &GaugeFunc{metricName: name, help: help, fn: fn}
+	Default.register(g)
+	return g
+}
+
+func (g *GaugeFunc) name() string {
+	return g.metricName
+}
+
+func (g *GaugeFunc) write(w *bufio.Writer) {
+	fmt.Fprintf(w, "# HELP %s %s\n", g.metricName, escapeHelp(g.help))
+	fmt.Fprintf(w, "# TYPE %s gauge\n", g.metricName)
+	writeSample(w, g.metricName, nil, nil, "", "", g.fn())
+}
+
+// histogramValue holds the bucket counts of one histogram series
+type histogramValue struct {
+	mu     sync.Mutex
+	counts []uint64 // per bucket, not cumulative
+	count  uint64
+	sum    float64
+}
+
+// Histogram counts observations in configurable buckets per label combination
+type Histogram struct {
+	*vec[*histogramValue]
+	buckets []float64
+}
+
+// NewHistogram registers a histogram in the default registry. Buckets must be sorted
+// in increasing order; DefBuckets is used when none are given.
+func NewHistogram(name, help string, buckets []float64, labels ...string) *Histogram {
+	if len(buckets) == 0 {
+		buckets = DefBuckets
+	}
+	h := &Histogram{buckets: buckets}
+	h.vec = newVec(name, help, labels, func() *histogramValue {
+		return &histogramValue{counts: make([]uint64, len(buckets))}
+	})
+	Default.register(h)
+	return h
+}
+
+// Observe records a value in the series with the given label values
+func (h *Histogram) Observe(value float64, labelValues ...string) {
+	hv := h.get(labelValues)
+	i := sort.SearchFloat64s(h.buckets, value)
+
+	hv.mu.Lock()
+	if i < len(hv.counts) {
+		hv.counts[i]++
+	}
+	hv.count++
+	hv.sum += value
+	hv.mu.Unlock()
+}
+
+func (h *Histogram) write(w *bufio.Writer) {
+	h.writeHeader(w, "histogram")
+	h.each(func(labelValues []string, hv *histogramValue) {
+		hv.mu.Lock()
+		counts := append([]uint64(nil), hv.counts...)
+		count, sum := hv.count, hv.sum
+		hv.mu.Unlock()
+
+		var cumulative uint64
+		for i, upper := range h.buckets {
+			cumulative += counts[i]
+			writeSample(w, h.metricName+"_bucket", h.labels, labelValues, "le", formatFloat(upper), float64(cumulative))
+		}
+		writeSample(w, h.metricName+"_bucket", h.labels, labelValues, "le", "+Inf", float64(count))
+		writeSample(w, h.metricName+"_sum", h.labels, labelValues, "", "", sum)
+		writeSample(w, h.metricName+"_count", h.labels, labelValues, "", "", float64(count))
+	})
+}
+
+// writeSample writes one sample line, with an optional extra label such as "le"
+func writeSample(w *bufio.Writer, name string, labels, labelValues []string, extraLabel, extraValue string, value float64) {
+	w.WriteString(name)
+	if len(labels) > 0 || extraLabel != "" {
+		w.WriteByte('{')
+		for i, label := range labels {
+			if i > 0 {
+				w.WriteByte(',')
+			}
+			fmt.Fprintf(w, "%s=\"%s\"", label, escapeLabel(labelValues[i]))
+		}
+		if extraLabel != "" {
+			if len(labels) > 0 {
+				w.WriteByte(',')
+			}
+			fmt.Fprintf(w, "%s=\"%s\"", extraLabel, extraValue)
+		}
+		w.WriteByte('}')
+	}
+	w.WriteByte(' ')
+	w.WriteString(formatFloat(value))
+	w.WriteByte('\n')
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+var (
+	labelEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+	helpEscaper  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+)
+
+func escapeLabel(s string) string {
+	return labelEscaper.Replace(s)
+}
+
+func escapeHelp(s string) string {
+	return helpEscaper.Replace(s)
+}
+
+// countingWriter counts the bytes written for WriteTo
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,77 @@
+package metrics
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRegistryTextFormat(t *testing.T) {
+	requests := NewCounter("test_requests_total", "Requests served.\nBy route.", "route", "status")
+	inFlight := NewGauge("test_in_flight", "Requests in flight.")
+	duration := NewHistogram("test_duration_seconds", "Request latency.", []float64{0.25, 1}, "route")
+
+	requests.Inc(`/a"b`, "200")
+	requests.Add(2, "/c", "500")
+	inFlight.Set(3)
+	duration.Observe(0.25, "/a") // bucket bounds are inclusive
+	duration.Observe(0.5, "/a")
+	duration.Observe(5, "/a")
+
+	rec := httptest.NewRecorder()
+	Default.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	if ct := rec.Header().Get("Content-Type"); ct != "text/plain; version=0.0.4; charset=utf-8" {
+		t.Errorf("Content-Type %q", ct)
+	}
+
+	// Keep only the families registered above; the package-level metrics are in Default too
+	var got []string
+	for _, line := range strings.Split(rec.Body.String(), "\n") {
+		if strings.HasPrefix(line, "test_") || strings.HasPrefix(line, "# HELP test_") || strings.HasPrefix(line, "# TYPE test_") {
+			got = append(got, line)
+		}
+	}
+	want := []string{
+		`# HELP test_duration_seconds Request latency.`,
+		`# TYPE test_duration_seconds histogram`,
+		`test_duration_seconds_bucket{route="/a",le="0.25"} 1`,
+		`test_duration_seconds_bucket{route="/a",le="1"} 2`,
+		`test_duration_seconds_bucket{route="/a",le="+Inf"} 3`,
+		`test_duration_seconds_sum{route="/a"} 5.75`,
+		`test_duration_seconds_count{route="/a"} 3`,
+		`# HELP test_in_flight Requests in flight.`,
+		`# TYPE test_in_flight gauge`,
+		`test_in_flight 3`,
+		`# HELP test_requests_total Requests served.\nBy route.`,
+		`# TYPE test_requests_total counter`,
+		`test_requests_total{route="/a\"b",status="200"} 1`,
+		`test_requests_total{route="/c",status="500"} 2`,
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("exposition:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestHistogramBuckets(t *testing.T) {
+	h := NewHistogram("test_default_buckets_seconds", "Default buckets.", nil)
+	h.Observe(0.003)
+	h.Observe(20)
+
+	var b strings.Builder
+	Default.WriteTo(&b)
+	var buckets []string
+	for _, line := range strings.Split(b.String(), "\n") {
+		if strings.HasPrefix(line, "test_default_buckets_seconds_bucket") {
+			buckets = append(buckets, line)
+		}
+	}
+	if len(buckets) != len(DefBuckets)+1 {
+		t.Fatalf("%d buckets, want %d", len(buckets), len(DefBuckets)+1)
+	}
+	if buckets[0] != `test_default_buckets_seconds_bucket{le="0.005"} 1` {
+		t.Errorf("first bucket %q", buckets[0])
+	}
+	if last := buckets[len(buckets)-1]; last != `test_default_buckets_seconds_bucket{le="+Inf"} 2` {
+		t.Errorf("+Inf bucket %q", last)
+	}
+}
@@ -0,0 +1,51 @@
+package metrics
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// transport records request counts and latencies of an HTTP client
+type transport struct {
+	base     http.RoundTripper
+	requests *Counter
+	duration *Histogram
+}
+
+// endpointKey is the context key of the endpoint label set with WithEndpoint
+type endpointKey struct{}
+
+// WithEndpoint returns a context whose requests are labelled with endpoint, a path
+// template such as "/account/{id}/followers", instead of their URL path
+func WithEndpoint(ctx context.Context, endpoint string) context.Context {
+	return context.WithValue(ctx, endpointKey{}, endpoint)
+}
+
+// InstrumentTransport wraps base so every request is counted in requests (labelled by
+// endpoint and status) and timed in duration (labelled by endpoint). The endpoint is
+// the URL path, so requests with IDs in the path must set a template with WithEndpoint.
+func InstrumentTransport(base http.RoundTripper, requests *Counter, duration *Histogram) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &transport{base: base, requests: requests, duration: duration}
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	resp, err := t.base.RoundTrip(req)
+
+	endpoint := req.URL.Path
+	if template, ok := req.Context().Value(endpointKey{}).(string); ok {
+		endpoint = template
+	}
+	status := "error"
+	if err == nil {
+		status = strconv.Itoa(resp.StatusCode)
+	}
+	t.requests.Inc(endpoint, status)
+	t.duration.Observe(time.Since(start).Seconds(), endpoint)
+	return resp, err
+}
@@ -0,0 +1,49 @@
+package metrics
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestInstrumentTransportEndpoint(t *testing.T) {
+	requests := NewCounter("test_upstream_requests_total", "Upstream requests.", "endpoint", "status")
+	duration := NewHistogram("test_upstream_request_duration_seconds", "Upstream latency.", nil, "endpoint")
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer upstream.Close()
+	client := &http.Client{Transport: InstrumentTransport(nil, requests, duration)}
+
+	get := func(ctx context.Context, path string) {
+		req, err := http.NewRequestWithContext(ctx, "GET", upstream.URL+path, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+	}
+	get(context.Background(), "/account/me")
+	for _, id := range []string{"111", "222"} {
+		get(WithEndpoint(context.Background(), "/account/{id}/followers"), "/account/"+id+"/followers")
+	}
+
+	var b strings.Builder
+	Default.WriteTo(&b)
+	out := b.String()
+	for _, want := range []string{
+		`test_upstream_requests_total{endpoint="/account/me",status="200"} 1`,
+		`test_upstream_requests_total{endpoint="/account/{id}/followers",status="200"} 2`,
+		`test_upstream_request_duration_seconds_count{endpoint="/account/{id}/followers"} 2`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %s", want)
+		}
+	}
+	if strings.Contains(out, "/account/111") {
+		t.Errorf("account ID used as a label value")
+	}
+}
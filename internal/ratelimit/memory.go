@@ -0,0 +1,69 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type windowCounter struct {
+	start  time.Time // start of the current fixed window
+	window time.Duration
+	prev   int
+	curr   int
+}
+
+// MemoryStore keeps sliding window counters in memory
+type MemoryStore struct {
+	mu        sync.Mutex
+	counters  map[string]*windowCounter
+	lastSweep time.Time
+}
+
+// NewMemoryStore creates an empty in-memory store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{counters: make(map[string]*windowCounter)}
+}
+
+// Allow records a request for key if it is within the limit
+func (s *MemoryStore) Allow(ctx context.Context, key string, limit Limit, now time.Time) (Result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sweep(now, limit.Window)
+
+	start := now.Truncate(limit.Window)
+	c, ok := s.counters[key]
+	if !ok || c.window != limit.Window {
+		c = &windowCounter{start: start, window: limit.Window}
+		s.counters[key] = c
+	}
+
+	// Roll the windows forward
+	switch {
+	case start.Equal(c.start):
+	case start.Sub(c.start) == limit.Window:
+		c.prev, c.curr, c.start = c.curr, 0, start
+	default:
+		c.prev, c.curr, c.start = 0, 0, start
+	}
+
+	res := slidingWindow(c.prev, c.curr, now.Sub(start), limit)
+	if res.Allowed {
+		c.curr++
+	}
+	return res, nil
+}
+
+// sweep drops counters that no longer affect any decision. Runs at most once per window.
+func (s *MemoryStore) sweep(now time.Time, window time.Duration) {
+	if now.Sub(s.lastSweep) < window {
+		return
+	}
+	s.lastSweep = now
+	for key, c := range s.counters {
+		if now.Sub(c.start) >= 2*c.window {
+			delete(s.counters, key)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+// Package ratelimit provides sliding-window request limiting with pluggable storage
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Limit allows Requests requests per Window
+type Limit struct {
+	Requests int
+	Window   time.Duration
+}
+
+// String formats the limit as "<requests>/<window>", the format accepted by ParseLimit
+func (l Limit) String() string {
+	return fmt.Sprintf("%d/%s", l.Requests, l.Window)
+}
+
+// ParseLimit parses a limit such as "100/1m"
+func ParseLimit(s string) (Limit, error) {
+	requests, window, ok := strings.Cut(strings.TrimSpace(s), "/")
+	if !ok {
+		return Limit{}, fmt.Errorf("invalid rate limit %q: expected <requests>/<window>", s)
+	}
+	n, err := strconv.Atoi(requests)
+	if err != nil || n <= 0 {
+		return Limit{}, fmt.Errorf("invalid rate limit %q: requests must be a positive integer", s)
+	}
+	d, err := time.ParseDuration(window)
+	if err != nil || d <= 0 {
+		return Limit{}, fmt.Errorf("invalid rate limit %q: window must be a positive duration", s)
+	}
+	return Limit{Requests: n, Window: d}, nil
+}
+
+// Result is the outcome of a rate limit check
+type Result struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	ResetAfter time.Duration // until the current window ends
+	RetryAfter time.Duration // until the next request would be allowed, zero when allowed
+}
+
+// Store counts requests per key. Implementations must be safe for concurrent use.
+//
+// The sliding window counter used by MemoryStore only needs two counters per key
+// (the current and previous fixed windows), so a Redis backend can implement Allow
+// with INCR and PEXPIRE on "<key>:<window start>" in a single script.
+type Store interface {
+	Allow(ctx context.Context, key string, limit Limit, now time.Time) (Result, error)
+}
+
+// slidingWindow estimates the request count over the last window from the
+// previous and current fixed window counters
+func slidingWindow(prev, curr int, elapsed time.Duration, limit Limit) Result {
+	weight := 1 - float64(elapsed)/float64(limit.Window)
+	estimate := float64(prev)*weight + float64(curr)
+	res := Result{
+		Limit:      limit.Requests,
+		ResetAfter: limit.Window - elapsed,
+	}
+
+	if estimate+1 <= float64(limit.Requests) {
+		res.Allowed = true
+		res.Remaining = int(float64(limit.Requests) - estimate - 1)
+		return res
+	}
+
+	// Wait until the previous window's share has decayed enough, or for the next
+	// window if the current one alone is full
+	if curr+1 > limit.Requests || prev == 0 {
+		res.RetryAfter = res.ResetAfter
+		return res
+	}
+	needed := 1 - float64(limit.Requests-curr-1)/float64(prev)
+	res.RetryAfter = time.Duration(needed*float64(limit.Window)) - elapsed
+	if res.RetryAfter <= 0 {
+		res.RetryAfter = time.Millisecond
+	}
+	return res
+}
@@ -0,0 +1,150 @@
+// Package search is an in-memory full-text index over synced post content.
+//
+// Post text, hashtags and media captions are tokenized into an inverted index
+// that supports term and phrase queries, ranked with BM25. The index is kept
+// up to date by calling Upsert and Delete as posts are synced.
+package search
+
+import (
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+// Document is a post as indexed for search
+type Document struct {
+	ID        string
+	CreatorID string
+	Text      string
+	Hashtags  []string
+	Captions  []string
+	CreatedAt time.Time
+}
+
+// Indexed fields and their weight in ranking. Hashtags are deliberate labels, so
+// a match there counts more than one in running text.
+const (
+	fieldText = iota
+	fieldHashtags
+	fieldCaptions
+	numFields
+)
+
+var fieldWeights = [numFields]float64{fieldText: 1, fieldHashtags: 2, fieldCaptions: 1}
+
+type indexedDoc struct {
+	doc    Document
+	fields [numFields][]string // tokens of each field, in order
+	length int                 // total number of tokens
+}
+
+// Index is a full-text index safe for concurrent use
+type Index struct {
+	mu          sync.RWMutex
+	docs        map[string]*indexedDoc
+	postings    map[string]map[string]struct{} // term -> IDs of documents containing it
+	totalLength int
+}
+
+// NewIndex creates an empty index
+func NewIndex() *Index {
+	return &Index{
+		docs:     make(map[string]*indexedDoc),
+		postings: make(map[string]map[string]struct{}),
+	}
+}
+
+// Len returns the number of indexed documents
+func (idx *Index) Len() int {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	return len(idx.docs)
+}
+
+// Upsert adds a document, replacing any document with the same ID
+func (idx *Index) Upsert(doc Document) {
+	d := &indexedDoc{doc: doc}
+	d.fields[fieldText] = tokenize(doc.Text)
+	d.fields[fieldHashtags] = tokenize(strings.Join(doc.Hashtags, " "))
+	d.fields[fieldCaptions] = tokenize(strings.Join(doc.Captions, " "))
+	for _, tokens := range d.fields {
+		d.length += len(tokens)
+	}
+
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	idx.remove(doc.ID)
+	idx.docs[doc.ID] = d
+	idx.totalLength += d.length
+	for _, tokens := range d.fields {
+		for _, term := range tokens {
+			ids, ok := idx.postings[term]
+			if !ok {
+				ids = make(map[string]struct{})
+				idx.postings[term] = ids
+			}
+			ids[doc.ID] = struct{}{}
+		}
+	}
+}
+
+// Delete removes a document. Deleting an unknown ID does nothing.
+func (idx *Index) Delete(id string) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	idx.remove(id)
+}
+
+func (idx *Index) remove(id string) {
+	d, ok := idx.docs[id]
+	if !ok {
+		return
+	}
+	for _, tokens := range d.fields {
+		for _, term := range tokens {
+			if ids, ok := idx.postings[term]; ok {
+				delete(ids, id)
+				if len(ids) == 0 {
+					delete(idx.postings, term)
+				}
+			}
+		}
+	}
+	idx.totalLength -= d.length
+	delete(idx.docs, id)
+}
+
+// token is a normalized term and its byte range in the original text
+type token struct {
+	term       string
+	start, end int
+}
+
+// tokenize splits s into lower-case terms of letters and digits
+func tokenize(s string) []string {
+	spans := tokenSpans(s)
+	terms := make([]string, len(spans))
+	for i, t := range spans {
+		terms[i] = t.term
+	}
+	return terms
+}
+
+func tokenSpans(s string) []token {
+	var tokens []token
+	start := -1
+	for i, r := range s {
+		inWord := unicode.IsLetter(r) || unicode.IsDigit(r)
+		if inWord && start < 0 {
+			start = i
+		} else if !inWord && start >= 0 {
+			tokens = append(tokens, token{term: strings.ToLower(s[start:i]), start: start, end: i})
+			start = -1
+		}
+	}
+	if start >= 0 {
+		tokens = append(tokens, token{term: strings.ToLower(s[start:]), start: start, end: len(s)})
+	}
+	return tokens
+}
@@ -0,0 +1,223 @@
+package search
+
+import (
+	"errors"
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ErrEmptyQuery is returned when a query contains no searchable terms
+var ErrEmptyQuery = errors.New("query contains no searchable terms")
+
+// BM25 parameters
+const (
+	bm25K1 = 1.2
+	bm25B  = 0.75
+)
+
+// Query is a full-text search. Text holds terms and "quoted phrases", all of
+// which must match; a leading # on a term is ignored.
+type Query struct {
+	Text       string
+	CreatorIDs []string  // restrict to these creators; empty matches all
+	From, To   time.Time // restrict to posts created in [From, To); zero is unbounded
+	Limit      int
+	Offset     int
+}
+
+// Hit is a matching document
+type Hit struct {
+	Document
+	Score float64
+	// Snippet is an HTML-escaped excerpt with matching terms wrapped in <mark>
+	Snippet string
+}
+
+// Results are the hits of one page of a search
+type Results struct {
+	Total int
+	Hits  []Hit
+}
+
+// parseQuery splits the query text into clauses, each a single term or a phrase
+func parseQuery(text string) [][]string {
+	var clauses [][]string
+	for i, part := range strings.Split(text, `"`) {
+		if i%2 == 1 {
+			// Inside quotes
+			if phrase := tokenize(part); len(phrase) > 0 {
+				clauses = append(clauses, phrase)
+			}
+			continue
+		}
+		for _, term := range tokenize(part) {
+			clauses = append(clauses, []string{term})
+		}
+	}
+	return clauses
+}
+
+// Search returns the documents matching every clause of the query, best first
+func (idx *Index) Search(q Query) (*Results, error) {
+	clauses := parseQuery(q.Text)
+	if len(clauses) == 0 {
+		return nil, ErrEmptyQuery
+	}
+	terms := uniqueTerms(clauses)
+
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	creators := make(map[string]bool, len(q.CreatorIDs))
+	for _, id := range q.CreatorIDs {
+		creators[id] = true
+	}
+
+	var hits []Hit
+	for id := range idx.candidates(terms) {
+		d := idx.docs[id]
+		if len(creators) > 0 && !creators[d.doc.CreatorID] {
+			continue
+		}
+		if !q.From.IsZero() && d.doc.CreatedAt.Before(q.From) {
+			continue
+		}
+		if !q.To.IsZero() && !d.doc.CreatedAt.Before(q.To) {
+			continue
+		}
+		if !matchesPhrases(d, clauses) {
+			continue
+		}
+		hits = append(hits, Hit{Document: d.doc, Score: idx.score(d, terms)})
+	}
+
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		if !hits[i].CreatedAt.Equal(hits[j].CreatedAt) {
+			return hits[i].CreatedAt.After(hits[j].CreatedAt)
+		}
+		return hits[i].ID < hits[j].ID
+	})
+
+	results := &Results{Total: len(hits)}
+	start, end := q.Offset, q.Offset+q.Limit
+	if start > len(hits) {
+		start = len(hits)
+	}
+	if end > len(hits) || q.Limit <= 0 {
+		end = len(hits)
+	}
+	results.Hits = hits[start:end]
+	for i := range results.Hits {
+		results.Hits[i].Snippet = snippet(results.Hits[i].Document, terms)
+	}
+	return results, nil
+}
+
+// candidates returns the IDs of documents containing every term
+func (idx *Index) candidates(terms []string) map[string]struct{} {
+	lists := make([]map[string]struct{}, len(terms))
+	for i, term := range terms {
+		lists[i] = idx.postings[term]
+		if len(lists[i]) == 0 {
+			return nil
+		}
+	}
+	sort.Slice(lists, func(i, j int) bool { return len(lists[i]) < len(lists[j]) })
+
+	result := make(map[string]struct{}, len(lists[0]))
+	for id := range lists[0] {
+		inAll := true
+		for _, ids := range lists[1:] {
+			if _, ok := ids[id]; !ok {
+				inAll = false
+				break
+			}
+		}
+		if inAll {
+			result[id] = struct{}{}
+		}
+	}
+	return result
+}
+
+// score ranks a document against the query terms with BM25, counting each
+// occurrence by the weight of its field
+func (idx *Index) score(d *indexedDoc, terms []string) float64 {
+	n := float64(len(idx.docs))
+	avgLength := float64(idx.totalLength) / n
+	if avgLength == 0 {
+		avgLength = 1
+	}
+
+	var score float64
+	for _, term := range terms {
+		var tf float64
+		for field, tokens := range d.fields {
+			for _, t := range tokens {
+				if t == term {
+					tf += fieldWeights[field]
+				}
+			}
+		}
+		df := float64(len(idx.postings[term]))
+		idf := math.Log(1 + (n-df+0.5)/(df+0.5))
+		norm := tf + bm25K1*(1-bm25B+bm25B*float64(d.length)/avgLength)
+		score += idf * tf * (bm25K1 + 1) / norm
+	}
+	return score
+}
+
+// matchesPhrases reports whether every multi-term clause occurs, in order, within one field
+func matchesPhrases(d *indexedDoc, clauses [][]string) bool {
+	for _, clause := range clauses {
+		if len(clause) < 2 {
+			continue
+		}
+		found := false
+		for _, tokens := range d.fields {
+			if containsPhrase(tokens, clause) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+func containsPhrase(tokens, phrase []string) bool {
+	for i := 0; i+len(phrase) <= len(tokens); i++ {
+		match := true
+		for j, term := range phrase {
+			if tokens[i+j] != term {
+				match = false
+				break
+			}
+		}
+		if match {
+			return true
+		}
+	}
+	return false
+}
+
+func uniqueTerms(clauses [][]string) []string {
+	seen := make(map[string]bool)
+	var terms []string
+	for _, clause := range clauses {
+		for _, term := range clause {
+			if !seen[term] {
+				seen[term] = true
+				terms = append(terms, term)
+			}
+		}
+	}
+	return terms
+}
@@ -0,0 +1,176 @@
+package search
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+var day = time.Date(2026, 5, 1, 0, 0, 0, 0, time.UTC)
+
+// search returns the IDs of the hits for q, best first
+func search(t *testing.T, idx *Index, q Query) []string {
+	t.Helper()
+	results, err := idx.Search(q)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ids := make([]string, len(results.Hits))
+	for i, hit := range results.Hits {
+		ids[i] = hit.ID
+	}
+	return ids
+}
+
+func TestSearchRanking(t *testing.T) {
+	idx := NewIndex()
+	for _, doc := range []Document{
+		// Same length, different term frequency and field
+		{ID: "often", Text: "beach beach beach", CreatedAt: day},
+		{ID: "hashtag", Text: "sunset shot", Hashtags: []string{"beach"}, CreatedAt: day},
+		{ID: "once", Text: "beach photo", Hashtags: []string{"sunset"}, CreatedAt: day},
+		// A single occurrence in a long post
+		{ID: "long", Text: "a long walk along the beach before the sun went down over the water", CreatedAt: day},
+		{ID: "other", Text: "mountain photo", CreatedAt: day},
+	} {
+		idx.Upsert(doc)
+	}
+
+	if got, want := search(t, idx, Query{Text: "beach"}), []string{"often", "hashtag", "once", "long"}; !slices.Equal(got, want) {
+		t.Errorf("beach: %v, want %v", got, want)
+	}
+	// Every term must match
+	if got, want := search(t, idx, Query{Text: "beach photo"}), []string{"once"}; !slices.Equal(got, want) {
+		t.Errorf("beach photo: %v, want %v", got, want)
+	}
+	// A leading # and case are ignored; a hashtag outranks the same word in text
+	if got, want := search(t, idx, Query{Text: "#SUNSET"}), []string{"once", "hashtag"}; !slices.Equal(got, want) {
+		t.Errorf("#SUNSET: %v, want %v", got, want)
+	}
+	if _, err := idx.Search(Query{Text: `"" !?`}); err != ErrEmptyQuery {
+		t.Errorf("query without terms: error %v, want ErrEmptyQuery", err)
+	}
+}
+
+func TestSearchTiesAndPaging(t *testing.T) {
+	idx := NewIndex()
+	idx.Upsert(Document{ID: "b", Text: "beach", CreatedAt: day})
+	idx.Upsert(Document{ID: "a", Text: "beach", CreatedAt: day})
+	idx.Upsert(Document{ID: "newer", Text: "beach", CreatedAt: day.Add(time.Hour)})
+
+	// Equal scores rank newer posts first, then by ID
+	if got, want := search(t, idx, Query{Text: "beach"}), []string{"newer", "a", "b"}; !slices.Equal(got, want) {
+		t.Errorf("ties: %v, want %v", got, want)
+	}
+	results, err := idx.Search(Query{Text: "beach", Limit: 2, Offset: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if results.Total != 3 || len(results.Hits) != 2 || results.Hits[0].ID != "a" {
+		t.Errorf("page: total %d, hits %+v", results.Total, results.Hits)
+	}
+	if got := search(t, idx, Query{Text: "beach", Offset: 5}); len(got) != 0 {
+		t.Errorf("offset past the end: %v", got)
+	}
+}
+
+func TestSearchPhrases(t *testing.T) {
+	idx := NewIndex()
+	idx.Upsert(Document{ID: "phrase", Text: "Golden hour at the beach", CreatedAt: day})
+	idx.Upsert(Document{ID: "apart", Text: "an hour of golden light", CreatedAt: day})
+	// The phrase must occur within one field
+	idx.Upsert(Document{ID: "fields", Text: "golden", Captions: []string{"hour"}, CreatedAt: day})
+
+	if got, want := search(t, idx, Query{Text: `"golden hour"`}), []string{"phrase"}; !slices.Equal(got, want) {
+		t.Errorf("phrase: %v, want %v", got, want)
+	}
+	if got := search(t, idx, Query{Text: "golden hour"}); len(got) != 3 {
+		t.Errorf("terms: %v, want all three posts", got)
+	}
+	if got := search(t, idx, Query{Text: `"hour golden"`}); len(got) != 0 {
+		t.Errorf("reversed phrase: %v", got)
+	}
+	if got, want := search(t, idx, Query{Text: `beach "golden hour"`}), []string{"phrase"}; !slices.Equal(got, want) {
+		t.Errorf("term and phrase: %v, want %v", got, want)
+	}
+}
+
+func TestSearchFilters(t *testing.T) {
+	idx := NewIndex()
+	idx.Upsert(Document{ID: "c1-early", CreatorID: "c1", Text: "beach", CreatedAt: day})
+	idx.Upsert(Document{ID: "c1-late", CreatorID: "c1", Text: "beach", CreatedAt: day.AddDate(0, 0, 2)})
+	idx.Upsert(Document{ID: "c2", CreatorID: "c2", Text: "beach", CreatedAt: day.AddDate(0, 0, 1)})
+
+	if got, want := search(t, idx, Query{Text: "beach", CreatorIDs: []string{"c1"}}), []string{"c1-late", "c1-early"}; !slices.Equal(got, want) {
+		t.Errorf("creator: %v, want %v", got, want)
+	}
+	// To is exclusive
+	if got, want := search(t, idx, Query{Text: "beach", From: day.AddDate(0, 0, 1), To: day.AddDate(0, 0, 2)}), []string{"c2"}; !slices.Equal(got, want) {
+		t.Errorf("date range: %v, want %v", got, want)
+	}
+}
+
+func TestIndexDelete(t *testing.T) {
+	idx := NewIndex()
+	idx.Upsert(Document{ID: "p1", Text: "beach day", Hashtags: []string{"summer"}, CreatedAt: day})
+	idx.Upsert(Document{ID: "p2", Text: "beach house", CreatedAt: day})
+
+	// Replacing a post drops the terms it no longer contains
+	idx.Upsert(Document{ID: "p1", Text: "snow day", CreatedAt: day})
+	if got, want := search(t, idx, Query{Text: "beach"}), []string{"p2"}; !slices.Equal(got, want) {
+		t.Errorf("after update: %v, want %v", got, want)
+	}
+	if got := search(t, idx, Query{Text: "summer"}); len(got) != 0 {
+		t.Errorf("removed hashtag still matches: %v", got)
+	}
+
+	idx.Delete("p2")
+	idx.Delete("unknown")
+	if got := search(t, idx, Query{Text: "beach"}); len(got) != 0 {
+		t.Errorf("after delete: %v", got)
+	}
+	if idx.Len() != 1 {
+		t.Errorf("Len %d, want 1", idx.Len())
+	}
+	idx.Delete("p1")
+	if len(idx.postings) != 0 || idx.totalLength != 0 {
+		t.Errorf("empty index keeps postings %v and length %d", idx.postings, idx.totalLength)
+	}
+}
+
+func TestSnippet(t *testing.T) {
+	words := make([]string, 60)
+	for i := range words {
+		words[i] = fmt.Sprintf("café%d", i)
+	}
+	words[20] = "Ñandú"
+	text := strings.Join(words, " · ")
+
+	// The window is snippetBefore tokens before the first match and runs
+	// snippetAfter tokens from it, cut at token boundaries
+	want := "…" + strings.Join(words[12:20], " · ") + " · <mark>Ñandú</mark> · " + strings.Join(words[21:44], " · ") + "…"
+	got := snippet(Document{Text: text}, []string{"ñandú"})
+	if got != want {
+		t.Errorf("snippet\n got %q\nwant %q", got, want)
+	}
+	if !utf8.ValidString(got) {
+		t.Errorf("snippet isn't valid UTF-8: %q", got)
+	}
+
+	// Text before the first token and after the last is kept when the window
+	// reaches the ends
+	if got, want := snippet(Document{Text: "¡Ñandú & café!"}, []string{"ñandú", "café"}), "¡<mark>Ñandú</mark> &amp; <mark>café</mark>!"; got != want {
+		t.Errorf("short text: %q, want %q", got, want)
+	}
+	// Captions and then hashtags are used when the text doesn't match
+	doc := Document{Text: "nothing here", Captions: []string{"no match"}, Hashtags: []string{"été", "plage"}}
+	if got, want := snippet(doc, []string{"plage"}), "#été #<mark>plage</mark>"; got != want {
+		t.Errorf("hashtags: %q, want %q", got, want)
+	}
+	if got := snippet(Document{Text: "nothing here"}, []string{"plage"}); got != "" {
+		t.Errorf("no match: %q", got)
+	}
+}
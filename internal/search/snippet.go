@@ -0,0 +1,84 @@
+package search
+
+import (
+	"html"
+	"strings"
+)
+
+// Snippet window, in tokens, around the first match
+const (
+	snippetBefore = 8
+	snippetAfter  = 24
+)
+
+// snippet returns an HTML-escaped excerpt of the first field that matches one of
+// terms, with the matching terms wrapped in <mark>
+func snippet(doc Document, terms []string) string {
+	wanted := make(map[string]bool, len(terms))
+	for _, term := range terms {
+		wanted[term] = true
+	}
+
+	sources := append([]string{doc.Text}, doc.Captions...)
+	if len(doc.Hashtags) > 0 {
+		sources = append(sources, "#"+strings.Join(doc.Hashtags, " #"))
+	}
+	for _, text := range sources {
+		if s, ok := highlight(text, wanted); ok {
+			return s
+		}
+	}
+	return ""
+}
+
+// highlight excerpts text around its first wanted term and marks every wanted term
+// in the excerpt. It reports false when text contains no wanted term.
+func highlight(text string, wanted map[string]bool) (string, bool) {
+	tokens := tokenSpans(text)
+	first := -1
+	for i, t := range tokens {
+		if wanted[t.term] {
+			first = i
+			break
+		}
+	}
+	if first < 0 {
+		return "", false
+	}
+
+	from, to := first-snippetBefore, first+snippetAfter
+	if from < 0 {
+		from = 0
+	}
+	if to > len(tokens) {
+		to = len(tokens)
+	}
+	start, end := tokens[from].start, tokens[to-1].end
+	if from == 0 {
+		start = 0
+	}
+	if to == len(tokens) {
+		end = len(text)
+	}
+
+	var b strings.Builder
+	if start > 0 {
+		b.WriteString("…")
+	}
+	pos := start
+	for _, t := range tokens[from:to] {
+		if !wanted[t.term] {
+			continue
+		}
+		b.WriteString(html.EscapeString(text[pos:t.start]))
+		b.WriteString("<mark>")
+		b.WriteString(html.EscapeString(text[t.start:t.end]))
+		b.WriteString("</mark>")
+		pos = t.end
+	}
+	b.WriteString(html.EscapeString(text[pos:end]))
+	if end < len(text) {
+		b.WriteString("…")
+	}
+	return strings.TrimSpace(b.String()), true
+}
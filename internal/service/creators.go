@@ -0,0 +1,218 @@
+package service
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Creator sort fields
+const (
+	SortByName        = "name"
+	SortByLastUpdated = "last_updated"
+)
+
+// ErrInvalidCursor is returned when a cursor can't be decoded or was issued for a
+// different sort order
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// CreatorQuery selects, orders and pages creators. When Cursor is set, Offset is
+// ignored and the page starts next to the cursor position.
+type CreatorQuery struct {
+	Limit  int
+	Offset int
+	Cursor string
+	Sort   string // SortByName (default) or SortByLastUpdated
+	Order  string // "asc" (default) or "desc"
+
+	// Filters; nil matches every creator
+	Verified   *bool
+	Following  *bool
+	Subscribed *bool
+}
+
+// CreatorPage is one page of creators
+type CreatorPage struct {
+	Creators []Creator
+	// Total is the number of creators matching the filters, across all pages
+	Total int
+	// Offset is the position of the first creator of the page
+	Offset int
+	// NextCursor and PrevCursor are empty at the ends of the listing
+	NextCursor string
+	PrevCursor string
+}
+
+// creatorCursor is the decoded form of an opaque cursor. It records the sort key
+// and ID of the creator next to which the page starts, so paging stays stable
+// when creators are added or removed between requests.
+type creatorCursor struct {
+	Sort   string    `json:"s"`
+	Order  string    `json:"o"`
+	Before bool      `json:"b,omitempty"`
+	ID     string    `json:"id"`
+	Name   string    `json:"n,omitempty"`
+	Time   time.Time `json:"t,omitempty"`
+}
+
+func encodeCursor(c creatorCursor) string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCursor(s string) (creatorCursor, error) {
+	var c creatorCursor
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return c, ErrInvalidCursor
+	}
+	if err := json.Unmarshal(data, &c); err != nil || c.ID == "" {
+		return c, ErrInvalidCursor
+	}
+	return c, nil
+}
+
+// paginateCreators filters and sorts creators, then cuts out the requested page
+func paginateCreators(creators []Creator, query CreatorQuery) (*CreatorPage, error) {
+	sortBy, order := query.Sort, query.Order
+	if sortBy == "" {
+		sortBy = SortByName
+	}
+	if order == "" {
+		order = "asc"
+	}
+
+	matched := make([]Creator, 0, len(creators))
+	for _, c := range creators {
+		if query.Verified != nil && c.IsVerified != *query.Verified {
+			continue
+		}
+		if query.Following != nil && c.IsFollowing != *query.Following {
+			continue
+		}
+		if query.Subscribed != nil && c.IsSubscribed != *query.Subscribed {
+			continue
+		}
+		matched = append(matched, c)
+	}
+
+	less := creatorLess(sortBy, order == "desc")
+	sort.SliceStable(matched, func(i, j int) bool { return less(matched[i], matched[j]) })
+
+	total := len(matched)
+	start := query.Offset
+	if query.Cursor != "" {
+		cur, err := decodeCursor(query.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		if cur.Sort != sortBy || cur.Order != order {
+			return nil, ErrInvalidCursor
+		}
+		pivot := Creator{ID: cur.ID, Name: cur.Name, LastUpdated: cur.Time}
+		// Index of the first creator ordered after the cursor position
+		after := sort.Search(total, func(i int) bool { return less(pivot, matched[i]) })
+		if cur.Before {
+			// Index of the first creator at or after the cursor position
+			at := sort.Search(total, func(i int) bool { return !less(matched[i], pivot) })
+			start = at - query.Limit
+			if start < 0 {
+				start = 0
+			}
+		} else {
+			start = after
+		}
+	}
+	if start > total {
+		start = total
+	}
+	end := start + query.Limit
+	if end > total {
+		end = total
+	}
+
+	page := &CreatorPage{
+		Creators: matched[start:end],
+		Total:    total,
+		Offset:   start,
+	}
+	if end < total && end > start {
+		page.NextCursor = encodeCursor(cursorAt(matched[end-1], sortBy, order, false))
+	}
+	if start > 0 && start < total {
+		page.PrevCursor = encodeCursor(cursorAt(matched[start], sortBy, order, true))
+	}
+	return page, nil
+}
+
+func cursorAt(c Creator, sortBy, order string, before bool) creatorCursor {
+	cur := creatorCursor{Sort: sortBy, Order: order, Before: before, ID: c.ID}
+	if sortBy == SortByLastUpdated {
+		cur.Time = c.LastUpdated
+	} else {
+		cur.Name = c.Name
+	}
+	return cur
+}
+
+// creatorLess orders creators by the sort field, breaking ties by ID so that the
+// order is total and cursors are unambiguous
+func creatorLess(sortBy string, desc bool) func(a, b Creator) bool {
+	return func(a, b Creator) bool {
+		var cmp int
+		if sortBy == SortByLastUpdated {
+			cmp = a.LastUpdated.Compare(b.LastUpdated)
+		} else {
+			cmp = strings.Compare(strings.ToLower(a.Name), strings.ToLower(b.Name))
+		}
+		if cmp == 0 {
+			cmp = strings.Compare(a.ID, b.ID)
+		}
+		if desc {
+			return cmp > 0
+		}
+		return cmp < 0
+	}
+}
+
+// ErrCreatorNotFound is returned when no creator has the requested ID
+var ErrCreatorNotFound = errors.New("creator not found")
+
+// CreatorProfile is the full profile of a creator
+type CreatorProfile struct {
+	Creator
+	Bio               string             `json:"bio"`
+	BannerURL         string             `json:"banner_url,omitempty"`
+	AvatarVariants    []ImageVariant     `json:"avatar_variants"`
+	SubscriptionTiers []SubscriptionTier `json:"subscription_tiers"`
+	Stats             CreatorStats       `json:"stats"`
+	LastActive        time.Time          `json:"last_active"`
+}
+
+// ImageVariant is one size of an image
+type ImageVariant struct {
+	URL    string `json:"url"`
+	Width  int    `json:"width"`
+	Height int    `json:"height"`
+}
+
+// SubscriptionTier is a subscription offered by a creator
+type SubscriptionTier struct {
+	ID             string `json:"id"`
+	Name           string `json:"name"`
+	Price          int    `json:"price"` // in the smallest currency unit, e.g. cents
+	Currency       string `json:"currency"`
+	DurationMonths int    `json:"duration_months"`
+}
+
+// CreatorStats are the public counters of a creator's profile
+type CreatorStats struct {
+	Posts     int `json:"posts"`
+	Media     int `json:"media"`
+	Images    int `json:"images"`
+	Videos    int `json:"videos"`
+	Followers int `json:"followers"`
+}
@@ -0,0 +1,135 @@
+package service
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+)
+
+// testCreators has ties on name (ignoring case) and on last_updated
+func testCreators() []Creator {
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	return []Creator{
+		{ID: "c07", Name: "bob", LastUpdated: base.Add(2 * time.Hour)},
+		{ID: "c01", Name: "Alice", LastUpdated: base},
+		{ID: "c05", Name: "alice", LastUpdated: base.Add(time.Hour)},
+		{ID: "c03", Name: "Carol", LastUpdated: base},
+		{ID: "c10", Name: "alice", LastUpdated: base.Add(time.Hour)},
+		{ID: "c02", Name: "Dave", LastUpdated: base.Add(3 * time.Hour)},
+		{ID: "c09", Name: "bob", LastUpdated: base},
+		{ID: "c04", Name: "Eve", LastUpdated: base.Add(time.Hour)},
+		{ID: "c08", Name: "carol", LastUpdated: base.Add(2 * time.Hour)},
+		{ID: "c06", Name: "Bob", LastUpdated: base.Add(time.Hour)},
+		{ID: "c11", Name: "Eve", LastUpdated: base},
+	}
+}
+
+func ids(creators []Creator) []string {
+	out := make([]string, len(creators))
+	for i, c := range creators {
+		out[i] = c.ID
+	}
+	return out
+}
+
+func TestPaginateCreatorsOrder(t *testing.T) {
+	tests := []struct {
+		sort, order string
+		want        []string
+	}{
+		{SortByName, "asc", []string{"c01", "c05", "c10", "c06", "c07", "c09", "c03", "c08", "c02", "c04", "c11"}},
+		{SortByName, "desc", []string{"c11", "c04", "c02", "c08", "c03", "c09", "c07", "c06", "c10", "c05", "c01"}},
+		{SortByLastUpdated, "asc", []string{"c01", "c03", "c09", "c11", "c04", "c05", "c06", "c10", "c07", "c08", "c02"}},
+	}
+	for _, tt := range tests {
+		page, err := paginateCreators(testCreators(), CreatorQuery{Limit: 100, Sort: tt.sort, Order: tt.order})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := ids(page.Creators); !slices.Equal(got, tt.want) {
+			t.Errorf("%s %s: %v, want %v", tt.sort, tt.order, got, tt.want)
+		}
+	}
+}
+
+func TestPaginateCreatorsCursors(t *testing.T) {
+	for _, sortBy := range []string{SortByName, SortByLastUpdated} {
+		for _, order := range []string{"asc", "desc"} {
+			for _, limit := range []int{1, 2, 3, 4, 11} {
+				all, err := paginateCreators(testCreators(), CreatorQuery{Limit: 100, Sort: sortBy, Order: order})
+				if err != nil {
+					t.Fatal(err)
+				}
+				want := ids(all.Creators)
+				query := CreatorQuery{Limit: limit, Sort: sortBy, Order: order}
+
+				// Forward from the first page
+				var forward []string
+				var last *CreatorPage
+				for cursor, pages := "", 0; ; pages++ {
+					if pages > len(want) {
+						t.Fatalf("%s %s limit %d: paging forward doesn't end", sortBy, order, limit)
+					}
+					query.Cursor = cursor
+					page, err := paginateCreators(testCreators(), query)
+					if err != nil {
+						t.Fatal(err)
+					}
+					if page.Offset != len(forward) {
+						t.Errorf("%s %s limit %d: page offset %d, want %d", sortBy, order, limit, page.Offset, len(forward))
+					}
+					forward = append(forward, ids(page.Creators)...)
+					last = page
+					if page.NextCursor == "" {
+						break
+					}
+					cursor = page.NextCursor
+				}
+				if !slices.Equal(forward, want) {
+					t.Errorf("%s %s limit %d: forward %v, want %v", sortBy, order, limit, forward, want)
+				}
+
+				// Back from the last page
+				backward := ids(last.Creators)
+				for cursor := last.PrevCursor; cursor != ""; {
+					query.Cursor = cursor
+					page, err := paginateCreators(testCreators(), query)
+					if err != nil {
+						t.Fatal(err)
+					}
+					if len(page.Creators) != limit {
+						t.Errorf("%s %s limit %d: previous page of %d creators", sortBy, order, limit, len(page.Creators))
+					}
+					backward = append(ids(page.Creators), backward...)
+					cursor = page.PrevCursor
+				}
+				if !slices.Equal(backward, want) {
+					t.Errorf("%s %s limit %d: backward %v, want %v", sortBy, order, limit, backward, want)
+				}
+			}
+		}
+	}
+}
+
+func TestPaginateCreatorsInvalidCursor(t *testing.T) {
+	page, err := paginateCreators(testCreators(), CreatorQuery{Limit: 3, Sort: SortByName, Order: "asc"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, query := range []CreatorQuery{
+		{Limit: 3, Sort: SortByLastUpdated, Order: "asc", Cursor: page.NextCursor},
+		{Limit: 3, Sort: SortByName, Order: "desc", Cursor: page.NextCursor},
+		// The default sort and order are name ascending
+		{Limit: 3, Order: "desc", Cursor: page.NextCursor},
+		{Limit: 3, Cursor: "not-a-cursor"},
+		{Limit: 3, Cursor: encodeCursor(creatorCursor{Sort: SortByName, Order: "asc"})},
+	} {
+		if _, err := paginateCreators(testCreators(), query); !errors.Is(err, ErrInvalidCursor) {
+			t.Errorf("%+v: error %v, want ErrInvalidCursor", query, err)
+		}
+	}
+	if _, err := paginateCreators(testCreators(), CreatorQuery{Limit: 3, Cursor: page.NextCursor}); err != nil {
+		t.Errorf("cursor with the default sort: %v", err)
+	}
+}
@@ -9,19 +9,28 @@ import (
 
 	"github.com/agnosto/fansly-scraper/auth"
 	"github.com/agnosto/fansly-scraper/headers"
-	"github.com/agnosto/fansly-scraper/logger"
+	"go.opentelemetry.io/otel/attribute"
+
+	"fansly-api/internal/logger"
+	"fansly-api/internal/storage"
+	"fansly-api/internal/tracing"
 )
 
+// ErrNotAuthenticated is returned when Fansly calls are made before Authenticate succeeds
+var ErrNotAuthenticated = errors.New("not authenticated with Fansly")
+
 // ScraperService handles all interactions with the fansly-scraper
 type ScraperService struct {
 	logger          logger.Logger
+	follows         storage.FollowStore       // follow changes made through the API
+	subscriptions   storage.SubscriptionStore // subscriptions as last fetched from Fansly
 	authConfig      *auth.Config
 	headers         *headers.FanslyHeaders
 	isAuthenticated bool
 }
 
 // NewScraperService creates a new ScraperService instance
-func NewScraperService(logger logger.Logger) *ScraperService {
+func NewScraperService(logger logger.Logger, follows storage.FollowStore, subscriptions storage.SubscriptionStore) *ScraperService {
 	headers := headers.New()
 	authConfig := &auth.Config{
 		Client:    *http.DefaultClient,
@@ -29,68 +38,180 @@ func NewScraperService(logger logger.Logger) *ScraperService {
 	}
 
 	return &ScraperService{
-		logger:       logger,
-		headers:      headers,
-		authConfig:   authConfig,
+		logger:        logger,
+		follows:       follows,
+		subscriptions: subscriptions,
+		headers:       headers,
+		authConfig:    authConfig,
 	}
 }
 
-// GetCreators retrieves a list of creators from Fansly
-func (s *ScraperService) GetCreators(ctx context.Context, limit, offset int) ([]Creator, error) {
-	s.logger.Infof("Fetching creators with limit=%d, offset=%d", limit, offset)
+// GetCreators returns one page of the creators matching the query, sorted before
+// paging so that ordering is stable across pages
+func (s *ScraperService) GetCreators(ctx context.Context, query CreatorQuery) (page *CreatorPage, err error) {
+	ctx, span := tracing.Start(ctx, "ScraperService.GetCreators",
+		attribute.Int("limit", query.Limit), attribute.Int("offset", query.Offset),
+		attribute.String("sort", query.Sort), attribute.Bool("cursor", query.Cursor != ""))
+	defer func() { tracing.End(span, err) }()
+
+	s.logger.Infof("Fetching creators with limit=%d, offset=%d, sort=%s, order=%s",
+		query.Limit, query.Offset, query.Sort, query.Order)
 
 	if !s.isAuthenticated {
-		return nil, errors.New("not authenticated with Fansly")
+		return nil, ErrNotAuthenticated
 	}
 
-	// TODO: Implement actual creator fetching logic using the fansly-scraper package
-	// For now, return mock data
-	creators := []Creator{
-		{
-			ID:          "1",
-			Name:        "Example Creator 1",
-			Username:    "creator1",
-			IsVerified:  true,
-			IsFollowing: true,
-			LastUpdated: time.Now().Add(-2 * time.Hour),
-		},
-		{
-			ID:          "2",
-			Name:        "Example Creator 2",
-			Username:    "creator2",
-			IsVerified:  false,
-			IsFollowing: true,
-			LastUpdated: time.Now().Add(-1 * time.Hour),
-		},
+	creators, err := s.fetchCreators(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return paginateCreators(creators, query)
+}
+
+// GetCreator returns the full profile of a creator
+func (s *ScraperService) GetCreator(ctx context.Context, id string) (profile *CreatorProfile, err error) {
+	ctx, span := tracing.Start(ctx, "ScraperService.GetCreator", attribute.String("creator_id", id))
+	defer func() { tracing.End(span, err) }()
+
+	if !s.isAuthenticated {
+		return nil, ErrNotAuthenticated
+	}
+
+	profiles, err := s.fetchCreatorProfiles(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range profiles {
+		if p.ID == id {
+			return &p, nil
+		}
+	}
+	return nil, ErrCreatorNotFound
+}
+
+// AllCreators returns every creator known to the account, unpaged
+func (s *ScraperService) AllCreators(ctx context.Context) ([]Creator, error) {
+	if !s.isAuthenticated {
+		return nil, ErrNotAuthenticated
+	}
+	return s.fetchCreators(ctx)
+}
+
+// SetFollowing records that the account now follows or no longer follows a creator.
+// The change must already have been made on Fansly.
+func (s *ScraperService) SetFollowing(ctx context.Context, id string, following bool) error {
+	err := s.follows.Put(ctx, &storage.Follow{CreatorID: id, Following: following, UpdatedAt: time.Now().UTC()})
+	if err != nil {
+		return fmt.Errorf("error saving follow state: %w", err)
 	}
+	return nil
+}
 
-	// Apply pagination
-	if offset >= len(creators) {
-		return []Creator{}, nil
+// fetchCreators returns every creator known to the account
+func (s *ScraperService) fetchCreators(ctx context.Context) ([]Creator, error) {
+	profiles, err := s.fetchCreatorProfiles(ctx)
+	if err != nil {
+		return nil, err
+	}
+	creators := make([]Creator, len(profiles))
+	for i, p := range profiles {
+		creators[i] = p.Creator
 	}
+	return creators, nil
+}
 
-	end := offset + limit
-	if end > len(creators) {
-		end = len(creators)
+// fetchCreatorProfiles returns the profile of every creator known to the account,
+// with follow changes made through the API and subscriptions applied
+func (s *ScraperService) fetchCreatorProfiles(ctx context.Context) ([]CreatorProfile, error) {
+	profiles := mockCreatorProfiles()
+	now := time.Now()
+	for i := range profiles {
+		follow, err := s.follows.Get(ctx, profiles[i].ID)
+		if err == nil {
+			profiles[i].IsFollowing = follow.Following
+		} else if !errors.Is(err, storage.ErrNotFound) {
+			return nil, fmt.Errorf("error loading follow state: %w", err)
+		}
+
+		sub, err := s.subscriptions.Get(ctx, profiles[i].ID)
+		if err == nil {
+			profiles[i].IsSubscribed = sub.Active(now)
+			if profiles[i].IsSubscribed {
+				profiles[i].SubscriptionTierID = sub.TierID
+			}
+		} else if !errors.Is(err, storage.ErrNotFound) {
+			return nil, fmt.Errorf("error loading subscription: %w", err)
+		}
 	}
+	return profiles, nil
+}
 
-	return creators[offset:end], nil
+func mockCreatorProfiles() []CreatorProfile {
+	// TODO: Implement actual creator fetching logic using the fansly-scraper package
+	// For now, return mock data with fixed timestamps so responses are cacheable
+	lastUpdated := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	return []CreatorProfile{
+		{
+			Creator: Creator{
+				ID:          "1",
+				Name:        "Example Creator 1",
+				Username:    "creator1",
+				AvatarURL:   "https://cdn.example.com/creator1/avatar.jpg",
+				IsVerified:  true,
+				IsFollowing: true,
+				LastUpdated: lastUpdated.Add(-2 * time.Hour),
+			},
+			Bio:       "Example creator profile",
+			BannerURL: "https://cdn.example.com/creator1/banner.jpg",
+			AvatarVariants: []ImageVariant{
+				{URL: "https://cdn.example.com/creator1/avatar-96.jpg", Width: 96, Height: 96},
+				{URL: "https://cdn.example.com/creator1/avatar-480.jpg", Width: 480, Height: 480},
+			},
+			SubscriptionTiers: []SubscriptionTier{
+				{ID: "1-basic", Name: "Basic", Price: 499, Currency: "USD", DurationMonths: 1},
+				{ID: "1-vip", Name: "VIP", Price: 1499, Currency: "USD", DurationMonths: 1},
+			},
+			Stats:      CreatorStats{Posts: 120, Media: 340, Images: 300, Videos: 40, Followers: 5400},
+			LastActive: lastUpdated.Add(-30 * time.Minute),
+		},
+		{
+			Creator: Creator{
+				ID:          "2",
+				Name:        "Example Creator 2",
+				Username:    "creator2",
+				IsVerified:  false,
+				IsFollowing: true,
+				LastUpdated: lastUpdated.Add(-1 * time.Hour),
+			},
+			AvatarVariants: []ImageVariant{},
+			SubscriptionTiers: []SubscriptionTier{
+				{ID: "2-basic", Name: "Basic", Price: 999, Currency: "USD", DurationMonths: 1},
+			},
+			Stats:      CreatorStats{Posts: 15, Media: 22, Images: 20, Videos: 2, Followers: 310},
+			LastActive: lastUpdated.Add(-1 * time.Hour),
+		},
+	}
 }
 
 // Creator represents a Fansly creator
 // TODO: Move this to a shared types package
 type Creator struct {
-	ID          string    `json:"id"`
-	Name        string    `json:"name"`
-	Username    string    `json:"username"`
-	AvatarURL   string    `json:"avatar_url,omitempty"`
-	IsVerified  bool      `json:"is_verified"`
-	IsFollowing bool      `json:"is_following"`
-	LastUpdated time.Time `json:"last_updated"`
+	ID                 string    `json:"id"`
+	Name               string    `json:"name"`
+	Username           string    `json:"username"`
+	AvatarURL          string    `json:"avatar_url,omitempty"`
+	IsVerified         bool      `json:"is_verified"`
+	IsFollowing        bool      `json:"is_following"`
+	IsSubscribed       bool      `json:"is_subscribed"`                  // the account has an active paid subscription
+	SubscriptionTierID string    `json:"subscription_tier_id,omitempty"` // tier of the active subscription
+	LastUpdated        time.Time `json:"last_updated"`
 }
 
 // Authenticate handles Fansly authentication
-func (s *ScraperService) Authenticate(ctx context.Context, authToken string) error {
+func (s *ScraperService) Authenticate(ctx context.Context, authToken string) (err error) {
+	_, span := tracing.Start(ctx, "ScraperService.Authenticate")
+	defer func() { tracing.End(span, err) }()
+
 	if authToken == "" {
 		return errors.New("authentication token is required")
 	}
@@ -102,8 +223,7 @@ func (s *ScraperService) Authenticate(ctx context.Context, authToken string) err
 	s.authConfig.Authorization = authToken
 
 	// Test authentication by getting account info
-	_, err := auth.Login(s.headers)
-	if err != nil {
+	if _, err := auth.Login(s.headers); err != nil {
 		s.logger.Errorf("Authentication failed: %v", err)
 		return fmt.Errorf("authentication failed: %w", err)
 	}
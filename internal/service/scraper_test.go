@@ -0,0 +1,48 @@
+package service
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"fansly-api/internal/logger"
+	"fansly-api/internal/storage"
+)
+
+func TestGetCreatorsSubscribed(t *testing.T) {
+	ctx := context.Background()
+	subs := storage.NewMemorySubscriptionStore()
+	err := subs.Replace(ctx, []*storage.Subscription{
+		// Fansly didn't report a renewal date
+		{CreatorID: "1", TierID: "1-basic"},
+		{CreatorID: "2", TierID: "2-basic", RenewsAt: time.Now().Add(-time.Hour)},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := NewScraperService(logger.New(), storage.NewMemoryFollowStore(), subs)
+	s.isAuthenticated = true
+
+	for _, subscribed := range []bool{true, false} {
+		page, err := s.GetCreators(ctx, CreatorQuery{Limit: 10, Subscribed: &subscribed})
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := []string{"1"}
+		if !subscribed {
+			want = []string{"2"}
+		}
+		if got := ids(page.Creators); !slices.Equal(got, want) {
+			t.Errorf("subscribed=%v: %v, want %v", subscribed, got, want)
+		}
+	}
+
+	profile, err := s.GetCreator(ctx, "1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !profile.IsSubscribed || profile.SubscriptionTierID != "1-basic" {
+		t.Fatalf("profile: subscribed %v, tier %q", profile.IsSubscribed, profile.SubscriptionTierID)
+	}
+}
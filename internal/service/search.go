@@ -0,0 +1,167 @@
+package service
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+
+	"fansly-api/internal/tracing"
+)
+
+// Sources of a creator search result
+const (
+	SourceFansly = "fansly"
+	SourceLocal  = "local"
+	SourceBoth   = "both"
+)
+
+// minSimilarity is the similarity, from 0 to 1, above which a username or name
+// word counts as a fuzzy match. 0.6 tolerates about one typo in five letters.
+const minSimilarity = 0.6
+
+// CreatorMatch is a creator search result
+type CreatorMatch struct {
+	Creator
+	Score  float64 `json:"score"`
+	Source string  `json:"source"`
+}
+
+// SearchCreators ranks the creators known locally together with remote, the results of
+// a Fansly account search, against query. Exact username matches rank first, then
+// prefix and substring matches, then fuzzy matches on the username or display name;
+// followed creators rank above others with a similar match.
+func (s *ScraperService) SearchCreators(ctx context.Context, query string, remote []Creator, limit int) (matches []CreatorMatch, err error) {
+	ctx, span := tracing.Start(ctx, "ScraperService.SearchCreators",
+		attribute.Int("remote", len(remote)), attribute.Int("limit", limit))
+	defer func() { tracing.End(span, err) }()
+
+	if !s.isAuthenticated {
+		return nil, ErrNotAuthenticated
+	}
+
+	local, err := s.fetchCreators(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return MatchCreators(query, remote, local, limit), nil
+}
+
+// MatchCreators ranks remote and local creators against query as SearchCreators
+// does. It is used directly when the local creators can't be listed.
+func MatchCreators(query string, remote, local []Creator, limit int) []CreatorMatch {
+	// Local records win, since they carry follow changes made through the API
+	candidates := make(map[string]*CreatorMatch, len(local)+len(remote))
+	var order []string
+	for _, c := range remote {
+		if _, ok := candidates[c.ID]; !ok {
+			order = append(order, c.ID)
+		}
+		candidates[c.ID] = &CreatorMatch{Creator: c, Source: SourceFansly}
+	}
+	for _, c := range local {
+		source := SourceLocal
+		if _, ok := candidates[c.ID]; ok {
+			source = SourceBoth
+		} else {
+			order = append(order, c.ID)
+		}
+		candidates[c.ID] = &CreatorMatch{Creator: c, Source: source}
+	}
+
+	var matches []CreatorMatch
+	q := normalizeSearch(query)
+	for _, id := range order {
+		m := candidates[id]
+		if m.Score = scoreCreator(q, m.Creator); m.Score > 0 {
+			matches = append(matches, *m)
+		}
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		if matches[i].Score != matches[j].Score {
+			return matches[i].Score > matches[j].Score
+		}
+		return strings.ToLower(matches[i].Username) < strings.ToLower(matches[j].Username)
+	})
+	if len(matches) > limit {
+		matches = matches[:limit]
+	}
+	return matches
+}
+
+// scoreCreator returns how well c matches the normalized query, or 0 for no match
+func scoreCreator(q string, c Creator) float64 {
+	username := normalizeSearch(c.Username)
+	name := strings.ToLower(c.Name)
+
+	var score float64
+	switch {
+	case username == q:
+		score = 1000
+	case strings.HasPrefix(username, q):
+		score = 500
+	case strings.Contains(username, q), strings.Contains(name, q):
+		score = 300
+	}
+
+	// Fuzzy matching tolerates typos in the username or any word of the name
+	best := similarity(q, username)
+	for _, word := range strings.Fields(name) {
+		if sim := similarity(q, word); sim > best {
+			best = sim
+		}
+	}
+	if sim := similarity(q, name); sim > best {
+		best = sim
+	}
+	if score == 0 && best < minSimilarity {
+		return 0
+	}
+	score += 100 * best
+
+	if c.IsFollowing {
+		score += 50
+	}
+	return score
+}
+
+func normalizeSearch(s string) string {
+	return strings.TrimPrefix(strings.ToLower(strings.TrimSpace(s)), "@")
+}
+
+// similarity is 1 minus the edit distance between a and b relative to the longer of the two
+func similarity(a, b string) float64 {
+	ra, rb := []rune(a), []rune(b)
+	longest := len(ra)
+	if len(rb) > longest {
+		longest = len(rb)
+	}
+	if longest == 0 {
+		return 0
+	}
+	return 1 - float64(levenshtein(ra, rb))/float64(longest)
+}
+
+// levenshtein returns the number of single-rune insertions, deletions and
+// substitutions needed to turn a into b
+func levenshtein(a, b []rune) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
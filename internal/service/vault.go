@@ -0,0 +1,71 @@
+package service
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"fansly-api/internal/tracing"
+)
+
+// Vault item sources
+const (
+	VaultSourcePost    = "post"
+	VaultSourceMessage = "message"
+)
+
+// VaultItem is a purchased media item the account has access to
+type VaultItem struct {
+	MediaID     string    `json:"media_id"`
+	BundleID    string    `json:"bundle_id,omitempty"` // set when bought as part of a bundle
+	CreatorID   string    `json:"creator_id"`
+	Type        string    `json:"type"`                // "image" or "video"
+	Source      string    `json:"source"`              // VaultSourcePost or VaultSourceMessage
+	SourceID    string    `json:"source_id,omitempty"` // post or message the purchase was made from
+	Price       int       `json:"price"`               // in the smallest currency unit, e.g. cents
+	PurchasedAt time.Time `json:"purchased_at"`
+	URL         string    `json:"-"` // signed download URL; short-lived, so not exposed
+}
+
+// PurchaseSource lists the media the account has bought, with bundles expanded
+// into their media
+type PurchaseSource interface {
+	ListPurchases(ctx context.Context) ([]VaultItem, error)
+}
+
+// Vault returns every purchased media item, newest purchase first. Media bought
+// more than once, for example individually and again in a bundle, is listed once
+// with its first purchase.
+func (s *ScraperService) Vault(ctx context.Context, source PurchaseSource) (items []VaultItem, err error) {
+	ctx, span := tracing.Start(ctx, "ScraperService.Vault")
+	defer func() { tracing.End(span, err) }()
+
+	if !s.isAuthenticated {
+		return nil, ErrNotAuthenticated
+	}
+
+	purchases, err := source.ListPurchases(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	first := make(map[string]int, len(purchases)) // media ID -> index in items
+	for _, p := range purchases {
+		if i, ok := first[p.MediaID]; ok {
+			if p.PurchasedAt.Before(items[i].PurchasedAt) {
+				items[i] = p
+			}
+			continue
+		}
+		first[p.MediaID] = len(items)
+		items = append(items, p)
+	}
+
+	sort.Slice(items, func(i, j int) bool {
+		if !items[i].PurchasedAt.Equal(items[j].PurchasedAt) {
+			return items[i].PurchasedAt.After(items[j].PurchasedAt)
+		}
+		return items[i].MediaID < items[j].MediaID
+	})
+	return items, nil
+}
@@ -0,0 +1,183 @@
+package storage
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// APIKey is a stored API key. Only the hash of the secret is kept.
+type APIKey struct {
+	ID         string     `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Hash       string     `json:"hash"`
+	Scopes     []string   `json:"scopes"`
+	CreatedAt  time.Time  `json:"created_at"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+}
+
+// Active reports whether the key can currently be used
+func (k *APIKey) Active(now time.Time) bool {
+	if k.RevokedAt != nil {
+		return false
+	}
+	return k.ExpiresAt == nil || now.Before(*k.ExpiresAt)
+}
+
+// APIKeyStore persists API keys
+type APIKeyStore interface {
+	Create(ctx context.Context, key *APIKey) error
+	Get(ctx context.Context, id string) (*APIKey, error)
+	GetByPrefix(ctx context.Context, prefix string) (*APIKey, error)
+	List(ctx context.Context) ([]*APIKey, error)
+	Revoke(ctx context.Context, id string, at time.Time) error
+	TouchLastUsed(ctx context.Context, id string, at time.Time) error
+}
+
+// MemoryAPIKeyStore keeps API keys in memory
+type MemoryAPIKeyStore struct {
+	mu   sync.RWMutex
+	keys map[string]*APIKey
+}
+
+// NewMemoryAPIKeyStore creates an empty in-memory API key store
+func NewMemoryAPIKeyStore() *MemoryAPIKeyStore {
+	return &MemoryAPIKeyStore{keys: make(map[string]*APIKey)}
+}
+
+// Create stores a new API key. It fails with ErrConflict when the ID or prefix is taken.
+func (s *MemoryAPIKeyStore) Create(ctx context.Context, key *APIKey) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, existing := range s.keys {
+		if existing.ID == key.ID || existing.Prefix == key.Prefix {
+			return ErrConflict
+		}
+	}
+	copied := *key
+	s.keys[key.ID] = &copied
+	return nil
+}
+
+// Get returns the API key with the given ID
+func (s *MemoryAPIKeyStore) Get(ctx context.Context, id string) (*APIKey, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	key, ok := s.keys[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	copied := *key
+	return &copied, nil
+}
+
+// GetByPrefix returns the API key with the given public prefix
+func (s *MemoryAPIKeyStore) GetByPrefix(ctx context.Context, prefix string) (*APIKey, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, key := range s.keys {
+		if key.Prefix == prefix {
+			copied := *key
+			return &copied, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+// List returns all API keys, newest first
+func (s *MemoryAPIKeyStore) List(ctx context.Context) ([]*APIKey, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	keys := make([]*APIKey, 0, len(s.keys))
+	for _, key := range s.keys {
+		copied := *key
+		keys = append(keys, &copied)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].CreatedAt.After(keys[j].CreatedAt) })
+	return keys, nil
+}
+
+// Revoke marks the API key as revoked. Revoking twice keeps the original time.
+func (s *MemoryAPIKeyStore) Revoke(ctx context.Context, id string, at time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key, ok := s.keys[id]
+	if !ok {
+		return ErrNotFound
+	}
+	if key.RevokedAt == nil {
+		key.RevokedAt = &at
+	}
+	return nil
+}
+
+// TouchLastUsed records when the API key was last used
+func (s *MemoryAPIKeyStore) TouchLastUsed(ctx context.Context, id string, at time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key, ok := s.keys[id]
+	if !ok {
+		return ErrNotFound
+	}
+	key.LastUsedAt = &at
+	return nil
+}
+
+// FileAPIKeyStore keeps API keys in memory and persists them to a JSON file
+type FileAPIKeyStore struct {
+	*MemoryAPIKeyStore
+	path string
+	mu   sync.Mutex // serializes writes to the file
+}
+
+// NewFileAPIKeyStore loads the API keys stored at path
+func NewFileAPIKeyStore(path string) (*FileAPIKeyStore, error) {
+	s := &FileAPIKeyStore{MemoryAPIKeyStore: NewMemoryAPIKeyStore(), path: path}
+
+	var keys []*APIKey
+	if err := readJSON(path, &keys); err != nil {
+		return nil, err
+	}
+	for _, key := range keys {
+		s.keys[key.ID] = key
+	}
+	return s, nil
+}
+
+// Create stores a new API key
+func (s *FileAPIKeyStore) Create(ctx context.Context, key *APIKey) error {
+	if err := s.MemoryAPIKeyStore.Create(ctx, key); err != nil {
+		return err
+	}
+	return s.save(ctx)
+}
+
+// Revoke marks the API key as revoked
+func (s *FileAPIKeyStore) Revoke(ctx context.Context, id string, at time.Time) error {
+	if err := s.MemoryAPIKeyStore.Revoke(ctx, id, at); err != nil {
+		return err
+	}
+	return s.save(ctx)
+}
+
+// TouchLastUsed records when the API key was last used
+func (s *FileAPIKeyStore) TouchLastUsed(ctx context.Context, id string, at time.Time) error {
+	if err := s.MemoryAPIKeyStore.TouchLastUsed(ctx, id, at); err != nil {
+		return err
+	}
+	return s.save(ctx)
+}
+
+func (s *FileAPIKeyStore) save(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	keys, err := s.List(ctx)
+	if err != nil {
+		return err
+	}
+	return writeJSON(s.path, keys)
+}
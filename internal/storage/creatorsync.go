@@ -0,0 +1,135 @@
+package storage
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Sync statuses of a creator
+const (
+	SyncStatusNeverSynced = "never_synced"
+	SyncStatusIdle        = "idle"
+	SyncStatusSyncing     = "syncing"
+	SyncStatusFailed      = "failed"
+)
+
+// CreatorSync is the local download and post sync state of one creator
+type CreatorSync struct {
+	CreatorID       string     `json:"creator_id"`
+	Status          string     `json:"status"`
+	LastSyncedAt    *time.Time `json:"last_synced_at,omitempty"`
+	LastError       string     `json:"last_error,omitempty"`
+	DownloadedMedia int        `json:"downloaded_media"`
+	DownloadedBytes int64      `json:"downloaded_bytes"`
+	Posts           int        `json:"posts"`                     // posts stored locally
+	PostsSyncedAt   *time.Time `json:"posts_synced_at,omitempty"` // last post sync that covered the full history
+}
+
+// CreatorSyncStore persists the sync state of creators
+type CreatorSyncStore interface {
+	Get(ctx context.Context, creatorID string) (*CreatorSync, error)
+	Put(ctx context.Context, state *CreatorSync) error
+	// Update applies update to the state of a creator, starting from a never_synced
+	// state if there is none, without another writer changing it in between
+	Update(ctx context.Context, creatorID string, update func(state *CreatorSync)) error
+}
+
+// MemoryCreatorSyncStore keeps sync state in memory
+type MemoryCreatorSyncStore struct {
+	mu     sync.RWMutex
+	states map[string]*CreatorSync
+}
+
+// NewMemoryCreatorSyncStore creates an empty in-memory sync store
+func NewMemoryCreatorSyncStore() *MemoryCreatorSyncStore {
+	return &MemoryCreatorSyncStore{states: make(map[string]*CreatorSync)}
+}
+
+// Get returns the sync state of a creator, or ErrNotFound if it has never been synced
+func (s *MemoryCreatorSyncStore) Get(ctx context.Context, creatorID string) (*CreatorSync, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	state, ok := s.states[creatorID]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	copied := *state
+	return &copied, nil
+}
+
+// Put creates or replaces the sync state of a creator
+func (s *MemoryCreatorSyncStore) Put(ctx context.Context, state *CreatorSync) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	copied := *state
+	s.states[state.CreatorID] = &copied
+	return nil
+}
+
+// Update applies update to the sync state of a creator
+func (s *MemoryCreatorSyncStore) Update(ctx context.Context, creatorID string, update func(state *CreatorSync)) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	state := &CreatorSync{CreatorID: creatorID, Status: SyncStatusNeverSynced}
+	if existing, ok := s.states[creatorID]; ok {
+		copied := *existing
+		state = &copied
+	}
+	update(state)
+	s.states[creatorID] = state
+	return nil
+}
+
+// snapshot returns a copy of every sync state
+func (s *MemoryCreatorSyncStore) snapshot() []*CreatorSync {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	states := make([]*CreatorSync, 0, len(s.states))
+	for _, state := range s.states {
+		copied := *state
+		states = append(states, &copied)
+	}
+	return states
+}
+
+// FileCreatorSyncStore keeps sync state in memory and persists it to a JSON file
+type FileCreatorSyncStore struct {
+	*MemoryCreatorSyncStore
+	path string
+	mu   sync.Mutex // serializes writes to the file
+}
+
+// NewFileCreatorSyncStore loads the sync state stored at path
+func NewFileCreatorSyncStore(path string) (*FileCreatorSyncStore, error) {
+	s := &FileCreatorSyncStore{MemoryCreatorSyncStore: NewMemoryCreatorSyncStore(), path: path}
+
+	var states []*CreatorSync
+	if err := readJSON(path, &states); err != nil {
+		return nil, err
+	}
+	for _, state := range states {
+		s.states[state.CreatorID] = state
+	}
+	return s, nil
+}
+
+// Put creates or replaces the sync state of a creator
+func (s *FileCreatorSyncStore) Put(ctx context.Context, state *CreatorSync) error {
+	if err := s.MemoryCreatorSyncStore.Put(ctx, state); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return writeJSON(s.path, s.snapshot())
+}
+
+// Update applies update to the sync state of a creator
+func (s *FileCreatorSyncStore) Update(ctx context.Context, creatorID string, update func(state *CreatorSync)) error {
+	if err := s.MemoryCreatorSyncStore.Update(ctx, creatorID, update); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return writeJSON(s.path, s.snapshot())
+}
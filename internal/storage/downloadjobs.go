@@ -0,0 +1,135 @@
+package storage
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Download job statuses
+const (
+	JobStatusRunning   = "running"
+	JobStatusCompleted = "completed" // every item was downloaded or already present
+	JobStatusFailed    = "failed"    // at least one item failed, or the job was interrupted
+	JobStatusCancelled = "cancelled"
+)
+
+// DownloadJob is a background download of many media items
+type DownloadJob struct {
+	ID         string     `json:"id"`
+	Status     string     `json:"status"`
+	Total      int        `json:"total"`
+	Downloaded int        `json:"downloaded"`
+	Skipped    int        `json:"skipped"` // already downloaded by an earlier job
+	Failed     int        `json:"failed"`
+	Bytes      int64      `json:"bytes"`
+	LastError  string     `json:"last_error,omitempty"`
+	CreatedBy  string     `json:"created_by"`
+	CreatedAt  time.Time  `json:"created_at"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+}
+
+// DownloadJobStore persists download jobs
+type DownloadJobStore interface {
+	Get(ctx context.Context, id string) (*DownloadJob, error)
+	// List returns every job, newest first
+	List(ctx context.Context) ([]*DownloadJob, error)
+	Put(ctx context.Context, job *DownloadJob) error
+}
+
+// MemoryDownloadJobStore keeps download jobs in memory
+type MemoryDownloadJobStore struct {
+	mu   sync.RWMutex
+	jobs map[string]*DownloadJob
+}
+
+// NewMemoryDownloadJobStore creates an empty in-memory download job store
+func NewMemoryDownloadJobStore() *MemoryDownloadJobStore {
+	return &MemoryDownloadJobStore{jobs: make(map[string]*DownloadJob)}
+}
+
+// Get returns the job with the given ID
+func (s *MemoryDownloadJobStore) Get(ctx context.Context, id string) (*DownloadJob, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	job, ok := s.jobs[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	copied := *job
+	return &copied, nil
+}
+
+// List returns every job, newest first
+func (s *MemoryDownloadJobStore) List(ctx context.Context) ([]*DownloadJob, error) {
+	jobs := s.snapshot()
+	sort.Slice(jobs, func(i, j int) bool {
+		if !jobs[i].CreatedAt.Equal(jobs[j].CreatedAt) {
+			return jobs[i].CreatedAt.After(jobs[j].CreatedAt)
+		}
+		return jobs[i].ID < jobs[j].ID
+	})
+	return jobs, nil
+}
+
+// Put creates or replaces a job
+func (s *MemoryDownloadJobStore) Put(ctx context.Context, job *DownloadJob) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	copied := *job
+	s.jobs[job.ID] = &copied
+	return nil
+}
+
+// snapshot returns a copy of every job
+func (s *MemoryDownloadJobStore) snapshot() []*DownloadJob {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	jobs := make([]*DownloadJob, 0, len(s.jobs))
+	for _, job := range s.jobs {
+		copied := *job
+		jobs = append(jobs, &copied)
+	}
+	return jobs
+}
+
+// FileDownloadJobStore keeps download jobs in memory and persists them to a JSON file
+type FileDownloadJobStore struct {
+	*MemoryDownloadJobStore
+	path string
+	mu   sync.Mutex // serializes writes to the file
+}
+
+// NewFileDownloadJobStore loads the jobs stored at path. Jobs that were still
+// running when the process stopped are marked failed.
+func NewFileDownloadJobStore(path string) (*FileDownloadJobStore, error) {
+	s := &FileDownloadJobStore{MemoryDownloadJobStore: NewMemoryDownloadJobStore(), path: path}
+
+	var jobs []*DownloadJob
+	if err := readJSON(path, &jobs); err != nil {
+		return nil, err
+	}
+	for _, job := range jobs {
+		if job.Status == JobStatusRunning {
+			job.Status = JobStatusFailed
+			job.LastError = "interrupted by shutdown"
+		}
+		s.jobs[job.ID] = job
+	}
+	return s, nil
+}
+
+// Put creates or replaces a job
+func (s *FileDownloadJobStore) Put(ctx context.Context, job *DownloadJob) error {
+	if err := s.MemoryDownloadJobStore.Put(ctx, job); err != nil {
+		return err
+	}
+	return s.save()
+}
+
+func (s *FileDownloadJobStore) save() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return writeJSON(s.path, s.snapshot())
+}
@@ -0,0 +1,95 @@
+package storage
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Follow records whether the Fansly account follows a creator, as last changed through the API
+type Follow struct {
+	CreatorID string    `json:"creator_id"`
+	Following bool      `json:"following"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// FollowStore persists follow state
+type FollowStore interface {
+	Get(ctx context.Context, creatorID string) (*Follow, error)
+	Put(ctx context.Context, follow *Follow) error
+}
+
+// MemoryFollowStore keeps follow state in memory
+type MemoryFollowStore struct {
+	mu      sync.RWMutex
+	follows map[string]*Follow
+}
+
+// NewMemoryFollowStore creates an empty in-memory follow store
+func NewMemoryFollowStore() *MemoryFollowStore {
+	return &MemoryFollowStore{follows: make(map[string]*Follow)}
+}
+
+// Get returns the follow state of a creator, or ErrNotFound if it was never changed
+func (s *MemoryFollowStore) Get(ctx context.Context, creatorID string) (*Follow, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	follow, ok := s.follows[creatorID]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	copied := *follow
+	return &copied, nil
+}
+
+// Put creates or replaces the follow state of a creator
+func (s *MemoryFollowStore) Put(ctx context.Context, follow *Follow) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	copied := *follow
+	s.follows[follow.CreatorID] = &copied
+	return nil
+}
+
+// snapshot returns a copy of every follow state
+func (s *MemoryFollowStore) snapshot() []*Follow {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	follows := make([]*Follow, 0, len(s.follows))
+	for _, follow := range s.follows {
+		copied := *follow
+		follows = append(follows, &copied)
+	}
+	return follows
+}
+
+// FileFollowStore keeps follow state in memory and persists it to a JSON file
+type FileFollowStore struct {
+	*MemoryFollowStore
+	path string
+	mu   sync.Mutex // serializes writes to the file
+}
+
+// NewFileFollowStore loads the follow state stored at path
+func NewFileFollowStore(path string) (*FileFollowStore, error) {
+	s := &FileFollowStore{MemoryFollowStore: NewMemoryFollowStore(), path: path}
+
+	var follows []*Follow
+	if err := readJSON(path, &follows); err != nil {
+		return nil, err
+	}
+	for _, follow := range follows {
+		s.follows[follow.CreatorID] = follow
+	}
+	return s, nil
+}
+
+// Put creates or replaces the follow state of a creator
+func (s *FileFollowStore) Put(ctx context.Context, follow *Follow) error {
+	if err := s.MemoryFollowStore.Put(ctx, follow); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return writeJSON(s.path, s.snapshot())
+}
@@ -0,0 +1,234 @@
+package storage
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// MessageGroup is a direct message conversation mirrored from Fansly
+type MessageGroup struct {
+	ID              string    `json:"id"`
+	PartnerID       string    `json:"partner_id"` // account ID of the other participant
+	PartnerUsername string    `json:"partner_username,omitempty"`
+	LastMessageAt   time.Time `json:"last_message_at"`
+	MessageCount    int       `json:"message_count"`  // messages stored locally
+	HistorySynced   bool      `json:"history_synced"` // every message down to the oldest has been stored
+	SyncedAt        time.Time `json:"synced_at"`
+}
+
+// Message is a direct message mirrored from Fansly
+type Message struct {
+	ID          string              `json:"id"`
+	GroupID     string              `json:"group_id"`
+	SenderID    string              `json:"sender_id"`
+	Content     string              `json:"content"`
+	Attachments []MessageAttachment `json:"attachments,omitempty"`
+	CreatedAt   time.Time           `json:"created_at"`
+}
+
+// MessageAttachment is content attached to a message, such as media or a tip
+type MessageAttachment struct {
+	ID   string `json:"id"`
+	Type string `json:"type"` // "media", "media_bundle", "tip" or "other"
+}
+
+// MessageStore persists message groups and their messages
+type MessageStore interface {
+	GetGroup(ctx context.Context, id string) (*MessageGroup, error)
+	// ListGroups returns every group, most recently active first
+	ListGroups(ctx context.Context) ([]*MessageGroup, error)
+	PutGroup(ctx context.Context, group *MessageGroup) error
+	GetMessage(ctx context.Context, id string) (*Message, error)
+	// ListMessages returns the messages of a group, newest first
+	ListMessages(ctx context.Context, groupID string) ([]*Message, error)
+	PutMessages(ctx context.Context, messages []*Message) error
+}
+
+// MemoryMessageStore keeps messages in memory
+type MemoryMessageStore struct {
+	mu       sync.RWMutex
+	groups   map[string]*MessageGroup
+	messages map[string]*Message
+	byGroup  map[string]map[string]struct{} // group ID -> message IDs
+}
+
+// NewMemoryMessageStore creates an empty in-memory message store
+func NewMemoryMessageStore() *MemoryMessageStore {
+	return &MemoryMessageStore{
+		groups:   make(map[string]*MessageGroup),
+		messages: make(map[string]*Message),
+		byGroup:  make(map[string]map[string]struct{}),
+	}
+}
+
+// GetGroup returns the group with the given ID
+func (s *MemoryMessageStore) GetGroup(ctx context.Context, id string) (*MessageGroup, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	group, ok := s.groups[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return s.copyGroup(group), nil
+}
+
+// ListGroups returns every group, most recently active first
+func (s *MemoryMessageStore) ListGroups(ctx context.Context) ([]*MessageGroup, error) {
+	s.mu.RLock()
+	groups := make([]*MessageGroup, 0, len(s.groups))
+	for _, group := range s.groups {
+		groups = append(groups, s.copyGroup(group))
+	}
+	s.mu.RUnlock()
+
+	sort.Slice(groups, func(i, j int) bool {
+		if !groups[i].LastMessageAt.Equal(groups[j].LastMessageAt) {
+			return groups[i].LastMessageAt.After(groups[j].LastMessageAt)
+		}
+		return groups[i].ID < groups[j].ID
+	})
+	return groups, nil
+}
+
+// copyGroup returns a copy of group with its local message count. The caller must hold s.mu.
+func (s *MemoryMessageStore) copyGroup(group *MessageGroup) *MessageGroup {
+	copied := *group
+	copied.MessageCount = len(s.byGroup[group.ID])
+	return &copied
+}
+
+// PutGroup creates or replaces a group
+func (s *MemoryMessageStore) PutGroup(ctx context.Context, group *MessageGroup) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	copied := *group
+	s.groups[group.ID] = &copied
+	return nil
+}
+
+// GetMessage returns the message with the given ID
+func (s *MemoryMessageStore) GetMessage(ctx context.Context, id string) (*Message, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	message, ok := s.messages[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	copied := *message
+	return &copied, nil
+}
+
+// ListMessages returns the messages of a group, newest first
+func (s *MemoryMessageStore) ListMessages(ctx context.Context, groupID string) ([]*Message, error) {
+	s.mu.RLock()
+	messages := make([]*Message, 0, len(s.byGroup[groupID]))
+	for id := range s.byGroup[groupID] {
+		copied := *s.messages[id]
+		messages = append(messages, &copied)
+	}
+	s.mu.RUnlock()
+
+	sortMessages(messages)
+	return messages, nil
+}
+
+// PutMessages creates or replaces messages
+func (s *MemoryMessageStore) PutMessages(ctx context.Context, messages []*Message) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, message := range messages {
+		if old, ok := s.messages[message.ID]; ok && old.GroupID != message.GroupID {
+			delete(s.byGroup[old.GroupID], message.ID)
+		}
+		copied := *message
+		s.messages[message.ID] = &copied
+		if s.byGroup[message.GroupID] == nil {
+			s.byGroup[message.GroupID] = make(map[string]struct{})
+		}
+		s.byGroup[message.GroupID][message.ID] = struct{}{}
+	}
+	return nil
+}
+
+// sortMessages orders messages newest first
+func sortMessages(messages []*Message) {
+	sort.Slice(messages, func(i, j int) bool {
+		if !messages[i].CreatedAt.Equal(messages[j].CreatedAt) {
+			return messages[i].CreatedAt.After(messages[j].CreatedAt)
+		}
+		return messages[i].ID > messages[j].ID
+	})
+}
+
+// messageSnapshot is the file format of FileMessageStore
+type messageSnapshot struct {
+	Groups   []*MessageGroup `json:"groups"`
+	Messages []*Message      `json:"messages"`
+}
+
+// snapshot returns a copy of every group and message
+func (s *MemoryMessageStore) snapshot() messageSnapshot {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	snap := messageSnapshot{
+		Groups:   make([]*MessageGroup, 0, len(s.groups)),
+		Messages: make([]*Message, 0, len(s.messages)),
+	}
+	for _, group := range s.groups {
+		copied := *group
+		snap.Groups = append(snap.Groups, &copied)
+	}
+	for _, message := range s.messages {
+		copied := *message
+		snap.Messages = append(snap.Messages, &copied)
+	}
+	return snap
+}
+
+// FileMessageStore keeps messages in memory and persists them to a JSON file
+type FileMessageStore struct {
+	*MemoryMessageStore
+	path string
+	mu   sync.Mutex // serializes writes to the file
+}
+
+// NewFileMessageStore loads the messages stored at path
+func NewFileMessageStore(path string) (*FileMessageStore, error) {
+	s := &FileMessageStore{MemoryMessageStore: NewMemoryMessageStore(), path: path}
+
+	var snap messageSnapshot
+	if err := readJSON(path, &snap); err != nil {
+		return nil, err
+	}
+	for _, group := range snap.Groups {
+		s.groups[group.ID] = group
+	}
+	if err := s.MemoryMessageStore.PutMessages(context.Background(), snap.Messages); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// PutGroup creates or replaces a group
+func (s *FileMessageStore) PutGroup(ctx context.Context, group *MessageGroup) error {
+	if err := s.MemoryMessageStore.PutGroup(ctx, group); err != nil {
+		return err
+	}
+	return s.save()
+}
+
+// PutMessages creates or replaces messages
+func (s *FileMessageStore) PutMessages(ctx context.Context, messages []*Message) error {
+	if err := s.MemoryMessageStore.PutMessages(ctx, messages); err != nil {
+		return err
+	}
+	return s.save()
+}
+
+func (s *FileMessageStore) save() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return writeJSON(s.path, s.snapshot())
+}
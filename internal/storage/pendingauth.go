@@ -0,0 +1,212 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"hash/fnv"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrExpired is returned when a record exists but is past its expiry
+	ErrExpired = errors.New("expired")
+	// ErrLimitExceeded is returned when a caller has too many outstanding records
+	ErrLimitExceeded = errors.New("limit exceeded")
+)
+
+// PendingAuth is an authentication attempt started by /auth/initiate (or an OAuth2
+// login redirect) and not yet completed
+type PendingAuth struct {
+	Token     string    `json:"token"`
+	IPAddress string    `json:"ip_address"`
+	CreatedAt time.Time `json:"created_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+
+	// OAuth2 logins only
+	CodeVerifier string `json:"code_verifier,omitempty"` // PKCE verifier sent with the token exchange
+	Nonce        string `json:"nonce,omitempty"`         // expected nonce claim in the OIDC ID token
+}
+
+// PendingAuthStore holds pending authentication attempts
+type PendingAuthStore interface {
+	// Add stores the attempt unless its IP already has maxPerIP outstanding attempts (0 for no limit)
+	Add(ctx context.Context, p *PendingAuth, maxPerIP int) error
+	// Take removes and returns the attempt; each token can only be taken once
+	Take(ctx context.Context, token string, now time.Time) (*PendingAuth, error)
+	// DeleteExpired removes expired attempts and returns how many were removed
+	DeleteExpired(ctx context.Context, now time.Time) (int, error)
+}
+
+// pendingAuthShards is the number of independently locked shards in MemoryPendingAuthStore
+const pendingAuthShards = 16
+
+type pendingAuthShard struct {
+	mu      sync.Mutex
+	pending map[string]*PendingAuth
+}
+
+// MemoryPendingAuthStore keeps pending attempts in memory, sharded by token to reduce lock contention
+type MemoryPendingAuthStore struct {
+	shards [pendingAuthShards]*pendingAuthShard
+
+	ipMu     sync.Mutex
+	ipCounts map[string]int
+}
+
+// NewMemoryPendingAuthStore creates an empty in-memory pending-auth store
+func NewMemoryPendingAuthStore() *MemoryPendingAuthStore {
+	s := &MemoryPendingAuthStore{ipCounts: make(map[string]int)}
+	for i := range s.shards {
+		s.shards[i] = &pendingAuthShard{pending: make(map[string]*PendingAuth)}
+	}
+	return s
+}
+
+func (s *MemoryPendingAuthStore) shard(token string) *pendingAuthShard {
+	h := fnv.New32a()
+	h.Write([]byte(token))
+	return s.shards[h.Sum32()%pendingAuthShards]
+}
+
+// Add stores the attempt unless its IP already has maxPerIP outstanding attempts
+func (s *MemoryPendingAuthStore) Add(ctx context.Context, p *PendingAuth, maxPerIP int) error {
+	s.ipMu.Lock()
+	if maxPerIP > 0 && s.ipCounts[p.IPAddress] >= maxPerIP {
+		s.ipMu.Unlock()
+		return ErrLimitExceeded
+	}
+	s.ipCounts[p.IPAddress]++
+	s.ipMu.Unlock()
+
+	copied := *p
+	shard := s.shard(p.Token)
+	shard.mu.Lock()
+	shard.pending[p.Token] = &copied
+	shard.mu.Unlock()
+	return nil
+}
+
+// Take removes and returns the attempt
+func (s *MemoryPendingAuthStore) Take(ctx context.Context, token string, now time.Time) (*PendingAuth, error) {
+	shard := s.shard(token)
+	shard.mu.Lock()
+	p, ok := shard.pending[token]
+	if ok {
+		delete(shard.pending, token)
+	}
+	shard.mu.Unlock()
+
+	if !ok {
+		return nil, ErrNotFound
+	}
+	s.release(p.IPAddress, 1)
+
+	if !now.Before(p.ExpiresAt) {
+		return nil, ErrExpired
+	}
+	return p, nil
+}
+
+// DeleteExpired removes expired attempts and returns how many were removed
+func (s *MemoryPendingAuthStore) DeleteExpired(ctx context.Context, now time.Time) (int, error) {
+	removed := make(map[string]int)
+	total := 0
+	for _, shard := range s.shards {
+		shard.mu.Lock()
+		for token, p := range shard.pending {
+			if !now.Before(p.ExpiresAt) {
+				delete(shard.pending, token)
+				removed[p.IPAddress]++
+				total++
+			}
+		}
+		shard.mu.Unlock()
+	}
+	for ip, n := range removed {
+		s.release(ip, n)
+	}
+	return total, nil
+}
+
+// release decrements the outstanding count for ip
+func (s *MemoryPendingAuthStore) release(ip string, n int) {
+	s.ipMu.Lock()
+	defer s.ipMu.Unlock()
+	if s.ipCounts[ip] -= n; s.ipCounts[ip] <= 0 {
+		delete(s.ipCounts, ip)
+	}
+}
+
+// snapshot returns a copy of every pending attempt
+func (s *MemoryPendingAuthStore) snapshot() []*PendingAuth {
+	var pending []*PendingAuth
+	for _, shard := range s.shards {
+		shard.mu.Lock()
+		for _, p := range shard.pending {
+			copied := *p
+			pending = append(pending, &copied)
+		}
+		shard.mu.Unlock()
+	}
+	return pending
+}
+
+// FilePendingAuthStore keeps pending attempts in memory and persists them to a JSON
+// file so they survive restarts
+type FilePendingAuthStore struct {
+	*MemoryPendingAuthStore
+	path string
+	mu   sync.Mutex // serializes writes to the file
+}
+
+// NewFilePendingAuthStore loads the pending attempts stored at path
+func NewFilePendingAuthStore(path string) (*FilePendingAuthStore, error) {
+	s := &FilePendingAuthStore{MemoryPendingAuthStore: NewMemoryPendingAuthStore(), path: path}
+
+	var pending []*PendingAuth
+	if err := readJSON(path, &pending); err != nil {
+		return nil, err
+	}
+	for _, p := range pending {
+		if err := s.MemoryPendingAuthStore.Add(context.Background(), p, 0); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// Add stores the attempt unless its IP already has maxPerIP outstanding attempts
+func (s *FilePendingAuthStore) Add(ctx context.Context, p *PendingAuth, maxPerIP int) error {
+	if err := s.MemoryPendingAuthStore.Add(ctx, p, maxPerIP); err != nil {
+		return err
+	}
+	return s.save()
+}
+
+// Take removes and returns the attempt
+func (s *FilePendingAuthStore) Take(ctx context.Context, token string, now time.Time) (*PendingAuth, error) {
+	p, err := s.MemoryPendingAuthStore.Take(ctx, token, now)
+	if errors.Is(err, ErrNotFound) {
+		return nil, err
+	}
+	if saveErr := s.save(); saveErr != nil && err == nil {
+		return nil, saveErr
+	}
+	return p, err
+}
+
+// DeleteExpired removes expired attempts and returns how many were removed
+func (s *FilePendingAuthStore) DeleteExpired(ctx context.Context, now time.Time) (int, error) {
+	removed, err := s.MemoryPendingAuthStore.DeleteExpired(ctx, now)
+	if err != nil || removed == 0 {
+		return removed, err
+	}
+	return removed, s.save()
+}
+
+func (s *FilePendingAuthStore) save() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return writeJSON(s.path, s.snapshot())
+}
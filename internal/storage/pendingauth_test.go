@@ -0,0 +1,196 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// pendingAuthStores returns a fresh memory and file store
+func pendingAuthStores(t *testing.T) map[string]PendingAuthStore {
+	t.Helper()
+	file, err := NewFilePendingAuthStore(filepath.Join(t.TempDir(), "pending_auth.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return map[string]PendingAuthStore{
+		"memory": NewMemoryPendingAuthStore(),
+		"file":   file,
+	}
+}
+
+func TestPendingAuthTakeOnce(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	for name, s := range pendingAuthStores(t) {
+		t.Run(name, func(t *testing.T) {
+			if err := s.Add(ctx, &PendingAuth{Token: "t", IPAddress: "ip", ExpiresAt: now.Add(time.Minute)}, 0); err != nil {
+				t.Fatal(err)
+			}
+
+			var wg sync.WaitGroup
+			var taken atomic.Int32
+			for i := 0; i < 20; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					p, err := s.Take(ctx, "t", now)
+					switch {
+					case err == nil && p.Token == "t":
+						taken.Add(1)
+					case !errors.Is(err, ErrNotFound):
+						t.Errorf("Take: %v", err)
+					}
+				}()
+			}
+			wg.Wait()
+			if n := taken.Load(); n != 1 {
+				t.Fatalf("token taken %d times, want 1", n)
+			}
+		})
+	}
+}
+
+func TestPendingAuthPerIPLimit(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	const limit = 5
+	for name, s := range pendingAuthStores(t) {
+		t.Run(name, func(t *testing.T) {
+			var wg sync.WaitGroup
+			var added, limited atomic.Int32
+			for i := 0; i < 20; i++ {
+				wg.Add(1)
+				go func(i int) {
+					defer wg.Done()
+					err := s.Add(ctx, &PendingAuth{Token: fmt.Sprint("t", i), IPAddress: "ip", ExpiresAt: now.Add(time.Minute)}, limit)
+					switch {
+					case err == nil:
+						added.Add(1)
+					case errors.Is(err, ErrLimitExceeded):
+						limited.Add(1)
+					default:
+						t.Errorf("Add: %v", err)
+					}
+				}(i)
+			}
+			wg.Wait()
+			if added.Load() != limit || limited.Load() != 20-limit {
+				t.Fatalf("added %d, limited %d; want %d, %d", added.Load(), limited.Load(), limit, 20-limit)
+			}
+
+			// Other IPs are unaffected, and taking an attempt frees a slot
+			if err := s.Add(ctx, &PendingAuth{Token: "other", IPAddress: "other-ip", ExpiresAt: now.Add(time.Minute)}, limit); err != nil {
+				t.Fatalf("Add from another IP: %v", err)
+			}
+			for i := 0; i < 20; i++ {
+				if _, err := s.Take(ctx, fmt.Sprint("t", i), now); err == nil {
+					break
+				}
+			}
+			if err := s.Add(ctx, &PendingAuth{Token: "again", IPAddress: "ip", ExpiresAt: now.Add(time.Minute)}, limit); err != nil {
+				t.Fatalf("Add after Take: %v", err)
+			}
+		})
+	}
+}
+
+func TestPendingAuthExpiry(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	for name, s := range pendingAuthStores(t) {
+		t.Run(name, func(t *testing.T) {
+			s.Add(ctx, &PendingAuth{Token: "old", IPAddress: "ip", ExpiresAt: now}, 0)
+			s.Add(ctx, &PendingAuth{Token: "old2", IPAddress: "ip", ExpiresAt: now.Add(-time.Second)}, 0)
+			s.Add(ctx, &PendingAuth{Token: "new", IPAddress: "ip", ExpiresAt: now.Add(time.Minute)}, 0)
+
+			if _, err := s.Take(ctx, "old", now); !errors.Is(err, ErrExpired) {
+				t.Fatalf("Take expired: got %v, want ErrExpired", err)
+			}
+			if _, err := s.Take(ctx, "old", now); !errors.Is(err, ErrNotFound) {
+				t.Fatalf("Take expired twice: got %v, want ErrNotFound", err)
+			}
+			if n, err := s.DeleteExpired(ctx, now); err != nil || n != 1 {
+				t.Fatalf("DeleteExpired = %d, %v; want 1", n, err)
+			}
+			// Expired attempts no longer count towards the limit
+			if err := s.Add(ctx, &PendingAuth{Token: "next", IPAddress: "ip", ExpiresAt: now.Add(time.Minute)}, 2); err != nil {
+				t.Fatalf("Add after sweep: %v", err)
+			}
+			if _, err := s.Take(ctx, "new", now); err != nil {
+				t.Fatalf("Take live: %v", err)
+			}
+		})
+	}
+}
+
+func TestPendingAuthConcurrentSweep(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	for name, s := range pendingAuthStores(t) {
+		t.Run(name, func(t *testing.T) {
+			var wg sync.WaitGroup
+			for i := 0; i < 50; i++ {
+				wg.Add(2)
+				go func(i int) {
+					defer wg.Done()
+					token := fmt.Sprint("t", i)
+					expires := now.Add(time.Minute)
+					if i%2 == 0 {
+						expires = now.Add(-time.Minute)
+					}
+					if err := s.Add(ctx, &PendingAuth{Token: token, IPAddress: fmt.Sprint("ip", i%3), ExpiresAt: expires}, 0); err != nil {
+						t.Errorf("Add: %v", err)
+					}
+					s.Take(ctx, token, now)
+				}(i)
+				go func() {
+					defer wg.Done()
+					if _, err := s.DeleteExpired(ctx, now); err != nil {
+						t.Errorf("DeleteExpired: %v", err)
+					}
+				}()
+			}
+			wg.Wait()
+
+			// Every attempt was taken or swept, so no IP has outstanding attempts left
+			for i := 0; i < 3; i++ {
+				if err := s.Add(ctx, &PendingAuth{Token: fmt.Sprint("last", i), IPAddress: fmt.Sprint("ip", i), ExpiresAt: now.Add(time.Minute)}, 1); err != nil {
+					t.Fatalf("Add after concurrent use: %v", err)
+				}
+			}
+		})
+	}
+}
+
+func TestFilePendingAuthStoreReload(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	path := filepath.Join(t.TempDir(), "pending_auth.json")
+	s, err := NewFilePendingAuthStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Add(ctx, &PendingAuth{Token: "a", IPAddress: "ip", ExpiresAt: now.Add(time.Minute)}, 0)
+	s.Add(ctx, &PendingAuth{Token: "b", IPAddress: "ip", ExpiresAt: now.Add(time.Minute)}, 0)
+	s.Take(ctx, "b", now)
+
+	reloaded, err := NewFilePendingAuthStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := reloaded.Add(ctx, &PendingAuth{Token: "c", IPAddress: "ip", ExpiresAt: now.Add(time.Minute)}, 1); !errors.Is(err, ErrLimitExceeded) {
+		t.Fatalf("reloaded attempt not counted towards the limit: %v", err)
+	}
+	if _, err := reloaded.Take(ctx, "a", now); err != nil {
+		t.Fatalf("Take after reload: %v", err)
+	}
+	if _, err := reloaded.Take(ctx, "b", now); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("taken attempt survived reload: %v", err)
+	}
+}
@@ -0,0 +1,138 @@
+package storage
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Post is a creator's post synced from Fansly
+type Post struct {
+	ID        string      `json:"id"`
+	CreatorID string      `json:"creator_id"`
+	Text      string      `json:"text"`
+	Hashtags  []string    `json:"hashtags,omitempty"`
+	Mentions  []string    `json:"mentions,omitempty"` // usernames mentioned with @
+	Media     []PostMedia `json:"media,omitempty"`
+	TierIDs   []string    `json:"tier_ids,omitempty"` // subscription tiers that unlock the post, empty if followers can see it
+	CreatedAt time.Time   `json:"created_at"`
+	SyncedAt  time.Time   `json:"synced_at"`
+}
+
+// PostMedia is an image or video attached to a post
+type PostMedia struct {
+	ID      string `json:"id"`
+	Type    string `json:"type"` // "image" or "video"
+	Caption string `json:"caption,omitempty"`
+}
+
+// PostStore persists synced posts
+type PostStore interface {
+	Get(ctx context.Context, id string) (*Post, error)
+	List(ctx context.Context) ([]*Post, error)
+	Put(ctx context.Context, post *Post) error
+	Delete(ctx context.Context, id string) error
+}
+
+// MemoryPostStore keeps posts in memory
+type MemoryPostStore struct {
+	mu    sync.RWMutex
+	posts map[string]*Post
+}
+
+// NewMemoryPostStore creates an empty in-memory post store
+func NewMemoryPostStore() *MemoryPostStore {
+	return &MemoryPostStore{posts: make(map[string]*Post)}
+}
+
+// Get returns the post with the given ID
+func (s *MemoryPostStore) Get(ctx context.Context, id string) (*Post, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	post, ok := s.posts[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	copied := *post
+	return &copied, nil
+}
+
+// List returns every post
+func (s *MemoryPostStore) List(ctx context.Context) ([]*Post, error) {
+	return s.snapshot(), nil
+}
+
+// Put creates or replaces a post
+func (s *MemoryPostStore) Put(ctx context.Context, post *Post) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	copied := *post
+	s.posts[post.ID] = &copied
+	return nil
+}
+
+// Delete removes a post
+func (s *MemoryPostStore) Delete(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.posts[id]; !ok {
+		return ErrNotFound
+	}
+	delete(s.posts, id)
+	return nil
+}
+
+// snapshot returns a copy of every post
+func (s *MemoryPostStore) snapshot() []*Post {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	posts := make([]*Post, 0, len(s.posts))
+	for _, post := range s.posts {
+		copied := *post
+		posts = append(posts, &copied)
+	}
+	return posts
+}
+
+// FilePostStore keeps posts in memory and persists them to a JSON file
+type FilePostStore struct {
+	*MemoryPostStore
+	path string
+	mu   sync.Mutex // serializes writes to the file
+}
+
+// NewFilePostStore loads the posts stored at path
+func NewFilePostStore(path string) (*FilePostStore, error) {
+	s := &FilePostStore{MemoryPostStore: NewMemoryPostStore(), path: path}
+
+	var posts []*Post
+	if err := readJSON(path, &posts); err != nil {
+		return nil, err
+	}
+	for _, post := range posts {
+		s.posts[post.ID] = post
+	}
+	return s, nil
+}
+
+// Put creates or replaces a post
+func (s *FilePostStore) Put(ctx context.Context, post *Post) error {
+	if err := s.MemoryPostStore.Put(ctx, post); err != nil {
+		return err
+	}
+	return s.save()
+}
+
+// Delete removes a post
+func (s *FilePostStore) Delete(ctx context.Context, id string) error {
+	if err := s.MemoryPostStore.Delete(ctx, id); err != nil {
+		return err
+	}
+	return s.save()
+}
+
+func (s *FilePostStore) save() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return writeJSON(s.path, s.snapshot())
+}
@@ -0,0 +1,195 @@
+package storage
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Session is a server-side login session referenced by a cookie
+type Session struct {
+	ID         string    `json:"id"`
+	UserID     string    `json:"user_id"`
+	Roles      []string  `json:"roles,omitempty"`
+	CSRFToken  string    `json:"csrf_token"`
+	UserAgent  string    `json:"user_agent,omitempty"`
+	IPAddress  string    `json:"ip_address,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+	ExpiresAt  time.Time `json:"expires_at"` // absolute expiry
+}
+
+// Expired reports whether the session is past its absolute expiry or has been idle too long
+func (s *Session) Expired(now time.Time, idleTimeout time.Duration) bool {
+	if !now.Before(s.ExpiresAt) {
+		return true
+	}
+	return idleTimeout > 0 && now.Sub(s.LastSeenAt) > idleTimeout
+}
+
+// SessionStore persists sessions
+type SessionStore interface {
+	Create(ctx context.Context, session *Session) error
+	Get(ctx context.Context, id string) (*Session, error)
+	ListByUser(ctx context.Context, userID string) ([]*Session, error)
+	Touch(ctx context.Context, id string, at time.Time) error
+	Delete(ctx context.Context, id string) error
+	DeleteExpired(ctx context.Context, now time.Time, idleTimeout time.Duration) (int, error)
+}
+
+// MemorySessionStore keeps sessions in memory
+type MemorySessionStore struct {
+	mu       sync.RWMutex
+	sessions map[string]*Session
+}
+
+// NewMemorySessionStore creates an empty in-memory session store
+func NewMemorySessionStore() *MemorySessionStore {
+	return &MemorySessionStore{sessions: make(map[string]*Session)}
+}
+
+// Create stores a new session
+func (s *MemorySessionStore) Create(ctx context.Context, session *Session) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	copied := *session
+	s.sessions[session.ID] = &copied
+	return nil
+}
+
+// Get returns the session with the given ID
+func (s *MemorySessionStore) Get(ctx context.Context, id string) (*Session, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	session, ok := s.sessions[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	copied := *session
+	return &copied, nil
+}
+
+// ListByUser returns the user's sessions, most recently used first
+func (s *MemorySessionStore) ListByUser(ctx context.Context, userID string) ([]*Session, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var sessions []*Session
+	for _, session := range s.sessions {
+		if session.UserID == userID {
+			copied := *session
+			sessions = append(sessions, &copied)
+		}
+	}
+	sort.Slice(sessions, func(i, j int) bool { return sessions[i].LastSeenAt.After(sessions[j].LastSeenAt) })
+	return sessions, nil
+}
+
+// Touch records activity on the session
+func (s *MemorySessionStore) Touch(ctx context.Context, id string, at time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	session, ok := s.sessions[id]
+	if !ok {
+		return ErrNotFound
+	}
+	session.LastSeenAt = at
+	return nil
+}
+
+// Delete removes the session
+func (s *MemorySessionStore) Delete(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.sessions[id]; !ok {
+		return ErrNotFound
+	}
+	delete(s.sessions, id)
+	return nil
+}
+
+// DeleteExpired removes expired sessions and returns how many were removed
+func (s *MemorySessionStore) DeleteExpired(ctx context.Context, now time.Time, idleTimeout time.Duration) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	removed := 0
+	for id, session := range s.sessions {
+		if session.Expired(now, idleTimeout) {
+			delete(s.sessions, id)
+			removed++
+		}
+	}
+	return removed, nil
+}
+
+// snapshot returns a copy of every session
+func (s *MemorySessionStore) snapshot() []*Session {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	sessions := make([]*Session, 0, len(s.sessions))
+	for _, session := range s.sessions {
+		copied := *session
+		sessions = append(sessions, &copied)
+	}
+	return sessions
+}
+
+// FileSessionStore keeps sessions in memory and persists them to a JSON file
+type FileSessionStore struct {
+	*MemorySessionStore
+	path string
+	mu   sync.Mutex // serializes writes to the file
+}
+
+// NewFileSessionStore loads the sessions stored at path
+func NewFileSessionStore(path string) (*FileSessionStore, error) {
+	s := &FileSessionStore{MemorySessionStore: NewMemorySessionStore(), path: path}
+
+	var sessions []*Session
+	if err := readJSON(path, &sessions); err != nil {
+		return nil, err
+	}
+	for _, session := range sessions {
+		s.sessions[session.ID] = session
+	}
+	return s, nil
+}
+
+// Create stores a new session
+func (s *FileSessionStore) Create(ctx context.Context, session *Session) error {
+	if err := s.MemorySessionStore.Create(ctx, session); err != nil {
+		return err
+	}
+	return s.save()
+}
+
+// Touch records activity on the session
+func (s *FileSessionStore) Touch(ctx context.Context, id string, at time.Time) error {
+	if err := s.MemorySessionStore.Touch(ctx, id, at); err != nil {
+		return err
+	}
+	return s.save()
+}
+
+// Delete removes the session
+func (s *FileSessionStore) Delete(ctx context.Context, id string) error {
+	if err := s.MemorySessionStore.Delete(ctx, id); err != nil {
+		return err
+	}
+	return s.save()
+}
+
+// DeleteExpired removes expired sessions and returns how many were removed
+func (s *FileSessionStore) DeleteExpired(ctx context.Context, now time.Time, idleTimeout time.Duration) (int, error) {
+	removed, err := s.MemorySessionStore.DeleteExpired(ctx, now, idleTimeout)
+	if err != nil || removed == 0 {
+		return removed, err
+	}
+	return removed, s.save()
+}
+
+func (s *FileSessionStore) save() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return writeJSON(s.path, s.snapshot())
+}
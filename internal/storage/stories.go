@@ -0,0 +1,141 @@
+package storage
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Story is a creator's story captured from Fansly. Stories expire on Fansly but are
+// kept here with their original expiry.
+type Story struct {
+	ID         string       `json:"id"`
+	CreatorID  string       `json:"creator_id"`
+	Media      []StoryMedia `json:"media,omitempty"`
+	CreatedAt  time.Time    `json:"created_at"`
+	ExpiresAt  time.Time    `json:"expires_at"`   // when the story disappears from Fansly
+	CapturedAt time.Time    `json:"captured_at"`  // when the story was first seen
+	LastSeenAt time.Time    `json:"last_seen_at"` // when the story was last seen on Fansly
+}
+
+// StoryMedia is an image or video shown in a story
+type StoryMedia struct {
+	ID       string `json:"id"`
+	Type     string `json:"type"`               // "image" or "video"
+	Location string `json:"location,omitempty"` // download URL on Fansly, valid while the story is visible
+	File     string `json:"file,omitempty"`     // local copy, relative to the stories directory
+}
+
+// Active reports whether the story is still visible on Fansly at t
+func (s *Story) Active(t time.Time) bool {
+	return t.Before(s.ExpiresAt)
+}
+
+// StoryStore persists captured stories
+type StoryStore interface {
+	Get(ctx context.Context, id string) (*Story, error)
+	// ListByCreator returns a creator's stories, newest first
+	ListByCreator(ctx context.Context, creatorID string) ([]*Story, error)
+	Put(ctx context.Context, story *Story) error
+}
+
+// MemoryStoryStore keeps stories in memory
+type MemoryStoryStore struct {
+	mu      sync.RWMutex
+	stories map[string]*Story
+}
+
+// NewMemoryStoryStore creates an empty in-memory story store
+func NewMemoryStoryStore() *MemoryStoryStore {
+	return &MemoryStoryStore{stories: make(map[string]*Story)}
+}
+
+// Get returns the story with the given ID
+func (s *MemoryStoryStore) Get(ctx context.Context, id string) (*Story, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	story, ok := s.stories[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	copied := *story
+	return &copied, nil
+}
+
+// ListByCreator returns a creator's stories, newest first
+func (s *MemoryStoryStore) ListByCreator(ctx context.Context, creatorID string) ([]*Story, error) {
+	s.mu.RLock()
+	var stories []*Story
+	for _, story := range s.stories {
+		if story.CreatorID == creatorID {
+			copied := *story
+			stories = append(stories, &copied)
+		}
+	}
+	s.mu.RUnlock()
+
+	sort.Slice(stories, func(i, j int) bool {
+		if !stories[i].CreatedAt.Equal(stories[j].CreatedAt) {
+			return stories[i].CreatedAt.After(stories[j].CreatedAt)
+		}
+		return stories[i].ID > stories[j].ID
+	})
+	return stories, nil
+}
+
+// Put creates or replaces a story
+func (s *MemoryStoryStore) Put(ctx context.Context, story *Story) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	copied := *story
+	s.stories[story.ID] = &copied
+	return nil
+}
+
+// snapshot returns a copy of every story
+func (s *MemoryStoryStore) snapshot() []*Story {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	stories := make([]*Story, 0, len(s.stories))
+	for _, story := range s.stories {
+		copied := *story
+		stories = append(stories, &copied)
+	}
+	return stories
+}
+
+// FileStoryStore keeps stories in memory and persists them to a JSON file
+type FileStoryStore struct {
+	*MemoryStoryStore
+	path string
+	mu   sync.Mutex // serializes writes to the file
+}
+
+// NewFileStoryStore loads the stories stored at path
+func NewFileStoryStore(path string) (*FileStoryStore, error) {
+	s := &FileStoryStore{MemoryStoryStore: NewMemoryStoryStore(), path: path}
+
+	var stories []*Story
+	if err := readJSON(path, &stories); err != nil {
+		return nil, err
+	}
+	for _, story := range stories {
+		s.stories[story.ID] = story
+	}
+	return s, nil
+}
+
+// Put creates or replaces a story
+func (s *FileStoryStore) Put(ctx context.Context, story *Story) error {
+	if err := s.MemoryStoryStore.Put(ctx, story); err != nil {
+		return err
+	}
+	return s.save()
+}
+
+func (s *FileStoryStore) save() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return writeJSON(s.path, s.snapshot())
+}
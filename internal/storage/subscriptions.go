@@ -0,0 +1,137 @@
+package storage
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Subscription is a paid subscription of the Fansly account to one of a creator's tiers
+type Subscription struct {
+	CreatorID      string    `json:"creator_id"`
+	TierID         string    `json:"tier_id"`
+	TierName       string    `json:"tier_name,omitempty"`
+	Price          int       `json:"price"` // paid per period, in the smallest currency unit, e.g. cents
+	Currency       string    `json:"currency"`
+	DurationMonths int       `json:"duration_months"`
+	AutoRenew      bool      `json:"auto_renew"`
+	SubscribedAt   time.Time `json:"subscribed_at,omitzero"`
+	RenewsAt       time.Time `json:"renews_at,omitzero"` // next renewal, or when access ends without auto-renewal; zero if unknown
+	SyncedAt       time.Time `json:"synced_at"`
+}
+
+// Active reports whether the subscription still grants access at t. A subscription
+// without a known renewal date is active.
+func (s *Subscription) Active(t time.Time) bool {
+	return s.RenewsAt.IsZero() || t.Before(s.RenewsAt)
+}
+
+// SubscriptionStore persists the account's subscriptions as last fetched from Fansly
+type SubscriptionStore interface {
+	// Get returns the subscription to a creator
+	Get(ctx context.Context, creatorID string) (*Subscription, error)
+	// List returns every subscription, soonest renewal first and those without a renewal date last
+	List(ctx context.Context) ([]*Subscription, error)
+	// Replace replaces every subscription, dropping those not in subs
+	Replace(ctx context.Context, subs []*Subscription) error
+}
+
+// MemorySubscriptionStore keeps subscriptions in memory
+type MemorySubscriptionStore struct {
+	mu   sync.RWMutex
+	subs map[string]*Subscription // keyed by creator ID
+}
+
+// NewMemorySubscriptionStore creates an empty in-memory subscription store
+func NewMemorySubscriptionStore() *MemorySubscriptionStore {
+	return &MemorySubscriptionStore{subs: make(map[string]*Subscription)}
+}
+
+// Get returns the subscription to a creator
+func (s *MemorySubscriptionStore) Get(ctx context.Context, creatorID string) (*Subscription, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	sub, ok := s.subs[creatorID]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	copied := *sub
+	return &copied, nil
+}
+
+// List returns every subscription, soonest renewal first and those without a
+// renewal date last
+func (s *MemorySubscriptionStore) List(ctx context.Context) ([]*Subscription, error) {
+	subs := s.snapshot()
+	sort.Slice(subs, func(i, j int) bool {
+		if subs[i].RenewsAt.IsZero() != subs[j].RenewsAt.IsZero() {
+			return subs[j].RenewsAt.IsZero()
+		}
+		if !subs[i].RenewsAt.Equal(subs[j].RenewsAt) {
+			return subs[i].RenewsAt.Before(subs[j].RenewsAt)
+		}
+		return subs[i].CreatorID < subs[j].CreatorID
+	})
+	return subs, nil
+}
+
+// Replace replaces every subscription, dropping those not in subs
+func (s *MemorySubscriptionStore) Replace(ctx context.Context, subs []*Subscription) error {
+	replaced := make(map[string]*Subscription, len(subs))
+	for _, sub := range subs {
+		copied := *sub
+		replaced[sub.CreatorID] = &copied
+	}
+	s.mu.Lock()
+	s.subs = replaced
+	s.mu.Unlock()
+	return nil
+}
+
+// snapshot returns a copy of every subscription
+func (s *MemorySubscriptionStore) snapshot() []*Subscription {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	subs := make([]*Subscription, 0, len(s.subs))
+	for _, sub := range s.subs {
+		copied := *sub
+		subs = append(subs, &copied)
+	}
+	return subs
+}
+
+// FileSubscriptionStore keeps subscriptions in memory and persists them to a JSON file
+type FileSubscriptionStore struct {
+	*MemorySubscriptionStore
+	path string
+	mu   sync.Mutex // serializes writes to the file
+}
+
+// NewFileSubscriptionStore loads the subscriptions stored at path
+func NewFileSubscriptionStore(path string) (*FileSubscriptionStore, error) {
+	s := &FileSubscriptionStore{MemorySubscriptionStore: NewMemorySubscriptionStore(), path: path}
+
+	var subs []*Subscription
+	if err := readJSON(path, &subs); err != nil {
+		return nil, err
+	}
+	for _, sub := range subs {
+		s.subs[sub.CreatorID] = sub
+	}
+	return s, nil
+}
+
+// Replace replaces every subscription, dropping those not in subs
+func (s *FileSubscriptionStore) Replace(ctx context.Context, subs []*Subscription) error {
+	if err := s.MemorySubscriptionStore.Replace(ctx, subs); err != nil {
+		return err
+	}
+	return s.save()
+}
+
+func (s *FileSubscriptionStore) save() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return writeJSON(s.path, s.snapshot())
+}
@@ -0,0 +1,139 @@
+package storage
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// User is an account of our API, provisioned on first login through an identity provider
+type User struct {
+	ID          string    `json:"id"`
+	Provider    string    `json:"provider"` // identity provider name
+	Subject     string    `json:"subject"`  // user ID at the identity provider
+	Email       string    `json:"email,omitempty"`
+	Name        string    `json:"name,omitempty"`
+	Roles       []string  `json:"roles,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+	LastLoginAt time.Time `json:"last_login_at"`
+}
+
+// UserStore persists users
+type UserStore interface {
+	Create(ctx context.Context, user *User) error
+	Get(ctx context.Context, id string) (*User, error)
+	GetByIdentity(ctx context.Context, provider, subject string) (*User, error)
+	Update(ctx context.Context, user *User) error
+}
+
+// MemoryUserStore keeps users in memory
+type MemoryUserStore struct {
+	mu    sync.RWMutex
+	users map[string]*User
+}
+
+// NewMemoryUserStore creates an empty in-memory user store
+func NewMemoryUserStore() *MemoryUserStore {
+	return &MemoryUserStore{users: make(map[string]*User)}
+}
+
+// Create stores a new user
+func (s *MemoryUserStore) Create(ctx context.Context, user *User) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	copied := *user
+	s.users[user.ID] = &copied
+	return nil
+}
+
+// Get returns the user with the given ID
+func (s *MemoryUserStore) Get(ctx context.Context, id string) (*User, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	user, ok := s.users[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	copied := *user
+	return &copied, nil
+}
+
+// GetByIdentity returns the user linked to the identity provider subject
+func (s *MemoryUserStore) GetByIdentity(ctx context.Context, provider, subject string) (*User, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, user := range s.users {
+		if user.Provider == provider && user.Subject == subject {
+			copied := *user
+			return &copied, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+// Update replaces an existing user
+func (s *MemoryUserStore) Update(ctx context.Context, user *User) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.users[user.ID]; !ok {
+		return ErrNotFound
+	}
+	copied := *user
+	s.users[user.ID] = &copied
+	return nil
+}
+
+// snapshot returns a copy of every user
+func (s *MemoryUserStore) snapshot() []*User {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	users := make([]*User, 0, len(s.users))
+	for _, user := range s.users {
+		copied := *user
+		users = append(users, &copied)
+	}
+	return users
+}
+
+// FileUserStore keeps users in memory and persists them to a JSON file
+type FileUserStore struct {
+	*MemoryUserStore
+	path string
+	mu   sync.Mutex // serializes writes to the file
+}
+
+// NewFileUserStore loads the users stored at path
+func NewFileUserStore(path string) (*FileUserStore, error) {
+	s := &FileUserStore{MemoryUserStore: NewMemoryUserStore(), path: path}
+
+	var users []*User
+	if err := readJSON(path, &users); err != nil {
+		return nil, err
+	}
+	for _, user := range users {
+		s.users[user.ID] = user
+	}
+	return s, nil
+}
+
+// Create stores a new user
+func (s *FileUserStore) Create(ctx context.Context, user *User) error {
+	if err := s.MemoryUserStore.Create(ctx, user); err != nil {
+		return err
+	}
+	return s.save()
+}
+
+// Update replaces an existing user
+func (s *FileUserStore) Update(ctx context.Context, user *User) error {
+	if err := s.MemoryUserStore.Update(ctx, user); err != nil {
+		return err
+	}
+	return s.save()
+}
+
+func (s *FileUserStore) save() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return writeJSON(s.path, s.snapshot())
+}
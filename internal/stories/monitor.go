@@ -0,0 +1,265 @@
+// Package stories captures creators' stories from Fansly before they expire
+package stories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"fansly-api/internal/health"
+	"fansly-api/internal/logger"
+	"fansly-api/internal/media"
+	"fansly-api/internal/metrics"
+	"fansly-api/internal/storage"
+)
+
+// minPollInterval is the shortest time between two polls of the same creator
+const minPollInterval = time.Minute
+
+// defaultLifetime is how long a story stays visible when Fansly doesn't say
+const defaultLifetime = 24 * time.Hour
+
+// Source lists creators' stories on Fansly
+type Source interface {
+	// ListStories returns the stories of a creator that are currently visible
+	ListStories(ctx context.Context, creatorID string) ([]storage.Story, error)
+}
+
+// CreatorsFunc returns the IDs of the creators whose stories are captured
+type CreatorsFunc func(ctx context.Context) ([]string, error)
+
+// Monitor polls each creator's stories and stores new ones as soon as they are seen,
+// downloading their media below a directory while the story is still visible.
+// Creators are polled every interval, and more often while one of their stories is
+// about to expire: the delay is halved as expiry approaches, down to minPollInterval,
+// so a story's final state is recorded and failed polls are retried while it is still
+// visible. A download that receives no data for media.IdleTimeout fails, so a stalled
+// download doesn't hold up polling.
+type Monitor struct {
+	source      Source
+	fetcher     media.Fetcher
+	store       storage.StoryStore
+	creators    CreatorsFunc
+	dir         string
+	log         logger.Logger
+	interval    time.Duration
+	idleTimeout time.Duration
+	heartbeat   *health.Heartbeat
+	now         func() time.Time
+
+	due       map[string]time.Time // creator ID -> next poll
+	refreshAt time.Time            // next refresh of the creator list
+}
+
+// NewMonitor creates a monitor that polls each creator at least every interval and
+// saves story media below dir
+func NewMonitor(source Source, fetcher media.Fetcher, store storage.StoryStore, creators CreatorsFunc, dir string, log logger.Logger, interval time.Duration) *Monitor {
+	return &Monitor{
+		source:      source,
+		fetcher:     fetcher,
+		store:       store,
+		creators:    creators,
+		dir:         dir,
+		log:         log,
+		interval:    interval,
+		idleTimeout: media.IdleTimeout,
+		// Allow a pass to fail twice before reporting the monitor unhealthy
+		heartbeat: health.NewHeartbeat(3 * interval),
+		now:       time.Now,
+		due:       make(map[string]time.Time),
+	}
+}
+
+// Heartbeat beats after every successful pass
+func (m *Monitor) Heartbeat() *health.Heartbeat {
+	return m.heartbeat
+}
+
+// Run polls creators as they become due until ctx is cancelled
+func (m *Monitor) Run(ctx context.Context) {
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+		}
+		if err := m.PollDue(ctx); err != nil && ctx.Err() == nil {
+			m.log.Warnf("Story poll failed: %v", err)
+		}
+		timer.Reset(m.untilNextPoll())
+	}
+}
+
+// PollDue polls every creator whose next poll is due. The creator list is refreshed
+// every interval; new creators are due immediately. A failing creator doesn't stop
+// the others; the errors are returned together.
+func (m *Monitor) PollDue(ctx context.Context) error {
+	var errs []error
+	now := m.now()
+	if !now.Before(m.refreshAt) {
+		if err := m.refreshCreators(ctx, now); err != nil {
+			// Keep polling the known creators and retry the list soon
+			errs = append(errs, err)
+			m.refreshAt = now.Add(minPollInterval)
+		} else {
+			m.refreshAt = now.Add(m.interval)
+		}
+	}
+
+	var pending []string
+	for id, next := range m.due {
+		if !next.After(now) {
+			pending = append(pending, id)
+		}
+	}
+	metrics.MonitorQueueDepth.Set(float64(len(pending)))
+
+	for i, id := range pending {
+		captured, err := m.pollCreator(ctx, id)
+		switch {
+		case err != nil:
+			metrics.MonitorPolls.Inc("error")
+			errs = append(errs, fmt.Errorf("creator %s: %w", id, err))
+		case captured > 0:
+			metrics.MonitorPolls.Inc("new_content")
+			m.log.Infof("Captured %d new stories of creator %s", captured, id)
+		default:
+			metrics.MonitorPolls.Inc("no_change")
+		}
+		m.due[id] = m.nextPoll(ctx, id)
+		metrics.MonitorQueueDepth.Set(float64(len(pending) - i - 1))
+	}
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+
+	m.heartbeat.Beat()
+	return nil
+}
+
+// refreshCreators replaces the polled creators with the current list
+func (m *Monitor) refreshCreators(ctx context.Context, now time.Time) error {
+	ids, err := m.creators(ctx)
+	if err != nil {
+		return fmt.Errorf("error listing creators: %w", err)
+	}
+	due := make(map[string]time.Time, len(ids))
+	for _, id := range ids {
+		if next, ok := m.due[id]; ok {
+			due[id] = next
+		} else {
+			due[id] = now
+		}
+	}
+	m.due = due
+	return nil
+}
+
+// pollCreator stores a creator's visible stories, downloads the media not saved
+// yet and returns how many stories were new. A story is stored even when some of
+// its media fail to download; they are retried on the next poll.
+func (m *Monitor) pollCreator(ctx context.Context, creatorID string) (int, error) {
+	live, err := m.source.ListStories(ctx, creatorID)
+	if err != nil {
+		return 0, fmt.Errorf("error listing stories: %w", err)
+	}
+
+	now := m.now().UTC()
+	captured := 0
+	var errs []error
+	for _, story := range live {
+		story.CreatorID = creatorID
+		story.LastSeenAt = now
+		if story.ExpiresAt.IsZero() {
+			story.ExpiresAt = story.CreatedAt.Add(defaultLifetime)
+		}
+
+		stored, err := m.store.Get(ctx, story.ID)
+		switch {
+		case errors.Is(err, storage.ErrNotFound):
+			story.CapturedAt = now
+			captured++
+		case err != nil:
+			return captured, err
+		default:
+			story.CapturedAt = stored.CapturedAt
+		}
+
+		for i := range story.Media {
+			item := &story.Media[i]
+			if stored != nil {
+				item.File = savedFile(stored.Media, item.ID)
+			}
+			// Media Fansly gives no URL for can't be downloaded
+			if item.File != "" || item.Location == "" {
+				continue
+			}
+			file, err := m.download(ctx, creatorID, *item)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("story %s: error downloading media %s: %w", story.ID, item.ID, err))
+				continue
+			}
+			item.File = file
+		}
+		if err := m.store.Put(ctx, &story); err != nil {
+			return captured, fmt.Errorf("error storing story: %w", err)
+		}
+	}
+	return captured, errors.Join(errs...)
+}
+
+// savedFile returns the local copy of the media with the given ID, if any
+func savedFile(saved []storage.StoryMedia, id string) string {
+	for _, m := range saved {
+		if m.ID == id {
+			return m.File
+		}
+	}
+	return ""
+}
+
+// download saves a story's media file and returns its path relative to the
+// stories directory
+func (m *Monitor) download(ctx context.Context, creatorID string, item storage.StoryMedia) (string, error) {
+	file, err := media.Path(creatorID, item.ID, item.Type, item.Location)
+	if err != nil {
+		return "", err
+	}
+	if _, err := media.Save(ctx, m.fetcher, item.Location, filepath.Join(m.dir, file), m.idleTimeout, nil); err != nil {
+		return "", err
+	}
+	return filepath.ToSlash(file), nil
+}
+
+// nextPoll returns when a creator should be polled next, based on the expiry of
+// their stories that are still visible
+func (m *Monitor) nextPoll(ctx context.Context, creatorID string) time.Time {
+	now := m.now()
+	delay := m.interval
+	stories, err := m.store.ListByCreator(ctx, creatorID)
+	if err != nil {
+		m.log.Warnf("Failed to list stories of creator %s: %v", creatorID, err)
+	}
+	for _, story := range stories {
+		if story.Active(now) {
+			delay = min(delay, max(story.ExpiresAt.Sub(now)/2, minPollInterval))
+		}
+	}
+	return now.Add(delay)
+}
+
+// untilNextPoll returns the time until the next creator is due, or until the
+// creator list is refreshed
+func (m *Monitor) untilNextPoll() time.Duration {
+	next := m.refreshAt
+	for _, due := range m.due {
+		if due.Before(next) {
+			next = due
+		}
+	}
+	return max(next.Sub(m.now()), 0)
+}
@@ -0,0 +1,124 @@
+package stories
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"fansly-api/internal/logger"
+	"fansly-api/internal/media"
+	"fansly-api/internal/storage"
+)
+
+type fakeSource struct {
+	stories []storage.Story
+}
+
+func (f *fakeSource) ListStories(ctx context.Context, creatorID string) ([]storage.Story, error) {
+	return append([]storage.Story(nil), f.stories...), nil
+}
+
+// fakeFetcher writes the URL as the file content; URLs containing "fail" fail and
+// URLs containing "hang" block until the download is aborted
+type fakeFetcher struct {
+	calls int
+}
+
+func (f *fakeFetcher) Download(ctx context.Context, mediaURL string, w io.Writer) (int64, error) {
+	f.calls++
+	if strings.Contains(mediaURL, "fail") {
+		return 0, errors.New("upstream error")
+	}
+	if strings.Contains(mediaURL, "hang") {
+		<-ctx.Done()
+		return 0, ctx.Err()
+	}
+	n, err := io.WriteString(w, mediaURL)
+	return int64(n), err
+}
+
+func TestPollDueDownloadsMedia(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	created := time.Now().Add(-time.Hour).UTC()
+	source := &fakeSource{stories: []storage.Story{{
+		ID:        "s1",
+		CreatedAt: created,
+		Media: []storage.StoryMedia{
+			{ID: "m1", Type: "image", Location: "https://cdn.example.com/m1.png?sig=abc"},
+			{ID: "m2", Type: "video", Location: "https://cdn.example.com/fail"},
+		},
+	}}}
+	fetcher := &fakeFetcher{}
+	store := storage.NewMemoryStoryStore()
+	creators := func(ctx context.Context) ([]string, error) { return []string{"c1"}, nil }
+	m := NewMonitor(source, fetcher, store, creators, dir, logger.New(), time.Hour)
+
+	// The story is stored even though one of its media failed
+	if err := m.PollDue(ctx); err == nil {
+		t.Fatal("expected the failed download to be reported")
+	}
+	story, err := store.Get(ctx, "s1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if story.Media[0].File != "c1/m1.png" || story.Media[1].File != "" {
+		t.Fatalf("files %q, %q", story.Media[0].File, story.Media[1].File)
+	}
+	if data, err := os.ReadFile(filepath.Join(dir, "c1", "m1.png")); err != nil || string(data) != source.stories[0].Media[0].Location {
+		t.Fatalf("downloaded file: %q, %v", data, err)
+	}
+	if !story.ExpiresAt.Equal(created.Add(defaultLifetime)) {
+		t.Fatalf("expires_at %v", story.ExpiresAt)
+	}
+
+	// The next poll only retries the media not saved yet
+	source.stories[0].Media[1].Location = "https://cdn.example.com/m2"
+	fetcher.calls = 0
+	m.due["c1"] = time.Time{}
+	if err := m.PollDue(ctx); err != nil {
+		t.Fatal(err)
+	}
+	story, _ = store.Get(ctx, "s1")
+	if fetcher.calls != 1 || story.Media[0].File != "c1/m1.png" || story.Media[1].File != "c1/m2.mp4" {
+		t.Fatalf("%d downloads, files %q, %q", fetcher.calls, story.Media[0].File, story.Media[1].File)
+	}
+}
+
+func TestPollDueAbortsStalledDownloads(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	source := &fakeSource{stories: []storage.Story{{
+		ID:        "s1",
+		CreatedAt: time.Now().Add(-time.Hour).UTC(),
+		Media: []storage.StoryMedia{
+			{ID: "m1", Type: "image", Location: "https://cdn.example.com/hang.jpg"},
+			{ID: "m2", Type: "image", Location: "https://cdn.example.com/m2.jpg"},
+		},
+	}}}
+	store := storage.NewMemoryStoryStore()
+	creators := func(ctx context.Context) ([]string, error) { return []string{"c1"}, nil }
+	m := NewMonitor(source, &fakeFetcher{}, store, creators, dir, logger.New(), time.Hour)
+	m.idleTimeout = 20 * time.Millisecond
+
+	err := m.PollDue(ctx)
+	if !errors.Is(err, media.ErrStalled) {
+		t.Fatalf("error %v, want a stalled download", err)
+	}
+	story, err := store.Get(ctx, "s1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if story.Media[0].File != "" || story.Media[1].File != "c1/m2.jpg" {
+		t.Fatalf("files %q, %q", story.Media[0].File, story.Media[1].File)
+	}
+	// The aborted download leaves no file behind
+	if entries, _ := os.ReadDir(filepath.Join(dir, "c1")); len(entries) != 1 {
+		t.Fatalf("%d files in the creator directory, want 1", len(entries))
+	}
+}
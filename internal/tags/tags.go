@@ -0,0 +1,235 @@
+// Package tags extracts hashtags and @mentions from post text and indexes them
+// for browsing by creator, by hashtag and by who mentions whom
+package tags
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+var (
+	// A tag or mention must not follow a word character, so e-mail addresses and
+	// URL fragments such as example.com/#top aren't picked up
+	hashtagPattern = regexp.MustCompile(`(?:^|[^\p{L}\p{N}_&/#])#([\p{L}\p{N}_]{1,100})`)
+	mentionPattern = regexp.MustCompile(`(?:^|[^\p{L}\p{N}_.@/])@([\p{L}\p{N}_]{1,50})`)
+)
+
+// Extract returns the distinct hashtags and mentioned usernames in text, lower-cased
+// and without their # or @ prefix, in order of first appearance
+func Extract(text string) (hashtags, mentions []string) {
+	for _, m := range hashtagPattern.FindAllStringSubmatch(text, -1) {
+		// Skip numbers such as "#1"
+		if strings.IndexFunc(m[1], func(r rune) bool { return !unicode.IsDigit(r) }) >= 0 {
+			hashtags = append(hashtags, m[1])
+		}
+	}
+	for _, m := range mentionPattern.FindAllStringSubmatch(text, -1) {
+		mentions = append(mentions, m[1])
+	}
+	return Normalize(hashtags), Normalize(mentions)
+}
+
+// Normalize lower-cases names, strips # and @ prefixes and removes duplicates and empty names
+func Normalize(names []string) []string {
+	seen := make(map[string]bool, len(names))
+	var out []string
+	for _, name := range names {
+		name = strings.ToLower(strings.TrimLeft(strings.TrimSpace(name), "#@"))
+		if name != "" && !seen[name] {
+			seen[name] = true
+			out = append(out, name)
+		}
+	}
+	return out
+}
+
+// Count is a name and how often it occurs
+type Count struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+// Mention is an edge of the mention graph: a creator's posts mentioning a username
+type Mention struct {
+	CreatorID string `json:"creator_id"`
+	Username  string `json:"username"`
+	Count     int    `json:"count"` // number of posts with the mention
+}
+
+type entry struct {
+	creatorID string
+	createdAt time.Time
+	hashtags  []string
+	mentions  []string
+}
+
+// Index maps hashtags and mentions to posts. It is safe for concurrent use.
+type Index struct {
+	mu       sync.RWMutex
+	posts    map[string]entry               // post ID -> entry
+	byTag    map[string]map[string]struct{} // hashtag -> post IDs
+	tagCount map[string]map[string]int      // creator ID -> hashtag -> posts
+	mentions map[string]map[string]int      // creator ID -> username -> posts
+}
+
+// NewIndex creates an empty index
+func NewIndex() *Index {
+	return &Index{
+		posts:    make(map[string]entry),
+		byTag:    make(map[string]map[string]struct{}),
+		tagCount: make(map[string]map[string]int),
+		mentions: make(map[string]map[string]int),
+	}
+}
+
+// Upsert indexes the hashtags and mentions of a post, replacing what was indexed for it before
+func (idx *Index) Upsert(postID, creatorID string, createdAt time.Time, hashtags, mentions []string) {
+	e := entry{creatorID: creatorID, createdAt: createdAt, hashtags: Normalize(hashtags), mentions: Normalize(mentions)}
+
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	idx.remove(postID)
+	idx.posts[postID] = e
+	for _, tag := range e.hashtags {
+		if idx.byTag[tag] == nil {
+			idx.byTag[tag] = make(map[string]struct{})
+		}
+		idx.byTag[tag][postID] = struct{}{}
+		increment(idx.tagCount, creatorID, tag, 1)
+	}
+	for _, username := range e.mentions {
+		increment(idx.mentions, creatorID, username, 1)
+	}
+}
+
+// Delete removes a post from the index. Deleting an unknown post does nothing.
+func (idx *Index) Delete(postID string) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	idx.remove(postID)
+}
+
+func (idx *Index) remove(postID string) {
+	e, ok := idx.posts[postID]
+	if !ok {
+		return
+	}
+	for _, tag := range e.hashtags {
+		delete(idx.byTag[tag], postID)
+		if len(idx.byTag[tag]) == 0 {
+			delete(idx.byTag, tag)
+		}
+		increment(idx.tagCount, e.creatorID, tag, -1)
+	}
+	for _, username := range e.mentions {
+		increment(idx.mentions, e.creatorID, username, -1)
+	}
+	delete(idx.posts, postID)
+}
+
+// increment adds delta to counts[outer][inner], dropping counts that reach zero
+func increment(counts map[string]map[string]int, outer, inner string, delta int) {
+	if counts[outer] == nil {
+		counts[outer] = make(map[string]int)
+	}
+	counts[outer][inner] += delta
+	if counts[outer][inner] <= 0 {
+		delete(counts[outer], inner)
+		if len(counts[outer]) == 0 {
+			delete(counts, outer)
+		}
+	}
+}
+
+// TopHashtags returns a creator's most used hashtags, most used first
+func (idx *Index) TopHashtags(creatorID string, limit int) []Count {
+	idx.mu.RLock()
+	counts := make([]Count, 0, len(idx.tagCount[creatorID]))
+	for tag, n := range idx.tagCount[creatorID] {
+		counts = append(counts, Count{Name: tag, Count: n})
+	}
+	idx.mu.RUnlock()
+
+	sort.Slice(counts, func(i, j int) bool {
+		if counts[i].Count != counts[j].Count {
+			return counts[i].Count > counts[j].Count
+		}
+		return counts[i].Name < counts[j].Name
+	})
+	if limit > 0 && len(counts) > limit {
+		counts = counts[:limit]
+	}
+	return counts
+}
+
+// PostsByHashtag returns one page of the IDs of posts with a hashtag, newest
+// first, and the total number of such posts
+func (idx *Index) PostsByHashtag(tag string, limit, offset int) (ids []string, total int) {
+	tags := Normalize([]string{tag})
+	if len(tags) == 0 {
+		return nil, 0
+	}
+
+	idx.mu.RLock()
+	type post struct {
+		id        string
+		createdAt time.Time
+	}
+	posts := make([]post, 0, len(idx.byTag[tags[0]]))
+	for id := range idx.byTag[tags[0]] {
+		posts = append(posts, post{id: id, createdAt: idx.posts[id].createdAt})
+	}
+	idx.mu.RUnlock()
+
+	sort.Slice(posts, func(i, j int) bool {
+		if !posts[i].createdAt.Equal(posts[j].createdAt) {
+			return posts[i].createdAt.After(posts[j].createdAt)
+		}
+		return posts[i].id < posts[j].id
+	})
+	total = len(posts)
+	if offset > total {
+		offset = total
+	}
+	end := offset + limit
+	if limit <= 0 || end > total {
+		end = total
+	}
+	for _, p := range posts[offset:end] {
+		ids = append(ids, p.id)
+	}
+	return ids, total
+}
+
+// Mentions returns the edges of the mention graph, most frequent first. When
+// creatorID is set, only mentions made by that creator are returned.
+func (idx *Index) Mentions(creatorID string, minCount int) []Mention {
+	idx.mu.RLock()
+	var edges []Mention
+	for from, usernames := range idx.mentions {
+		if creatorID != "" && from != creatorID {
+			continue
+		}
+		for username, n := range usernames {
+			if n >= minCount {
+				edges = append(edges, Mention{CreatorID: from, Username: username, Count: n})
+			}
+		}
+	}
+	idx.mu.RUnlock()
+
+	sort.Slice(edges, func(i, j int) bool {
+		if edges[i].Count != edges[j].Count {
+			return edges[i].Count > edges[j].Count
+		}
+		if edges[i].CreatorID != edges[j].CreatorID {
+			return edges[i].CreatorID < edges[j].CreatorID
+		}
+		return edges[i].Username < edges[j].Username
+	})
+	return edges
+}
@@ -0,0 +1,31 @@
+package tags
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestExtract(t *testing.T) {
+	tests := []struct {
+		text     string
+		hashtags []string
+		mentions []string
+	}{
+		{"New set out now #Photoshoot #beach with @Alice", []string{"photoshoot", "beach"}, []string{"alice"}},
+		{"#Beach #beach #BEACH @bob @Bob", []string{"beach"}, []string{"bob"}},
+		{"#café (@zoë)", []string{"café"}, []string{"zoë"}},
+		// E-mail addresses aren't mentions
+		{"write to user@example.com", nil, nil},
+		// URL fragments and HTML entities aren't hashtags
+		{"see example.com/#top or &#39;quoted&#39;", nil, nil},
+		// Numbers aren't hashtags, but tags containing digits are
+		{"#123 #1st", []string{"1st"}, nil},
+		{"##double @@double", nil, nil},
+	}
+	for _, tt := range tests {
+		hashtags, mentions := Extract(tt.text)
+		if !slices.Equal(hashtags, tt.hashtags) || !slices.Equal(mentions, tt.mentions) {
+			t.Errorf("Extract(%q) = %q, %q; want %q, %q", tt.text, hashtags, mentions, tt.hashtags, tt.mentions)
+		}
+	}
+}
@@ -0,0 +1,230 @@
+// Package timeline mirrors creators' posts from Fansly into local storage
+package timeline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"fansly-api/internal/events"
+	"fansly-api/internal/health"
+	"fansly-api/internal/logger"
+	"fansly-api/internal/storage"
+	"fansly-api/internal/tags"
+)
+
+// pageSize is the number of posts requested from Fansly at a time
+const pageSize = 50
+
+// Source lists creators' posts on Fansly
+type Source interface {
+	// ListPosts returns up to limit posts of a creator older than the post with ID
+	// before (or the newest when before is empty), newest first
+	ListPosts(ctx context.Context, creatorID, before string, limit int) ([]storage.Post, error)
+}
+
+// CreatorsFunc returns the IDs of the creators whose posts are synced
+type CreatorsFunc func(ctx context.Context) ([]string, error)
+
+// Syncer periodically mirrors the posts of each creator into a store, with the
+// hashtags and mentions extracted from their text, and publishes
+// a post.synced event for every new or changed post and a post.deleted event for
+// every post removed from Fansly. Until a creator's full history has been synced,
+// each pass pages back to the oldest post; later passes stop at the first page
+// containing posts already stored.
+type Syncer struct {
+	source    Source
+	posts     storage.PostStore
+	state     storage.CreatorSyncStore
+	creators  CreatorsFunc
+	events    *events.Bus
+	log       logger.Logger
+	interval  time.Duration
+	heartbeat *health.Heartbeat
+	now       func() time.Time
+}
+
+// NewSyncer creates a syncer that runs every interval
+func NewSyncer(source Source, posts storage.PostStore, state storage.CreatorSyncStore, creators CreatorsFunc, bus *events.Bus, log logger.Logger, interval time.Duration) *Syncer {
+	return &Syncer{
+		source:   source,
+		posts:    posts,
+		state:    state,
+		creators: creators,
+		events:   bus,
+		log:      log,
+		interval: interval,
+		// Allow a pass to fail twice before reporting the syncer unhealthy
+		heartbeat: health.NewHeartbeat(3 * interval),
+		now:       time.Now,
+	}
+}
+
+// Heartbeat beats after every successful pass
+func (s *Syncer) Heartbeat() *health.Heartbeat {
+	return s.heartbeat
+}
+
+// Run syncs immediately and then every interval until ctx is cancelled
+func (s *Syncer) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	for {
+		if err := s.SyncOnce(ctx); err != nil && ctx.Err() == nil {
+			s.log.Warnf("Post sync failed: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// SyncOnce syncs every creator. A failing creator doesn't stop the others; the
+// errors are returned together.
+func (s *Syncer) SyncOnce(ctx context.Context) error {
+	creators, err := s.creators(ctx)
+	if err != nil {
+		return fmt.Errorf("error listing creators: %w", err)
+	}
+	all, err := s.posts.List(ctx)
+	if err != nil {
+		return fmt.Errorf("error listing stored posts: %w", err)
+	}
+	stored := make(map[string]map[string]*storage.Post)
+	for _, post := range all {
+		if stored[post.CreatorID] == nil {
+			stored[post.CreatorID] = make(map[string]*storage.Post)
+		}
+		stored[post.CreatorID][post.ID] = post
+	}
+
+	var errs []error
+	synced, deleted := 0, 0
+	for _, id := range creators {
+		n, d, err := s.syncCreator(ctx, id, stored[id])
+		synced += n
+		deleted += d
+		if err != nil {
+			errs = append(errs, fmt.Errorf("creator %s: %w", id, err))
+		}
+	}
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+
+	s.heartbeat.Beat()
+	s.log.Debugf("Post sync finished: %d creators, %d posts synced, %d deleted", len(creators), synced, deleted)
+	return nil
+}
+
+// syncCreator stores the new and changed posts of a creator, removes stored posts
+// that are gone from Fansly, and returns how many posts were synced and deleted
+func (s *Syncer) syncCreator(ctx context.Context, creatorID string, stored map[string]*storage.Post) (synced, deleted int, err error) {
+	state, err := s.state.Get(ctx, creatorID)
+	if err != nil && !errors.Is(err, storage.ErrNotFound) {
+		return 0, 0, err
+	}
+	historySynced := state != nil && state.PostsSyncedAt != nil
+
+	now := s.now().UTC()
+	seen := make(map[string]bool)
+	var oldest time.Time // creation time of the oldest post fetched in this pass
+	reachedEnd := false
+	before := ""
+	for {
+		page, err := s.source.ListPosts(ctx, creatorID, before, pageSize)
+		if err != nil {
+			return synced, deleted, fmt.Errorf("error listing posts: %w", err)
+		}
+
+		known := 0
+		for i := range page {
+			post := &page[i]
+			post.CreatorID = creatorID
+			extractTags(post)
+			seen[post.ID] = true
+			if oldest.IsZero() || post.CreatedAt.Before(oldest) {
+				oldest = post.CreatedAt
+			}
+			if previous, ok := stored[post.ID]; ok {
+				known++
+				if unchanged(previous, post) {
+					continue
+				}
+			}
+			post.SyncedAt = now
+			if err := s.posts.Put(ctx, post); err != nil {
+				return synced, deleted, fmt.Errorf("error storing post: %w", err)
+			}
+			s.events.Publish(events.PostSynced, map[string]interface{}{"post_id": post.ID, "creator_id": creatorID})
+			synced++
+		}
+
+		if len(page) < pageSize {
+			reachedEnd = true
+			break
+		}
+		// Stop where the previous pass left off
+		if historySynced && known > 0 {
+			break
+		}
+		before = page[len(page)-1].ID
+	}
+
+	// Stored posts within the time range this pass covered that Fansly no longer
+	// returns have been deleted. Posts as old as the oldest one fetched may still
+	// be on the next page, so they are left alone.
+	for id, post := range stored {
+		if seen[id] || !(reachedEnd || post.CreatedAt.After(oldest)) {
+			continue
+		}
+		if err := s.posts.Delete(ctx, id); err != nil && !errors.Is(err, storage.ErrNotFound) {
+			return synced, deleted, fmt.Errorf("error deleting post: %w", err)
+		}
+		s.events.Publish(events.PostDeleted, map[string]interface{}{"post_id": id, "creator_id": creatorID})
+		deleted++
+	}
+
+	count := len(seen)
+	for id := range stored {
+		if !seen[id] {
+			count++
+		}
+	}
+	count -= deleted
+	err = s.state.Update(ctx, creatorID, func(state *storage.CreatorSync) {
+		state.Posts = count
+		if reachedEnd || historySynced {
+			state.PostsSyncedAt = &now
+		}
+		if state.Status == storage.SyncStatusNeverSynced {
+			state.Status = storage.SyncStatusIdle
+		}
+	})
+	if err != nil {
+		return synced, deleted, fmt.Errorf("error storing sync state: %w", err)
+	}
+	return synced, deleted, nil
+}
+
+// extractTags adds the hashtags and mentions written in a post's text to those
+// Fansly reports for it
+func extractTags(post *storage.Post) {
+	hashtags, mentions := tags.Extract(post.Text)
+	post.Hashtags = tags.Normalize(append(append([]string(nil), post.Hashtags...), hashtags...))
+	post.Mentions = tags.Normalize(append(append([]string(nil), post.Mentions...), mentions...))
+}
+
+// unchanged reports whether a fetched post matches the stored one
+func unchanged(stored, fetched *storage.Post) bool {
+	return stored.Text == fetched.Text &&
+		stored.CreatedAt.Equal(fetched.CreatedAt) &&
+		slices.Equal(stored.Hashtags, fetched.Hashtags) &&
+		slices.Equal(stored.Mentions, fetched.Mentions) &&
+		slices.Equal(stored.Media, fetched.Media) &&
+		slices.Equal(stored.TierIDs, fetched.TierIDs)
+}
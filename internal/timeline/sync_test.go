@@ -0,0 +1,164 @@
+package timeline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+
+	"fansly-api/internal/events"
+	"fansly-api/internal/logger"
+	"fansly-api/internal/storage"
+)
+
+// fakeSource serves a creator's posts newest first, optionally failing from a page on
+type fakeSource struct {
+	posts    []storage.Post // newest first
+	calls    int
+	failPage int // 1-based page that fails, 0 for none
+}
+
+func (f *fakeSource) ListPosts(ctx context.Context, creatorID, before string, limit int) ([]storage.Post, error) {
+	f.calls++
+	start := 0
+	if before != "" {
+		start = slices.IndexFunc(f.posts, func(p storage.Post) bool { return p.ID == before }) + 1
+	}
+	if f.failPage > 0 && start/limit+1 >= f.failPage {
+		return nil, errors.New("upstream error")
+	}
+	end := min(start+limit, len(f.posts))
+	return slices.Clone(f.posts[start:end]), nil
+}
+
+// newFakeSource creates n posts, p000 being the oldest
+func newFakeSource(n int) *fakeSource {
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	f := &fakeSource{}
+	for i := n - 1; i >= 0; i-- {
+		f.posts = append(f.posts, storage.Post{ID: fmt.Sprintf("p%03d", i), Text: "post", CreatedAt: base.Add(time.Duration(i) * time.Minute)})
+	}
+	return f
+}
+
+// remove deletes the post with the given ID from the source
+func (f *fakeSource) remove(id string) {
+	f.posts = slices.DeleteFunc(f.posts, func(p storage.Post) bool { return p.ID == id })
+}
+
+type testSyncer struct {
+	*Syncer
+	posts  *storage.MemoryPostStore
+	state  *storage.MemoryCreatorSyncStore
+	events []string // "type post_id"
+}
+
+func newTestSyncer(source Source) *testSyncer {
+	ts := &testSyncer{posts: storage.NewMemoryPostStore(), state: storage.NewMemoryCreatorSyncStore()}
+	bus := events.NewBus()
+	bus.Subscribe(func(e events.Event) {
+		ts.events = append(ts.events, e.Type+" "+e.Data["post_id"].(string))
+	})
+	creators := func(ctx context.Context) ([]string, error) { return []string{"c1"}, nil }
+	ts.Syncer = NewSyncer(source, ts.posts, ts.state, creators, bus, logger.New(), time.Minute)
+	return ts
+}
+
+func TestSyncOnce(t *testing.T) {
+	ctx := context.Background()
+	source := newFakeSource(120)
+	s := newTestSyncer(source)
+
+	// The first pass downloads the full history
+	if err := s.SyncOnce(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if source.calls != 3 || len(s.events) != 120 {
+		t.Fatalf("first pass: %d calls, %d events; want 3, 120", source.calls, len(s.events))
+	}
+	state, err := s.state.Get(ctx, "c1")
+	if err != nil || state.Posts != 120 || state.PostsSyncedAt == nil {
+		t.Fatalf("sync state after first pass: %+v, %v", state, err)
+	}
+
+	// Later passes stop at the first page with stored posts and pick up changes in it
+	source.calls, s.events = 0, nil
+	source.remove("p110")
+	source.posts[slices.IndexFunc(source.posts, func(p storage.Post) bool { return p.ID == "p115" })].Text = "edited"
+	source.posts = append([]storage.Post{{ID: "p120", Text: "new", CreatedAt: source.posts[0].CreatedAt.Add(time.Minute)}}, source.posts...)
+	if err := s.SyncOnce(ctx); err != nil {
+		t.Fatal(err)
+	}
+	slices.Sort(s.events)
+	want := []string{"post.deleted p110", "post.synced p115", "post.synced p120"}
+	if source.calls != 1 || !slices.Equal(s.events, want) {
+		t.Fatalf("second pass: %d calls, events %v; want 1, %v", source.calls, s.events, want)
+	}
+	if post, err := s.posts.Get(ctx, "p115"); err != nil || post.Text != "edited" {
+		t.Fatalf("edited post not stored: %v", err)
+	}
+	// Posts older than the page fetched are kept
+	if _, err := s.posts.Get(ctx, "p005"); err != nil {
+		t.Fatalf("older post removed: %v", err)
+	}
+	if state, _ := s.state.Get(ctx, "c1"); state.Posts != 120 {
+		t.Fatalf("stored posts %d, want 120", state.Posts)
+	}
+
+	// Nothing changed, nothing published
+	s.events = nil
+	if err := s.SyncOnce(ctx); err != nil || len(s.events) != 0 {
+		t.Fatalf("unchanged pass: %v, events %v", err, s.events)
+	}
+}
+
+func TestSyncOnceResumesInterruptedHistory(t *testing.T) {
+	ctx := context.Background()
+	source := newFakeSource(120)
+	source.failPage = 2
+	s := newTestSyncer(source)
+
+	if err := s.SyncOnce(ctx); err == nil {
+		t.Fatal("expected an error")
+	}
+	if state, err := s.state.Get(ctx, "c1"); err == nil && state.PostsSyncedAt != nil {
+		t.Fatal("interrupted history reported as synced")
+	}
+
+	// The first page is stored now, but the next pass still pages to the oldest post
+	source.failPage, source.calls = 0, 0
+	if err := s.SyncOnce(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if source.calls != 3 {
+		t.Fatalf("%d calls, want 3", source.calls)
+	}
+	if posts, _ := s.posts.List(ctx); len(posts) != 120 {
+		t.Fatalf("%d posts stored, want 120", len(posts))
+	}
+}
+
+func TestSyncOnceExtractsTags(t *testing.T) {
+	ctx := context.Background()
+	source := &fakeSource{posts: []storage.Post{{ID: "p1", Text: "Out now #NewSet with @Alice", Hashtags: []string{"Beach"}, CreatedAt: time.Now()}}}
+	s := newTestSyncer(source)
+
+	if err := s.SyncOnce(ctx); err != nil {
+		t.Fatal(err)
+	}
+	post, err := s.posts.Get(ctx, "p1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(post.Hashtags, []string{"beach", "newset"}) || !slices.Equal(post.Mentions, []string{"alice"}) {
+		t.Fatalf("hashtags %q, mentions %q", post.Hashtags, post.Mentions)
+	}
+
+	// Extracted tags don't make an unchanged post look edited
+	s.events = nil
+	if err := s.SyncOnce(ctx); err != nil || len(s.events) != 0 {
+		t.Fatalf("unchanged pass: %v, events %v", err, s.events)
+	}
+}
@@ -0,0 +1,79 @@
+package tracing
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestTransport(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	provider := NewProvider(sdktrace.NewSimpleSpanProcessor(exporter), 1, "")
+	prev := otel.GetTracerProvider()
+	otel.SetTracerProvider(provider)
+	t.Cleanup(func() { otel.SetTracerProvider(prev) })
+
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("traceparent") != "" {
+			t.Error("trace context sent to the upstream")
+		}
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer upstream.Close()
+
+	ctx, parent := Start(t.Context(), "parent")
+	req, err := http.NewRequestWithContext(ctx, "GET", upstream.URL+"/api/v1/account", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := (&http.Client{Transport: Transport(nil)}).Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	End(parent, nil)
+
+	spans := exporter.GetSpans()
+	if len(spans) != 2 {
+		t.Fatalf("recorded %d spans, want 2", len(spans))
+	}
+	client := spans[0]
+	if client.Name != "GET /api/v1/account" || client.SpanKind != trace.SpanKindClient {
+		t.Errorf("client span %q of kind %v", client.Name, client.SpanKind)
+	}
+	if client.Parent.SpanID() != spans[1].SpanContext.SpanID() {
+		t.Errorf("client span is not a child of the caller's span")
+	}
+	for _, want := range []attribute.KeyValue{
+		attribute.String("http.request.method", "GET"),
+		attribute.String("url.path", "/api/v1/account"),
+		attribute.Int("http.response.status_code", http.StatusNotFound),
+	} {
+		if !hasAttribute(client.Attributes, want) {
+			t.Errorf("client span lacks %s=%s", want.Key, want.Value.Emit())
+		}
+	}
+	if client.Status.Code != codes.Error {
+		t.Errorf("client span status %v, want error", client.Status)
+	}
+	if !hasAttribute(client.Resource.Attributes(), attribute.String("service.name", instrumentationName)) {
+		t.Errorf("resource %v lacks service.name", client.Resource.Attributes())
+	}
+}
+
+// hasAttribute reports whether attrs contain want
+func hasAttribute(attrs []attribute.KeyValue, want attribute.KeyValue) bool {
+	for _, attr := range attrs {
+		if attr == want {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,231 @@
+// Package validate decodes and checks request parameters against declarative rules
+// given in struct tags.
+//
+// Query parameters are bound with the `query` tag and may have a `default`.
+// Rules are given in the `validate` tag, separated by commas:
+//
+//	required      the value must be present (non-zero for body fields)
+//	min=N, max=N  bounds for numbers; length bounds for strings and slices
+//	oneof=a b c   the value must be one of the space-separated options
+//	dive          apply the remaining rules to each element of a slice
+//
+// For example:
+//
+//	type params struct {
+//		Limit int    `query:"limit" default:"20" validate:"min=1,max=100"`
+//		Order string `query:"order" default:"asc" validate:"oneof=asc desc"`
+//	}
+package validate
+
+import (
+	"fmt"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Error codes reported in FieldError.Code
+const (
+	CodeRequired = "required"
+	CodeInvalid  = "invalid"
+	CodeTooSmall = "too_small"
+	CodeTooLarge = "too_large"
+	CodeNotOneOf = "not_one_of"
+)
+
+// FieldError describes one invalid parameter
+type FieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// Errors is returned when one or more parameters are invalid
+type Errors []FieldError
+
+func (e Errors) Error() string {
+	msgs := make([]string, len(e))
+	for i, fe := range e {
+		msgs[i] = fe.Field + ": " + fe.Message
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// Query binds query parameters into the struct pointed to by dst, applies defaults
+// and validates the result. Invalid parameters are returned as Errors.
+func Query(values url.Values, dst interface{}) error {
+	v := reflect.ValueOf(dst).Elem()
+	t := v.Type()
+
+	var errs Errors
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := field.Tag.Get("query")
+		if name == "" {
+			continue
+		}
+
+		raw, present := values[name]
+		value := ""
+		if present && len(raw) > 0 {
+			value = raw[0]
+		}
+		if value == "" {
+			value, present = field.Tag.Get("default"), false
+		}
+		if value == "" {
+			if hasRule(field.Tag.Get("validate"), "required") {
+				errs = append(errs, FieldError{Field: name, Code: CodeRequired, Message: "is required"})
+			}
+			continue
+		}
+
+		if err := setValue(v.Field(i), value); err != nil {
+			errs = append(errs, FieldError{Field: name, Code: CodeInvalid, Message: err.Error()})
+			continue
+		}
+		errs = append(errs, check(name, v.Field(i), field.Tag.Get("validate"), present)...)
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// Struct validates a decoded request body. Fields are named after their `json` tag.
+func Struct(src interface{}) error {
+	v := reflect.Indirect(reflect.ValueOf(src))
+	t := v.Type()
+
+	var errs Errors
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		rules := field.Tag.Get("validate")
+		if rules == "" {
+			continue
+		}
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "" {
+			name = field.Name
+		}
+		errs = append(errs, check(name, v.Field(i), rules, true)...)
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+func hasRule(rules, name string) bool {
+	for _, rule := range strings.Split(rules, ",") {
+		if rule == name {
+			return true
+		}
+	}
+	return false
+}
+
+// setValue parses a query parameter into a field of a supported kind
+func setValue(field reflect.Value, value string) error {
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(value)
+	case reflect.Int, reflect.Int64:
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return fmt.Errorf("must be an integer")
+		}
+		field.SetInt(n)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("must be true or false")
+		}
+		field.SetBool(b)
+	case reflect.Slice:
+		if field.Type().Elem().Kind() != reflect.String {
+			return fmt.Errorf("unsupported parameter type %s", field.Type())
+		}
+		parts := strings.Split(value, ",")
+		field.Set(reflect.ValueOf(parts))
+	default:
+		return fmt.Errorf("unsupported parameter type %s", field.Type())
+	}
+	return nil
+}
+
+// check applies the rules to one value. Rules after "dive" apply to each slice element.
+// present is false for query parameters filled from their default.
+func check(name string, value reflect.Value, rules string, present bool) Errors {
+	if rules == "" {
+		return nil
+	}
+	list := strings.Split(rules, ",")
+	var errs Errors
+	for i, rule := range list {
+		key, arg, _ := strings.Cut(rule, "=")
+		if key == "dive" {
+			for j := 0; j < value.Len(); j++ {
+				elem := fmt.Sprintf("%s[%d]", name, j)
+				errs = append(errs, check(elem, value.Index(j), strings.Join(list[i+1:], ","), true)...)
+			}
+			return errs
+		}
+		if fe := checkRule(name, value, key, arg, present); fe != nil {
+			// Report only the first failing rule for each value
+			return append(errs, *fe)
+		}
+	}
+	return errs
+}
+
+func checkRule(name string, value reflect.Value, rule, arg string, present bool) *FieldError {
+	switch rule {
+	case "required":
+		if !present || value.IsZero() || (value.Kind() == reflect.Slice && value.Len() == 0) {
+			return &FieldError{Field: name, Code: CodeRequired, Message: "is required"}
+		}
+	case "min", "max":
+		limit, err := strconv.ParseFloat(arg, 64)
+		if err != nil {
+			panic(fmt.Sprintf("validate: invalid %s rule %q on %s", rule, arg, name))
+		}
+		n, unit := measure(value)
+		if rule == "min" && n < limit {
+			return &FieldError{Field: name, Code: CodeTooSmall, Message: fmt.Sprintf("must be at least %s%s", arg, unit)}
+		}
+		if rule == "max" && n > limit {
+			return &FieldError{Field: name, Code: CodeTooLarge, Message: fmt.Sprintf("must be at most %s%s", arg, unit)}
+		}
+	case "oneof":
+		options := strings.Fields(arg)
+		s := fmt.Sprint(value.Interface())
+		for _, option := range options {
+			if s == option {
+				return nil
+			}
+		}
+		return &FieldError{Field: name, Code: CodeNotOneOf, Message: "must be one of: " + strings.Join(options, ", ")}
+	default:
+		panic(fmt.Sprintf("validate: unknown rule %q on %s", rule, name))
+	}
+	return nil
+}
+
+// measure returns the number compared by min and max, and the unit used in messages
+func measure(value reflect.Value) (float64, string) {
+	switch value.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(value.Int()), ""
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(value.Uint()), ""
+	case reflect.Float32, reflect.Float64:
+		return value.Float(), ""
+	case reflect.String:
+		return float64(len([]rune(value.String()))), " characters"
+	case reflect.Slice, reflect.Map:
+		return float64(value.Len()), " items"
+	}
+	return 0, ""
+}
@@ -0,0 +1,131 @@
+package validate
+
+import (
+	"errors"
+	"net/url"
+	"reflect"
+	"testing"
+)
+
+type queryParams struct {
+	Query  string   `query:"q" validate:"required,max=5"`
+	Limit  int      `query:"limit" default:"20" validate:"min=1,max=100"`
+	Order  string   `query:"order" default:"asc" validate:"oneof=asc desc"`
+	Active bool     `query:"active"`
+	Tags   []string `query:"tags" validate:"max=2,dive,min=2"`
+	Token  string   `query:"token" default:"x" validate:"required"`
+}
+
+// codes returns "field code" for each error in err
+func codes(t *testing.T, err error) []string {
+	t.Helper()
+	if err == nil {
+		return nil
+	}
+	var errs Errors
+	if !errors.As(err, &errs) {
+		t.Fatalf("unexpected error type %T: %v", err, err)
+	}
+	out := make([]string, len(errs))
+	for i, fe := range errs {
+		out[i] = fe.Field + " " + fe.Code
+	}
+	return out
+}
+
+func TestQuery(t *testing.T) {
+	tests := []struct {
+		name   string
+		query  string
+		want   []string
+		params queryParams
+	}{
+		{
+			name:   "defaults",
+			query:  "q=abc&token=t",
+			params: queryParams{Query: "abc", Limit: 20, Order: "asc", Token: "t"},
+		},
+		{
+			name:   "explicit values",
+			query:  "q=abc&limit=100&order=desc&active=true&tags=ab,cd&token=t",
+			params: queryParams{Query: "abc", Limit: 100, Order: "desc", Active: true, Tags: []string{"ab", "cd"}, Token: "t"},
+		},
+		// A required parameter isn't satisfied by its default
+		{name: "required", query: "", want: []string{"q required", "token required"}},
+		{name: "empty counts as missing", query: "q=&token=t", want: []string{"q required"}},
+		// max counts characters of strings, not bytes
+		{name: "string max", query: "q=ééééé&token=t", params: queryParams{Query: "ééééé", Limit: 20, Order: "asc", Token: "t"}},
+		{name: "string too long", query: "q=abcdef&token=t", want: []string{"q too_large"}},
+		{name: "int bounds", query: "q=a&limit=0&token=t", want: []string{"limit too_small"}},
+		{name: "int too large", query: "q=a&limit=101&token=t", want: []string{"limit too_large"}},
+		{name: "not an int", query: "q=a&limit=ten&token=t", want: []string{"limit invalid"}},
+		{name: "not a bool", query: "q=a&active=maybe&token=t", want: []string{"active invalid"}},
+		{name: "oneof", query: "q=a&order=up&token=t", want: []string{"order not_one_of"}},
+		{name: "slice max", query: "q=a&tags=ab,cd,ef&token=t", want: []string{"tags too_large"}},
+		{name: "dive", query: "q=a&tags=ab,c&token=t", want: []string{"tags[1] too_small"}},
+	}
+	for _, tt := range tests {
+		values, err := url.ParseQuery(tt.query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var params queryParams
+		err = Query(values, &params)
+		if got := codes(t, err); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: errors %q, want %q", tt.name, got, tt.want)
+			continue
+		}
+		if tt.want == nil && !reflect.DeepEqual(params, tt.params) {
+			t.Errorf("%s: params %+v, want %+v", tt.name, params, tt.params)
+		}
+	}
+}
+
+type body struct {
+	Name   string   `json:"name" validate:"required,max=3"`
+	Count  int      `json:"count,omitempty" validate:"min=0,max=10"`
+	Scopes []string `json:"scopes" validate:"required,dive,oneof=read admin"`
+	Note   string
+	Size   int `validate:"max=5"`
+}
+
+func TestStruct(t *testing.T) {
+	tests := []struct {
+		name string
+		body body
+		want []string
+	}{
+		{name: "valid", body: body{Name: "abc", Count: 10, Scopes: []string{"read", "admin"}}},
+		{name: "required", body: body{}, want: []string{"name required", "scopes required"}},
+		{name: "empty slice is missing", body: body{Name: "a", Scopes: []string{}}, want: []string{"scopes required"}},
+		{name: "bounds", body: body{Name: "abcd", Count: -1, Scopes: []string{"read"}}, want: []string{"name too_large", "count too_small"}},
+		{name: "dive oneof", body: body{Name: "a", Scopes: []string{"read", "write"}}, want: []string{"scopes[1] not_one_of"}},
+		// Fields without a json tag are named after the field
+		{name: "field name", body: body{Name: "a", Scopes: []string{"read"}, Size: 6}, want: []string{"Size too_large"}},
+	}
+	for _, tt := range tests {
+		if got := codes(t, Struct(&tt.body)); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: errors %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestUnknownRulePanics(t *testing.T) {
+	for _, src := range []interface{}{
+		&struct {
+			Name string `json:"name" validate:"requried"`
+		}{Name: "a"},
+		&struct {
+			Count int `json:"count" validate:"min=one"`
+		}{},
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%T: expected a panic", src)
+				}
+			}()
+			Struct(src)
+		}()
+	}
+}
@@ -0,0 +1,312 @@
+// Package vault downloads purchased media into the data directory
+package vault
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"fansly-api/internal/health"
+	"fansly-api/internal/logger"
+	"fansly-api/internal/media"
+	"fansly-api/internal/service"
+	"fansly-api/internal/storage"
+)
+
+// ErrJobRunning is returned when a job is started while another is running
+var ErrJobRunning = errors.New("a download job is already running")
+
+// ErrJobNotRunning is returned when cancelling a job that isn't running
+var ErrJobNotRunning = errors.New("download job is not running")
+
+// urlMaxAge is how long download URLs are used before they are looked up again;
+// Fansly's signed URLs expire
+const urlMaxAge = 10 * time.Minute
+
+// Source lists the purchased media with current download URLs
+type Source interface {
+	ListPurchases(ctx context.Context) ([]service.VaultItem, error)
+}
+
+// Downloader runs one download job at a time in the background. Files are saved as
+// <dir>/<creator ID>/<media ID><ext>; items already on disk are skipped, so a job
+// that failed or was interrupted can simply be started again. The sync state of
+// each creator in a job is updated as it starts and finishes. A download that
+// receives no data for media.IdleTimeout fails, and download URLs older than
+// urlMaxAge are looked up again before use.
+type Downloader struct {
+	fetcher     media.Fetcher
+	source      Source
+	jobs        storage.DownloadJobStore
+	creators    storage.CreatorSyncStore
+	dir         string
+	log         logger.Logger
+	idleTimeout time.Duration
+	urlMaxAge   time.Duration
+	heartbeat   *health.Heartbeat
+
+	mu      sync.Mutex
+	running string // ID of the running job, empty when idle
+	cancel  context.CancelFunc
+	done    chan struct{}
+}
+
+// NewDownloader creates a downloader that saves files below dir and looks up
+// expired download URLs in source
+func NewDownloader(fetcher media.Fetcher, source Source, jobs storage.DownloadJobStore, creators storage.CreatorSyncStore, dir string, log logger.Logger) *Downloader {
+	return &Downloader{
+		fetcher:     fetcher,
+		source:      source,
+		jobs:        jobs,
+		creators:    creators,
+		dir:         dir,
+		log:         log,
+		idleTimeout: media.IdleTimeout,
+		urlMaxAge:   urlMaxAge,
+		// A running job makes progress at least every idle timeout, as a stalled
+		// download is aborted then
+		heartbeat: health.NewHeartbeat(2 * media.IdleTimeout),
+	}
+}
+
+// Check is a readiness check failing when the running job has stopped making
+// progress; it passes while no job is running
+func (d *Downloader) Check(ctx context.Context) error {
+	d.mu.Lock()
+	running := d.running != ""
+	d.mu.Unlock()
+	if !running {
+		return nil
+	}
+	return d.heartbeat.Check(ctx)
+}
+
+// Start records a new job downloading items and runs it in the background
+func (d *Downloader) Start(ctx context.Context, id, createdBy string, items []service.VaultItem) (*storage.DownloadJob, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.running != "" {
+		return nil, ErrJobRunning
+	}
+
+	job := &storage.DownloadJob{
+		ID:        id,
+		Status:    storage.JobStatusRunning,
+		Total:     len(items),
+		CreatedBy: createdBy,
+		CreatedAt: time.Now().UTC(),
+	}
+	if err := d.jobs.Put(ctx, job); err != nil {
+		return nil, fmt.Errorf("error storing download job: %w", err)
+	}
+
+	runCtx, cancel := context.WithCancel(context.Background())
+	d.running, d.cancel, d.done = id, cancel, make(chan struct{})
+	d.heartbeat.Beat()
+	// The job looks up expired URLs in its own copy of the items
+	go d.run(runCtx, *job, slices.Clone(items), d.done)
+	return job, nil
+}
+
+// Cancel stops the running job with the given ID. The job is marked cancelled
+// once the download in progress has been aborted.
+func (d *Downloader) Cancel(id string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.running != id {
+		return ErrJobNotRunning
+	}
+	d.cancel()
+	return nil
+}
+
+// Stop cancels the running job, if any, and waits for it to finish
+func (d *Downloader) Stop() {
+	d.mu.Lock()
+	cancel, done := d.cancel, d.done
+	d.mu.Unlock()
+	if cancel != nil {
+		cancel()
+		<-done
+	}
+}
+
+func (d *Downloader) run(ctx context.Context, job storage.DownloadJob, items []service.VaultItem, done chan struct{}) {
+	defer close(done)
+	d.log.Infof("Download job %s started: %d items", job.ID, job.Total)
+	creators := d.startCreatorSync(items)
+	failures := make(map[string]string) // last error by creator ID
+
+	// The URLs were looked up when the job was started
+	resolved := time.Now()
+	for i := range items {
+		if time.Since(resolved) > d.urlMaxAge {
+			d.resolveURLs(ctx, job.ID, items[i:])
+			resolved = time.Now()
+		}
+		item := items[i]
+		n, skipped, err := d.download(ctx, item)
+		d.heartbeat.Beat()
+		if ctx.Err() != nil {
+			break
+		}
+		switch {
+		case err != nil:
+			job.Failed++
+			job.LastError = fmt.Sprintf("media %s: %v", item.MediaID, err)
+			failures[item.CreatorID] = job.LastError
+			d.log.Warnf("Download job %s: failed to download media %s: %v", job.ID, item.MediaID, err)
+		case skipped:
+			job.Skipped++
+		default:
+			job.Downloaded++
+			job.Bytes += n
+		}
+		d.save(&job)
+	}
+
+	switch {
+	case ctx.Err() != nil:
+		job.Status = storage.JobStatusCancelled
+	case job.Failed > 0:
+		job.Status = storage.JobStatusFailed
+	default:
+		job.Status = storage.JobStatusCompleted
+	}
+	finished := time.Now().UTC()
+	job.FinishedAt = &finished
+	d.save(&job)
+	d.finishCreatorSync(creators, failures, ctx.Err() != nil, finished)
+	d.log.Infof("Download job %s %s: %d downloaded, %d skipped, %d failed",
+		job.ID, job.Status, job.Downloaded, job.Skipped, job.Failed)
+
+	d.mu.Lock()
+	d.running, d.cancel = "", nil
+	d.mu.Unlock()
+}
+
+// resolveURLs replaces the download URLs of items with current ones. On failure
+// the old URLs are kept; downloads with expired URLs fail and are retried by the
+// next job.
+func (d *Downloader) resolveURLs(ctx context.Context, jobID string, items []service.VaultItem) {
+	current, err := d.source.ListPurchases(ctx)
+	if err != nil {
+		if ctx.Err() == nil {
+			d.log.Warnf("Download job %s: failed to refresh download URLs: %v", jobID, err)
+		}
+		return
+	}
+	urls := make(map[string]string, len(current))
+	for _, item := range current {
+		urls[item.MediaID] = item.URL
+	}
+	for i := range items {
+		if u := urls[items[i].MediaID]; u != "" {
+			items[i].URL = u
+		}
+	}
+}
+
+// save records the progress of a job; a failure only loses progress information
+func (d *Downloader) save(job *storage.DownloadJob) {
+	if err := d.jobs.Put(context.Background(), job); err != nil {
+		d.log.Warnf("Failed to save download job %s: %v", job.ID, err)
+	}
+}
+
+// startCreatorSync marks the creators of items as syncing and returns their IDs
+func (d *Downloader) startCreatorSync(items []service.VaultItem) []string {
+	var creators []string
+	seen := make(map[string]bool)
+	for _, item := range items {
+		if seen[item.CreatorID] || !media.ValidID(item.CreatorID) {
+			continue
+		}
+		seen[item.CreatorID] = true
+		creators = append(creators, item.CreatorID)
+		d.updateCreatorSync(item.CreatorID, func(state *storage.CreatorSync) {
+			state.Status = storage.SyncStatusSyncing
+		})
+	}
+	return creators
+}
+
+// finishCreatorSync records the outcome of a job for each of its creators. The
+// downloaded totals are counted on disk, so files from earlier jobs are included.
+func (d *Downloader) finishCreatorSync(creators []string, failures map[string]string, cancelled bool, finished time.Time) {
+	for _, id := range creators {
+		files, bytes, err := countFiles(filepath.Join(d.dir, id))
+		if err != nil {
+			d.log.Warnf("Failed to count downloaded media of creator %s: %v", id, err)
+		}
+		d.updateCreatorSync(id, func(state *storage.CreatorSync) {
+			state.DownloadedMedia, state.DownloadedBytes = files, bytes
+			switch {
+			case failures[id] != "":
+				state.Status = storage.SyncStatusFailed
+				state.LastError = failures[id]
+			case cancelled:
+				state.Status = storage.SyncStatusIdle
+			default:
+				state.Status = storage.SyncStatusIdle
+				state.LastError = ""
+				state.LastSyncedAt = &finished
+			}
+		})
+	}
+}
+
+// updateCreatorSync applies update to the stored sync state of a creator; a failure
+// only loses status information
+func (d *Downloader) updateCreatorSync(id string, update func(state *storage.CreatorSync)) {
+	if err := d.creators.Update(context.Background(), id, update); err != nil {
+		d.log.Warnf("Failed to save sync state of creator %s: %v", id, err)
+	}
+}
+
+// countFiles returns the number and total size of the downloaded files in dir,
+// ignoring downloads in progress
+func countFiles(dir string) (files int, bytes int64, err error) {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, 0, nil
+	}
+	if err != nil {
+		return 0, 0, err
+	}
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		files++
+		bytes += info.Size()
+	}
+	return files, bytes, nil
+}
+
+// download saves one item and returns its size, or skipped when it is already on disk
+func (d *Downloader) download(ctx context.Context, item service.VaultItem) (n int64, skipped bool, err error) {
+	file, err := media.Path(item.CreatorID, item.MediaID, item.Type, item.URL)
+	if err != nil {
+		return 0, false, err
+	}
+	dest := filepath.Join(d.dir, file)
+	if _, err := os.Stat(dest); err == nil {
+		return 0, true, nil
+	}
+	if item.URL == "" {
+		return 0, false, errors.New("no download URL")
+	}
+	n, err = media.Save(ctx, d.fetcher, item.URL, dest, d.idleTimeout, d.heartbeat.Beat)
+	return n, false, err
+}
@@ -0,0 +1,141 @@
+package vault
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"fansly-api/internal/logger"
+	"fansly-api/internal/service"
+	"fansly-api/internal/storage"
+)
+
+// fakeFetcher writes the URL as the file content, failing for URLs containing
+// "fail" and hanging for URLs containing "hang"
+type fakeFetcher struct{}
+
+func (fakeFetcher) Download(ctx context.Context, mediaURL string, w io.Writer) (int64, error) {
+	if strings.Contains(mediaURL, "fail") {
+		return 0, errors.New("upstream error")
+	}
+	if strings.Contains(mediaURL, "hang") {
+		<-ctx.Done()
+		return 0, ctx.Err()
+	}
+	n, err := io.WriteString(w, mediaURL)
+	return int64(n), err
+}
+
+// fakeSource returns the purchases with fresh URLs
+type fakeSource struct {
+	items []service.VaultItem
+	calls int
+}
+
+func (f *fakeSource) ListPurchases(ctx context.Context) ([]service.VaultItem, error) {
+	f.calls++
+	return f.items, nil
+}
+
+// runJob starts a job downloading items and waits for it to finish
+func runJob(t *testing.T, d *Downloader, id string, items []service.VaultItem) {
+	t.Helper()
+	if _, err := d.Start(context.Background(), id, "user", items); err != nil {
+		t.Fatal(err)
+	}
+	d.mu.Lock()
+	done := d.done
+	d.mu.Unlock()
+	<-done
+}
+
+func TestDownloaderUpdatesCreatorSync(t *testing.T) {
+	creators := storage.NewMemoryCreatorSyncStore()
+	d := NewDownloader(fakeFetcher{}, &fakeSource{}, storage.NewMemoryDownloadJobStore(), creators, t.TempDir(), logger.New())
+
+	runJob(t, d, "job-1", []service.VaultItem{
+		{MediaID: "m1", CreatorID: "alice", Type: "image", URL: "https://cdn.example.com/m1.jpg"},
+		{MediaID: "m2", CreatorID: "alice", Type: "video", URL: "https://cdn.example.com/m2.mp4"},
+		{MediaID: "m3", CreatorID: "bob", Type: "image", URL: "https://cdn.example.com/fail.jpg"},
+	})
+
+	alice, err := creators.Get(context.Background(), "alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if alice.Status != storage.SyncStatusIdle || alice.LastSyncedAt == nil || alice.DownloadedMedia != 2 ||
+		alice.DownloadedBytes != int64(len("https://cdn.example.com/m1.jpg")+len("https://cdn.example.com/m2.mp4")) {
+		t.Errorf("alice: %+v", alice)
+	}
+	bob, err := creators.Get(context.Background(), "bob")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bob.Status != storage.SyncStatusFailed || bob.LastSyncedAt != nil || bob.LastError == "" || bob.DownloadedMedia != 0 {
+		t.Errorf("bob: %+v", bob)
+	}
+
+	// Files already on disk still count after a job that skips them
+	runJob(t, d, "job-2", []service.VaultItem{
+		{MediaID: "m1", CreatorID: "alice", Type: "image", URL: "https://cdn.example.com/m1.jpg"},
+	})
+	if alice, _ := creators.Get(context.Background(), "alice"); alice.DownloadedMedia != 2 {
+		t.Errorf("alice after resume: %d media, want 2", alice.DownloadedMedia)
+	}
+}
+
+func TestDownloaderAbortsStalledDownloads(t *testing.T) {
+	jobs := storage.NewMemoryDownloadJobStore()
+	d := NewDownloader(fakeFetcher{}, &fakeSource{}, jobs, storage.NewMemoryCreatorSyncStore(), t.TempDir(), logger.New())
+	d.idleTimeout = 20 * time.Millisecond
+
+	runJob(t, d, "job-1", []service.VaultItem{
+		{MediaID: "m1", CreatorID: "alice", Type: "image", URL: "https://cdn.example.com/hang.jpg"},
+		{MediaID: "m2", CreatorID: "alice", Type: "image", URL: "https://cdn.example.com/m2.jpg"},
+	})
+
+	job, err := jobs.Get(context.Background(), "job-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if job.Status != storage.JobStatusFailed || job.Failed != 1 || job.Downloaded != 1 || !strings.Contains(job.LastError, "stalled") {
+		t.Fatalf("job: %+v", job)
+	}
+	// An idle downloader is healthy
+	if err := d.Check(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDownloaderRefreshesExpiredURLs(t *testing.T) {
+	jobs := storage.NewMemoryDownloadJobStore()
+	source := &fakeSource{items: []service.VaultItem{
+		{MediaID: "m1", CreatorID: "alice", Type: "image", URL: "https://cdn.example.com/m1.jpg?sig=new"},
+		{MediaID: "m2", CreatorID: "alice", Type: "image", URL: "https://cdn.example.com/m2.jpg?sig=new"},
+	}}
+	dir := t.TempDir()
+	d := NewDownloader(fakeFetcher{}, source, jobs, storage.NewMemoryCreatorSyncStore(), dir, logger.New())
+	d.urlMaxAge = 0
+
+	// The URLs captured when the job was started have expired
+	runJob(t, d, "job-1", []service.VaultItem{
+		{MediaID: "m1", CreatorID: "alice", Type: "image", URL: "https://cdn.example.com/m1.jpg?sig=fail"},
+		{MediaID: "m2", CreatorID: "alice", Type: "image", URL: "https://cdn.example.com/m2.jpg?sig=fail"},
+	})
+
+	job, err := jobs.Get(context.Background(), "job-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if job.Status != storage.JobStatusCompleted || job.Downloaded != 2 || source.calls != 2 {
+		t.Fatalf("job: %+v, %d URL lookups", job, source.calls)
+	}
+	if data, err := os.ReadFile(filepath.Join(dir, "alice", "m2.jpg")); err != nil || string(data) != source.items[1].URL {
+		t.Fatalf("downloaded file: %q, %v", data, err)
+	}
+}